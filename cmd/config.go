@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/leandrodaf/pianalyze/internal/config"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+)
+
+// loadOptions builds Options from the given option functions and the configuration file, exiting with a
+// clear error when the configuration is invalid.
+func loadOptions(opts ...Option) Options {
+	options, err := applyConfig(applyOptions(opts...))
+	if err != nil {
+		// Printed as is rather than logged, as the logger itself may be what is misconfigured.
+		fmt.Fprintf(os.Stderr, "%s:\n%v\n", constants.MsgConfigError, err)
+		os.Exit(1)
+	}
+	return options
+}
+
+// applyConfig fills the settings of options that were not given on the command line from the selected
+// profile of the configuration file. A missing default file is not an error.
+func applyConfig(options Options) (Options, error) {
+	path := options.ConfigPath
+	if path == "" {
+		path = os.Getenv(constants.EnvConfig)
+	}
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return options, nil
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		if options.ConfigProfile != "" {
+			return options, fmt.Errorf("%w: profile %q requested but %s does not exist",
+				config.ErrInvalidConfig, options.ConfigProfile, path)
+		}
+		return options, nil
+	}
+	if err != nil {
+		return options, err
+	}
+	profile, err := cfg.Profile(options.ConfigProfile)
+	if err != nil {
+		return options, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Settings given as options, such as command line flags, take precedence over the profile, even when empty.
+	if !options.explicit[settingDevice] && profile.Device != "" {
+		options.Device = profile.Device
+	}
+	if !options.explicit[settingSinks] && len(profile.Sinks) > 0 {
+		options.Sinks = profile.Sinks
+	}
+	if !options.explicit[settingWebSocket] && profile.WebSocket != "" {
+		options.WebSocketAddr = profile.WebSocket
+	}
+	if !options.explicit[settingDuration] && profile.Duration > 0 {
+		options.Duration = profile.Duration
+	}
+	if !options.explicit[settingLogLevel] && profile.Logging.Level != "" {
+		options.LogLevel = profile.Logging.Level
+	}
+
+	// Settings only available in the configuration file.
+	for _, stage := range profile.Stages {
+		options.Stages = append(options.Stages, stage.Spec())
+	}
+	options.Disabled = profile.DisabledStages
+	if profile.BufferSize > 0 {
+		options.BufferSize = profile.BufferSize
+	}
+	if len(profile.Events) > 0 {
+		if options.Events, err = config.EventCommands(profile.Events); err != nil {
+			return options, err
+		}
+	}
+	if profile.PollInterval > 0 {
+		options.PollInterval = profile.PollInterval
+	}
+	if profile.ShutdownTimeout > 0 {
+		options.ShutdownTimeout = profile.ShutdownTimeout
+	}
+	if profile.Logging.Mode != "" {
+		options.LogMode = profile.Logging.Mode
+	}
+	if profile.Logging.Output != "" {
+		options.LogOutput = profile.Logging.Output
+	}
+	return options, nil
+}
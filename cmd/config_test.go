@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+default: studio
+profiles:
+  studio:
+    device: Yamaha
+    duration: 10m
+    websocket: ":9000"
+    logging:
+      level: debug
+`
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantDevice   string
+		wantDuration time.Duration
+		wantWS       string
+		wantLevel    string
+	}{
+		{
+			name:         "profile fills unset settings",
+			wantDevice:   "Yamaha",
+			wantDuration: 10 * time.Minute,
+			wantWS:       ":9000",
+			wantLevel:    "debug",
+		},
+		{
+			name:         "explicit settings win",
+			opts:         []Option{WithDevice("Roland"), WithDuration(time.Minute), WithLogLevel("warn")},
+			wantDevice:   "Roland",
+			wantDuration: time.Minute,
+			wantWS:       ":9000",
+			wantLevel:    "warn",
+		},
+		{
+			name:         "explicit empty settings win",
+			opts:         []Option{WithDevice(""), WithDuration(0), WithWebSocket("")},
+			wantDevice:   "",
+			wantDuration: 0,
+			wantWS:       "",
+			wantLevel:    "debug",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := applyOptions(append(tt.opts, WithConfig(path, ""))...)
+			options, err := applyConfig(options)
+			if err != nil {
+				t.Fatalf("applyConfig: %v", err)
+			}
+			if options.Device != tt.wantDevice || options.Duration != tt.wantDuration ||
+				options.WebSocketAddr != tt.wantWS || options.LogLevel != tt.wantLevel {
+				t.Errorf("got device %q, duration %v, websocket %q, level %q; want %q, %v, %q, %q",
+					options.Device, options.Duration, options.WebSocketAddr, options.LogLevel,
+					tt.wantDevice, tt.wantDuration, tt.wantWS, tt.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/pipeline"
+	internalContext "github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/smf"
+	"go.uber.org/zap"
+)
+
+// eventLoop is the goroutine running captured events through the pipeline.
+type eventLoop struct {
+	events   chan contracts.MIDI
+	recorder *smf.Recorder
+	receive  sync.Mutex                     // Held while events are taken and recorded, so they are recorded in order.
+	abort    chan struct{}                  // Closed to drop the events not processed yet.
+	done     chan struct{}                  // Closed once the goroutine returns.
+	current  atomic.Pointer[contracts.MIDI] // Event being processed, if any.
+}
+
+// processEvents starts running every event received on eventChannel through the pipeline until the channel is
+// closed. When recorder is not nil, each event is recorded before being processed, and the events dropped by
+// drain are still recorded, so the recording holds every captured event.
+func processEvents(ctx context.Context, logger *zap.Logger, processor *pipeline.Processor, eventChannel chan contracts.MIDI, recorder *smf.Recorder) *eventLoop {
+	loop := &eventLoop{
+		events:   eventChannel,
+		recorder: recorder,
+		abort:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(loop.done)
+		for {
+			event, ok := loop.next()
+			if !ok {
+				return
+			}
+			loop.current.Store(&event)
+			pipelineCtx := internalContext.NewPipelineContext(ctx, event)
+			if err := processor.Process(pipelineCtx); err != nil {
+				logger.Error(constants.MsgMIDIProcessingError, zap.Error(err))
+			}
+			loop.current.Store(nil)
+		}
+	}()
+	return loop
+}
+
+// drain waits up to timeout for the events left in the closed event channel to be processed, then drops the
+// remaining ones, recording them all the same. The event being processed gets the same time again to complete; a stage stuck on it is
+// reported and left behind, so shutdown carries on.
+func (l *eventLoop) drain(logger *zap.Logger, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-l.done:
+		return
+	case <-timer.C:
+	}
+
+	close(l.abort)
+	logger.Warn(constants.MsgDrainTimeout, zap.Int("dropped", l.recordRest()))
+	timer.Reset(timeout)
+	select {
+	case <-l.done:
+	case <-timer.C:
+		fields := []zap.Field{zap.Duration("timeout", timeout)}
+		if event := l.current.Load(); event != nil {
+			fields = append(fields, zap.Uint8("command", event.Command), zap.Uint8("note", event.Note),
+				zap.Uint64("timestamp", event.Timestamp))
+		}
+		logger.Error(constants.MsgEventStuck, fields...)
+	}
+}
+
+// next takes the next event to process and records it. It returns false once the event channel is closed and
+// empty, or processing is aborted.
+func (l *eventLoop) next() (contracts.MIDI, bool) {
+	l.receive.Lock()
+	defer l.receive.Unlock()
+	select {
+	case <-l.abort:
+		return contracts.MIDI{}, false
+	case event, ok := <-l.events:
+		if ok && l.recorder != nil {
+			l.recorder.Record(event)
+		}
+		return event, ok
+	}
+}
+
+// recordRest takes the events left in the closed event channel without processing them, recording them if a
+// recorder is set, and returns how many there were.
+func (l *eventLoop) recordRest() int {
+	l.receive.Lock()
+	defer l.receive.Unlock()
+	n := 0
+	for event := range l.events {
+		n++
+		if l.recorder != nil {
+			l.recorder.Record(event)
+		}
+	}
+	return n
+}
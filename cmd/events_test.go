@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/pipeline"
+	internalContext "github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/smf"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// stuckStage never returns from Process until release is closed.
+type stuckStage struct{ release chan struct{} }
+
+func (s stuckStage) Process(*internalContext.PipelineContext, *store.State) error {
+	<-s.release
+	return nil
+}
+
+var stuckRelease = make(chan struct{})
+
+func init() {
+	pipeline.Register("cmdTestStuck", struct{}{}, func(pipeline.StageEnv, struct{}) (pipeline.AnalysisStage, error) {
+		return stuckStage{release: stuckRelease}, nil
+	})
+}
+
+func TestEventLoopDrainWithStuckStage(t *testing.T) {
+	defer close(stuckRelease)
+	core, logs := observer.New(zap.WarnLevel)
+	logger := zap.New(core)
+	processor, err := pipeline.NewProcessor(zap.NewNop(), pipeline.WithStages("cmdTestStuck"))
+	if err != nil {
+		t.Fatalf("NewProcessor: %v", err)
+	}
+
+	eventChannel := make(chan contracts.MIDI, 10)
+	for note := byte(60); note < 65; note++ {
+		eventChannel <- contracts.MIDI{Command: byte(contracts.NoteOn), Note: note, Velocity: 90}
+	}
+	close(eventChannel)
+	recorder := smf.NewRecorder(filepath.Join(t.TempDir(), "session.mid"))
+	loop := processEvents(context.Background(), logger, processor, eventChannel, recorder)
+
+	start := time.Now()
+	loop.drain(logger, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("drain took %v with a stuck stage", elapsed)
+	}
+	dropped := logs.FilterMessage(constants.MsgDrainTimeout).All()
+	if len(dropped) != 1 || dropped[0].ContextMap()["dropped"] != int64(4) {
+		t.Errorf("dropped events not reported: %v", dropped)
+	}
+	// Events dropped without being processed are still part of the recording.
+	if recorder.Len() != 5 {
+		t.Errorf("recorded %d events, want 5", recorder.Len())
+	}
+	stuck := logs.FilterMessage(constants.MsgEventStuck).All()
+	if len(stuck) != 1 || stuck[0].ContextMap()["note"] != uint8(60) {
+		t.Errorf("stuck event not reported: %v", stuck)
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/drill"
+	"github.com/leandrodaf/pianalyze/internal/lesson"
+	internalMidi "github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"go.uber.org/zap"
+)
+
+// exercise is an interactive session driven by the pipeline results, such as a lesson or a drill.
+type exercise interface {
+	sink.Sink
+	Start()
+	Done() <-chan struct{}
+}
+
+// newExercise creates the lesson or drill configured in options, printing its feedback to out. Returns nil
+// when none is configured.
+func newExercise(logger *zap.Logger, options Options, out io.Writer) (exercise, error) {
+	switch {
+	case options.LessonPath != "":
+		l, err := lesson.Load(options.LessonPath)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info(constants.MsgLessonLoaded,
+			zap.String("path", options.LessonPath),
+			zap.String("title", l.Title),
+			zap.Int("steps", len(l.Steps)))
+		return lesson.NewRunner(l, out), nil
+	case options.Drill == "chords":
+		difficulty, err := drill.ParseDifficulty(options.Difficulty)
+		if err != nil {
+			return nil, err
+		}
+		prompts := drill.ChordPrompts(difficulty, options.Inversions)
+		next, err := drill.ChordGenerator(prompts, newRand())
+		if err != nil {
+			return nil, err
+		}
+		d := drill.New("chords ("+string(difficulty)+")", next, out)
+		return startDrill(logger, d, options, zap.String("difficulty", string(difficulty)), zap.Int("chords", len(prompts)))
+	case options.Drill == "scales":
+		scales, tonic, err := scaleDrillSettings(options)
+		if err != nil {
+			return nil, err
+		}
+		next, err := drill.ScaleGenerator(scales, tonic, options.Octaves, newRand())
+		if err != nil {
+			return nil, err
+		}
+		d := drill.New("scales", next, out)
+		return startDrill(logger, d, options, zap.Strings("scales", scales), zap.Int("octaves", options.Octaves))
+	case options.Drill != "":
+		return nil, fmt.Errorf("unknown drill %q", options.Drill)
+	default:
+		return nil, nil
+	}
+}
+
+// startDrill applies the rounds configured in options to d and logs its settings.
+func startDrill(logger *zap.Logger, d *drill.Drill, options Options, fields ...zap.Field) (exercise, error) {
+	if options.Rounds > 0 {
+		d.Rounds = options.Rounds
+	}
+	fields = append([]zap.Field{zap.String("drill", options.Drill), zap.Int("rounds", d.Rounds)}, fields...)
+	logger.Info(constants.MsgDrillStarted, fields...)
+	return d, nil
+}
+
+// scaleDrillSettings parses the scales and tonic of a scale drill. A tonic of -1 picks a random tonic per round.
+func scaleDrillSettings(options Options) ([]string, int, error) {
+	scales := internalMidi.ScaleTypes()
+	if options.Scale != "" {
+		scale, err := internalMidi.ParseScaleType(options.Scale)
+		if err != nil {
+			return nil, 0, err
+		}
+		scales = []string{scale}
+	}
+
+	tonic := -1
+	if options.Tonic != "" {
+		note, ok := internalMidi.ParseNoteName(options.Tonic)
+		if !ok {
+			// A bare pitch class starts on the octave of middle C.
+			if note, ok = internalMidi.ParseNoteName(options.Tonic + "4"); !ok {
+				return nil, 0, fmt.Errorf("invalid tonic %q", options.Tonic)
+			}
+		}
+		tonic = note
+	}
+
+	return scales, tonic, nil
+}
+
+// newRand returns a randomly seeded generator for drill prompts.
+func newRand() *rand.Rand {
+	return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+// exerciseSinks returns the exercise as a sink list, or nil without an exercise.
+func exerciseSinks(e exercise) []sink.Sink {
+	if e == nil {
+		return nil
+	}
+	return []sink.Sink{e}
+}
+
+// exerciseStages returns the pipeline stages whose results the exercises among sinks read: the chords for the
+// chord steps of a lesson and the key for its key steps. Note and melody steps and the drills only read the
+// notes played, which every processor tracks.
+func exerciseStages(sinks []sink.Sink) []string {
+	var stages []string
+	for _, s := range sinks {
+		runner, ok := s.(*lesson.Runner)
+		if !ok {
+			continue
+		}
+		if runner.Lesson().HasStep(lesson.StepChord) {
+			stages = append(stages, pipeline.StageChord)
+		}
+		if runner.Lesson().HasStep(lesson.StepKey) {
+			stages = append(stages, pipeline.StageKey)
+		}
+	}
+	return stages
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"io"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/leandrodaf/pianalyze/internal/drill"
+	"github.com/leandrodaf/pianalyze/internal/lesson"
+	"github.com/leandrodaf/pianalyze/internal/pipeline"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+func TestExerciseStages(t *testing.T) {
+	runner := func(steps string) sink.Sink {
+		l, err := lesson.Parse(strings.NewReader(`{"title": "Test", "steps": [` + steps + `]}`))
+		if err != nil {
+			t.Fatalf("lesson.Parse: %v", err)
+		}
+		return lesson.NewRunner(l, io.Discard)
+	}
+	chordDrill := func() sink.Sink {
+		next, err := drill.ChordGenerator(drill.ChordPrompts(drill.Triads, false), rand.New(rand.NewPCG(1, 2)))
+		if err != nil {
+			t.Fatalf("ChordGenerator: %v", err)
+		}
+		return drill.New("chords", next, io.Discard)
+	}
+
+	tests := []struct {
+		name  string
+		sinks []sink.Sink
+		want  []string
+	}{
+		{name: "no exercise", sinks: nil, want: nil},
+		{name: "chord drill", sinks: []sink.Sink{chordDrill()}, want: nil},
+		{name: "melody lesson", sinks: []sink.Sink{runner(`{"type": "melody", "notes": ["C4", "D4"]}`)}, want: nil},
+		{
+			name:  "chord lesson",
+			sinks: []sink.Sink{runner(`{"type": "note", "notes": ["C4"]}, {"type": "chord", "quality": "Major"}`)},
+			want:  []string{pipeline.StageChord},
+		},
+		{
+			name:  "key lesson",
+			sinks: []sink.Sink{runner(`{"type": "key", "key": "G major"}`)},
+			want:  []string{pipeline.StageKey},
+		},
+		{
+			name:  "chord and key lesson",
+			sinks: []sink.Sink{runner(`{"type": "key", "key": "G major"}, {"type": "chord", "quality": "Minor"}`)},
+			want:  []string{pipeline.StageChord, pipeline.StageKey},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exerciseStages(tt.sinks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exerciseStages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newLogger creates a logger like InitLogger with the level, mode and output configured in options. While the
+// terminal UI is active, logs meant for the terminal are written to a file instead.
+func newLogger(options Options) (*zap.Logger, error) {
+	mode := options.LogMode
+	if mode == "" {
+		mode = BuildMode
+	}
+	config := zap.NewDevelopmentConfig()
+	if mode == constants.BuildModeProduction {
+		config = zap.NewProductionConfig()
+	}
+	if options.LogLevel != "" {
+		level, err := zapcore.ParseLevel(options.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		config.Level = zap.NewAtomicLevelAt(level)
+	}
+	output := options.LogOutput
+	if options.TUI && (output == "" || output == "stdout" || output == "stderr") {
+		output = tuiLogPath()
+	}
+	if output != "" {
+		config.OutputPaths = []string{output}
+		config.ErrorOutputPaths = []string{output}
+	}
+	return config.Build()
+}
+
+// clientLogLevel returns the MIDI client log level matching the level configured in options.
+func clientLogLevel(options Options) contracts.LogLevel {
+	level, err := zapcore.ParseLevel(options.LogLevel)
+	if err != nil {
+		return contracts.InfoLevel
+	}
+	switch {
+	case level <= zapcore.DebugLevel:
+		return contracts.DebugLevel
+	case level == zapcore.InfoLevel:
+		return contracts.InfoLevel
+	case level == zapcore.WarnLevel:
+		return contracts.WarnLevel
+	default:
+		return contracts.ErrorLevel
+	}
+}
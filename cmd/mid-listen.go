@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
 	"os/signal"
 	"sync"
@@ -11,26 +13,50 @@ import (
 	"github.com/leandrodaf/midi/sdk/contracts"
 	"github.com/leandrodaf/midi/sdk/midi"
 	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/device"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/game"
+	internalMidi "github.com/leandrodaf/pianalyze/internal/midi"
 	"github.com/leandrodaf/pianalyze/internal/pipeline"
-	internalContext "github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/progress"
+	"github.com/leandrodaf/pianalyze/internal/server"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"github.com/leandrodaf/pianalyze/internal/smf"
+	"github.com/leandrodaf/pianalyze/internal/tui"
+	"github.com/leandrodaf/pianalyze/internal/virtual"
 	"go.uber.org/zap"
 )
 
 // Start initializes MIDI event capture and sets up a pipeline to process the captured events.
-func Start() {
-	logger := InitLogger()
+func Start(opts ...Option) {
+	options := loadOptions(opts...)
+	// The terminal UI owns the screen, so logs go to a file while it is active.
+	logger, err := newLogger(options)
+	if err != nil {
+		InitLogger().Error(constants.MsgLoggerSetupError, zap.Error(err))
+		return
+	}
+
+	// Load the achievements up front, so a bad file is reported before the session is played rather than after.
+	var achievements []game.Achievement
+	if options.Profile != "" || options.Achievements != "" {
+		if achievements, err = loadAchievements(options); err != nil {
+			logger.Error(constants.MsgAchievementsLoadError, zap.String("path", options.Achievements), zap.Error(err))
+			return
+		}
+	}
 
 	// Configure MIDI client with specific logging level and event filters.
-	midiClient, err := midi.NewMIDIClient(
-		contracts.WithLogLevel(contracts.InfoLevel),
-		contracts.WithMIDIEventFilter(contracts.MIDIEventFilter{
-			Commands: []contracts.MIDICommand{contracts.NoteOn, contracts.NoteOff},
-		}),
-	)
+	client, err := newMIDIClient(options)
 	if err != nil {
 		logger.Error(constants.MsgMIDIClientSetupError, zap.Error(err))
 		return
 	}
+	// Keep capturing into the same pipeline if the device is unplugged and plugged back in.
+	midiClient := device.NewSupervisor(logger, client, connector(options, client))
+	if options.PollInterval > 0 {
+		midiClient.PollInterval = options.PollInterval
+	}
 
 	// Create a cancellable context for graceful shutdown handling.
 	ctx, cancel := context.WithCancel(context.Background())
@@ -53,7 +79,7 @@ func Start() {
 	}
 
 	// Select and configure the MIDI device.
-	deviceID, err := SetupDevice(ctx, midiClient)
+	deviceID, err := SetupDevice(ctx, midiClient, options.Device)
 	if err != nil {
 		logger.Fatal(constants.MsgDeviceSelectionError, zap.Error(err))
 		return
@@ -61,29 +87,69 @@ func Start() {
 	logger.Info(constants.MsgMIDIClientSetupSuccess, zap.Int("deviceID", deviceID))
 
 	// Create a buffered channel for capturing MIDI events.
-	eventChannel := make(chan contracts.MIDI, constants.MIDIChannelBufferSize)
+	eventChannel := make(chan contracts.MIDI, options.BufferSize)
 
 	// Start capturing MIDI events.
 	midiClient.StartCapture(eventChannel)
 
-	// Initialize pipeline processor to handle MIDI events with the configured logger.
-	pipelineProcessor := pipeline.NewProcessor(logger)
+	// Optionally draw the session in the terminal UI, which then also shows the exercise feedback.
+	var ui *tui.UI
+	var exerciseOut io.Writer = os.Stdout
+	if options.TUI {
+		ui = tui.New(os.Stdout, deviceName(midiClient, deviceID))
+		exerciseOut = ui.Messages()
+	}
+
+	// Optionally drive a lesson or a drill from the analysis results.
+	exercise, err := newExercise(logger, options, exerciseOut)
+	if err != nil {
+		logger.Error(constants.MsgPipelineSetupError, zap.Error(err))
+		_ = midiClient.Stop()
+		return
+	}
+
+	// Count what is played for the practice progress of the profile.
+	extraSinks := exerciseSinks(exercise)
+	if ui != nil {
+		extraSinks = append(extraSinks, ui)
+	}
+	var tracker *progress.Tracker
+	var scorer *game.Scorer
+	if options.Profile != "" {
+		tracker = progress.NewTracker()
+		scorer = game.NewScorer(game.DefaultRules())
+		extraSinks = append(extraSinks, tracker, scorer)
+	}
 
-	var wg sync.WaitGroup
+	// Initialize pipeline processor to handle MIDI events with the configured logger and sinks.
+	pipelineProcessor, err := newProcessor(logger, options, extraSinks...)
+	if err != nil {
+		logger.Error(constants.MsgPipelineSetupError, zap.Error(err))
+		_ = midiClient.Stop()
+		return
+	}
+	// Start the stages that set up before processing, such as opening files or starting goroutines. A failed
+	// start stops the stages again, releasing the sinks.
+	if err := pipelineProcessor.Start(ctx); err != nil {
+		logger.Error(constants.MsgStageStartError, zap.Error(err))
+		_ = midiClient.Stop()
+		return
+	}
+
+	// Optionally tee every captured event into a Standard MIDI File recording.
+	var recorder *smf.Recorder
+	if options.RecordPath != "" {
+		recorder = smf.NewRecorder(options.RecordPath)
+		logger.Info(constants.MsgRecordingStarted, zap.String("path", options.RecordPath))
+	}
 
 	// Goroutine for processing incoming MIDI events through the pipeline.
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for event := range eventChannel {
-			pipelineCtx := internalContext.NewPipelineContext(ctx, event)
-			if err := pipelineProcessor.Process(pipelineCtx); err != nil {
-				logger.Error(constants.MsgMIDIProcessingError, zap.Error(err))
-			}
-		}
-	}()
+	events := processEvents(ctx, logger, pipelineProcessor, eventChannel, recorder)
 
 	logger.Info(constants.MsgMIDIEventCaptureStarted)
+	if ui != nil {
+		ui.Start()
+	}
 
 	// Goroutine to handle OS interrupt signals and initiate shutdown.
 	go func() {
@@ -91,17 +157,42 @@ func Start() {
 		stopCapture("Received shutdown signal, stopping capture...")
 	}()
 
-	// Optional timeout-based shutdown mechanism (e.g., after 60 seconds).
-	go func() {
-		timer := time.NewTimer(60 * time.Second)
-		defer timer.Stop()
-		select {
-		case <-timer.C:
-			stopCapture("Timeout reached, stopping capture...")
-		case <-done:
-			// Do nothing if already shutdown.
-		}
-	}()
+	// Virtual clients signal when their script has been fully played, ending the session early.
+	if finisher, ok := client.(interface{ Done() <-chan struct{} }); ok {
+		go func() {
+			select {
+			case <-finisher.Done():
+				stopCapture("Virtual script finished, stopping capture...")
+			case <-done:
+			}
+		}()
+	}
+
+	if exercise != nil {
+		// A lesson or drill ends the session once it is complete.
+		exercise.Start()
+		go func() {
+			select {
+			case <-exercise.Done():
+				stopCapture("Exercise complete, stopping capture...")
+			case <-done:
+			}
+		}()
+	}
+
+	if options.Duration > 0 {
+		// Optional shutdown once the configured session length is reached.
+		go func() {
+			timer := time.NewTimer(options.Duration)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				stopCapture("Duration reached, stopping capture...")
+			case <-done:
+				// Do nothing if already shutdown.
+			}
+		}()
+	}
 
 	// Wait for the shutdown signal.
 	<-done
@@ -109,8 +200,148 @@ func Start() {
 	// Close the event channel to signal end of event processing.
 	close(eventChannel)
 
-	// Wait for all events to be processed.
-	wg.Wait()
+	// Process the remaining events, then stop the stages, flushing the sinks and logging the session summaries,
+	// each within the shutdown timeout.
+	events.drain(logger, options.ShutdownTimeout)
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), options.ShutdownTimeout)
+	defer cancelStop()
+	stopProcessor(stopCtx, logger, pipelineProcessor)
+
+	if tracker != nil {
+		saveProgress(logger, options, tracker, scorer, achievements, exercise)
+	}
+
+	// Flush the recording once every event has been teed, whichever path triggered the shutdown.
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			logger.Error(constants.MsgRecordingSaveError, zap.String("path", recorder.Path()), zap.Error(err))
+		} else {
+			logger.Info(constants.MsgRecordingSaved, zap.String("path", recorder.Path()), zap.Int("events", recorder.Len()))
+		}
+	}
 
 	logger.Info("Shutdown complete")
 }
+
+// newMIDIClient returns the client configured in options, a virtual client playing the configured script,
+// or a hardware client for the current platform.
+func newMIDIClient(options Options) (contracts.ClientMIDI, error) {
+	if options.Client != nil {
+		return options.Client, nil
+	}
+
+	clientOptions := []contracts.Option{
+		contracts.WithLogLevel(clientLogLevel(options)),
+		contracts.WithMIDIEventFilter(eventFilter(options)),
+	}
+
+	if options.VirtualScript != "" {
+		script, err := virtual.LoadScript(options.VirtualScript)
+		if err != nil {
+			return nil, err
+		}
+		client := virtual.NewMIDIClient(clientOptions...)
+		client.LoadScript(script)
+		return client, nil
+	}
+
+	return midi.NewMIDIClient(clientOptions...)
+}
+
+// connector returns how the device supervisor gets a client for a reconnected device. Hardware clients lose
+// their connection with an unplugged device, so a new one is created; injected and virtual clients are reused.
+func connector(options Options, client contracts.ClientMIDI) func() (contracts.ClientMIDI, error) {
+	if options.Client != nil || options.VirtualScript != "" {
+		return func() (contracts.ClientMIDI, error) { return client, nil }
+	}
+	return func() (contracts.ClientMIDI, error) { return newMIDIClient(options) }
+}
+
+// newProcessor creates the pipeline processor with the analysis key, metronome, reference score, sinks and
+// WebSocket server configured in options. Extra sinks, such as a lesson or drill, receive every snapshot too.
+func newProcessor(logger *zap.Logger, options Options, extra ...sink.Sink) (*pipeline.Processor, error) {
+	var processorOptions []pipeline.ProcessorOption
+	if options.Key != "" {
+		key, err := internalMidi.ParseKey(options.Key)
+		if err != nil {
+			return nil, err
+		}
+		processorOptions = append(processorOptions, pipeline.WithKey(key))
+	}
+
+	if options.MetronomeBPM > 0 {
+		processorOptions = append(processorOptions,
+			pipeline.WithMetronome(options.MetronomeBPM, options.Subdivision, options.Tolerance))
+	}
+
+	if options.ScorePath != "" {
+		score, err := follower.LoadScore(options.ScorePath)
+		if err != nil {
+			return nil, err
+		}
+		processorOptions = append(processorOptions, pipeline.WithScore(score))
+	}
+
+	sinks := append([]sink.Sink(nil), extra...)
+	for _, spec := range options.Sinks {
+		s, err := sink.Parse(spec)
+		if err != nil {
+			_ = sink.Multi(sinks).Close()
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	if options.WebSocketAddr != "" {
+		wsServer := server.NewServer(options.WebSocketAddr, logger, server.WithAllowedOrigins(options.WSOrigins...))
+		if err := wsServer.Start(); err != nil {
+			_ = sink.Multi(sinks).Close()
+			return nil, err
+		}
+		sinks = append(sinks, wsServer)
+	}
+
+	processorOptions = append(processorOptions,
+		pipeline.WithStageSpecs(options.Stages...),
+		pipeline.WithoutStages(options.Disabled...),
+		pipeline.WithRequiredStages(exerciseStages(extra)...),
+		pipeline.WithSinks(sinks...),
+	)
+	processor, err := pipeline.NewProcessor(logger, processorOptions...)
+	if err != nil {
+		_ = sink.Multi(sinks).Close()
+		return nil, err
+	}
+	return processor, nil
+}
+
+// stopProcessor stops the pipeline stages, logging each stage that fails to stop before ctx is done.
+func stopProcessor(ctx context.Context, logger *zap.Logger, processor *pipeline.Processor) {
+	err := processor.Stop(ctx)
+	if err == nil {
+		return
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var stageErr *pipeline.StageError
+		if errors.As(err, &stageErr) {
+			logger.Error(constants.MsgStageStopError, zap.String("stage", stageErr.Stage), zap.Error(stageErr.Err))
+		} else {
+			logger.Error(constants.MsgStageStopError, zap.Error(err))
+		}
+	}
+}
+
+// eventFilter returns the MIDI commands forwarded to the pipeline, shared by live capture and replay: those
+// configured in options, or notes and control changes, so the sustain pedal can be tracked.
+func eventFilter(options Options) contracts.MIDIEventFilter {
+	if options.Events != nil {
+		return contracts.MIDIEventFilter{Commands: options.Events}
+	}
+	return contracts.MIDIEventFilter{
+		Commands: []contracts.MIDICommand{contracts.NoteOn, contracts.NoteOff, constants.ControlChangeCommand},
+	}
+}
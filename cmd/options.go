@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/game"
+	"github.com/leandrodaf/pianalyze/internal/pipeline"
+)
+
+// Options holds the settings for a capture session started with Start.
+type Options struct {
+	RecordPath    string               // Destination of the Standard MIDI File recording; empty disables recording.
+	Client        contracts.ClientMIDI // MIDI client to capture from; nil creates a hardware client.
+	Device        string               // Device to capture from (see device.Find); empty uses PIANALYZE_DEVICE or prompts.
+	Duration      time.Duration        // Length of the session; 0 runs until interrupted or the exercise ends.
+	LogLevel      string               // Minimum level logged (debug, info, warn or error); empty uses the default.
+	VirtualScript string               // Script played by a virtual client instead of capturing from hardware.
+	Sinks         []string             // Output sink specifications (see sink.Parse).
+	WebSocketAddr string               // Address of the live analysis WebSocket server; empty disables it.
+	WSOrigins     []string             // Origins of pages allowed to connect to the WebSocket server besides localhost.
+	Key           string               // Key for Roman numeral analysis (e.g. "C major"); empty uses the detected key.
+	MetronomeBPM  float64              // Tempo of the grid onsets are scored against; 0 disables timing scoring.
+	Subdivision   int                  // Grid points per beat.
+	Tolerance     time.Duration        // Largest timing error counted as on time.
+	ScorePath     string               // Reference score (SMF or JSON) the performance is aligned to; empty disables it.
+	LessonPath    string               // Lesson file driven by the analysis results; empty disables it.
+	Drill         string               // Drill to run ("chords" or "scales"); empty disables it.
+	Difficulty    string               // Difficulty level of the chord drill (see drill.ParseDifficulty).
+	Rounds        int                  // Prompts in the drill.
+	Inversions    bool                 // Whether chord drill prompts may require inversions.
+	Scale         string               // Scale of the scale drill (see midi.ParseScaleType); empty picks any.
+	Tonic         string               // Starting note of the scale drill (e.g. "C4" or "Eb"); empty picks any.
+	Octaves       int                  // Range of the scale drill.
+	Profile       string               // Practice profile the session is saved to; empty disables progress tracking.
+	DailyGoal     time.Duration        // Practice time per day that meets the daily goal.
+	Achievements  string               // Achievements file (see game.LoadAchievements); empty uses the built-in ones.
+	TUI           bool                 // Draw the session in a full-screen terminal UI instead of logging to the terminal.
+
+	// Settings read from the configuration file (see config.Profile).
+	ConfigPath      string                  // Configuration file; empty uses PIANALYZE_CONFIG or the default file, if any.
+	ConfigProfile   string                  // Profile of the configuration file; empty uses its default profile.
+	Stages          []pipeline.StageSpec    // Pipeline stages to run, in order; nil runs the built-in stages.
+	Disabled        []string                // Pipeline stages not to run.
+	BufferSize      int                     // Capacity of the captured event channel.
+	Events          []contracts.MIDICommand // MIDI messages captured; nil captures notes and control changes.
+	PollInterval    time.Duration           // How often the device is checked to still be connected; 0 uses the default.
+	ShutdownTimeout time.Duration           // Time allowed at shutdown to process the remaining events, then to stop the stages.
+	LogMode         string                  // Log format, "development" or "production"; empty follows the build mode.
+	LogOutput       string                  // Log destination: stdout, stderr or a file path; empty uses stderr.
+
+	explicit map[string]bool // Settings given by an option, which the configuration file does not override.
+}
+
+// Settings that options and the configuration file can both provide.
+const (
+	settingDevice    = "device"
+	settingDuration  = "duration"
+	settingLogLevel  = "logLevel"
+	settingSinks     = "sinks"
+	settingWebSocket = "websocket"
+)
+
+// setExplicit records that setting was given by an option.
+func (o *Options) setExplicit(setting string) {
+	if o.explicit == nil {
+		o.explicit = make(map[string]bool)
+	}
+	o.explicit[setting] = true
+}
+
+// Option is a function that modifies Options.
+type Option func(*Options)
+
+// WithRecordPath records every captured event to a Standard MIDI File at path.
+func WithRecordPath(path string) Option {
+	return func(opts *Options) {
+		opts.RecordPath = path
+	}
+}
+
+// WithClient captures from the given MIDI client instead of creating a hardware client.
+func WithClient(client contracts.ClientMIDI) Option {
+	return func(opts *Options) {
+		opts.Client = client
+	}
+}
+
+// WithDevice captures from the device selected by spec, an index, a full or partial name or "first" (see
+// device.Find), instead of prompting for it.
+func WithDevice(spec string) Option {
+	return func(opts *Options) {
+		opts.setExplicit(settingDevice)
+		opts.Device = spec
+	}
+}
+
+// WithDuration stops the session after d. A d of 0 runs until the session is interrupted or its exercise or
+// virtual script ends.
+func WithDuration(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.setExplicit(settingDuration)
+		opts.Duration = d
+	}
+}
+
+// WithLogLevel logs messages at level ("debug", "info", "warn" or "error") and above.
+func WithLogLevel(level string) Option {
+	return func(opts *Options) {
+		opts.setExplicit(settingLogLevel)
+		opts.LogLevel = level
+	}
+}
+
+// WithVirtualScript captures from a virtual client that plays the script file at path.
+func WithVirtualScript(path string) Option {
+	return func(opts *Options) {
+		opts.VirtualScript = path
+	}
+}
+
+// WithSinks publishes a snapshot of every processed event to the sinks described by specs (see sink.Parse).
+func WithSinks(specs ...string) Option {
+	return func(opts *Options) {
+		opts.setExplicit(settingSinks)
+		opts.Sinks = append(opts.Sinks, specs...)
+	}
+}
+
+// WithWebSocket streams every processed event to WebSocket clients connected to addr (e.g. ":8080").
+func WithWebSocket(addr string) Option {
+	return func(opts *Options) {
+		opts.setExplicit(settingWebSocket)
+		opts.WebSocketAddr = addr
+	}
+}
+
+// WithWebSocketOrigins lets pages served by origins (e.g. "https://example.com", or "*" for any) connect to the
+// WebSocket server, which otherwise only accepts pages of the same origin or of localhost.
+func WithWebSocketOrigins(origins ...string) Option {
+	return func(opts *Options) {
+		opts.WSOrigins = append(opts.WSOrigins, origins...)
+	}
+}
+
+// WithKey analyzes harmony relative to a fixed key (see midi.ParseKey) instead of the detected one.
+func WithKey(key string) Option {
+	return func(opts *Options) {
+		opts.Key = key
+	}
+}
+
+// WithMetronome scores every onset against a grid of subdivision points per beat at bpm.
+// Timing errors up to tolerance count as on time. A bpm of 0 disables scoring.
+func WithMetronome(bpm float64, subdivision int, tolerance time.Duration) Option {
+	return func(opts *Options) {
+		opts.MetronomeBPM = bpm
+		opts.Subdivision = subdivision
+		opts.Tolerance = tolerance
+	}
+}
+
+// WithScore aligns the performance to the reference score at path (see follower.LoadScore).
+func WithScore(path string) Option {
+	return func(opts *Options) {
+		opts.ScorePath = path
+	}
+}
+
+// WithLesson runs the lesson at path (see lesson.Load), ending the session once every step is done.
+func WithLesson(path string) Option {
+	return func(opts *Options) {
+		opts.LessonPath = path
+	}
+}
+
+// WithChordDrill runs a chord drill of rounds prompts at difficulty ("triads", "sevenths" or "extensions"),
+// ending the session once every round is played. Prompts require inversions when inversions is true.
+func WithChordDrill(difficulty string, rounds int, inversions bool) Option {
+	return func(opts *Options) {
+		opts.Drill = "chords"
+		opts.Difficulty = difficulty
+		opts.Rounds = rounds
+		opts.Inversions = inversions
+	}
+}
+
+// WithScaleDrill runs a scale drill of rounds prompts, ending the session once every round is played. Each
+// prompt asks for scale (any scale when empty) over octaves, up and down from tonic (any tonic when empty).
+func WithScaleDrill(scale, tonic string, octaves, rounds int) Option {
+	return func(opts *Options) {
+		opts.Drill = "scales"
+		opts.Scale = scale
+		opts.Tonic = tonic
+		opts.Octaves = octaves
+		opts.Rounds = rounds
+	}
+}
+
+// WithProfile saves a summary of the session to the practice progress of profile (see progress.Store).
+func WithProfile(profile string) Option {
+	return func(opts *Options) {
+		opts.Profile = profile
+	}
+}
+
+// WithGoals sets the daily practice goal and the achievements file (empty for the built-in achievements)
+// used to score sessions saved to the profile.
+func WithGoals(dailyGoal time.Duration, achievementsPath string) Option {
+	return func(opts *Options) {
+		opts.DailyGoal = dailyGoal
+		opts.Achievements = achievementsPath
+	}
+}
+
+// WithTUI draws the session in a full-screen terminal UI when enabled; logs are written to a file meanwhile.
+func WithTUI(enabled bool) Option {
+	return func(opts *Options) {
+		opts.TUI = enabled
+	}
+}
+
+// WithConfig reads the settings not given by other options from profile (the default profile when empty) of
+// the configuration file at path (see config.Load). An empty path uses the file named by PIANALYZE_CONFIG or
+// the default file, when it exists.
+func WithConfig(path, profile string) Option {
+	return func(opts *Options) {
+		opts.ConfigPath = path
+		opts.ConfigProfile = profile
+	}
+}
+
+// applyOptions builds Options from the given option functions.
+func applyOptions(opts ...Option) Options {
+	options := Options{DailyGoal: game.DefaultDailyGoal, BufferSize: constants.MIDIChannelBufferSize,
+		ShutdownTimeout: constants.DefaultShutdownTimeout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/drill"
+	"github.com/leandrodaf/pianalyze/internal/game"
+	"github.com/leandrodaf/pianalyze/internal/lesson"
+	"github.com/leandrodaf/pianalyze/internal/progress"
+	"go.uber.org/zap"
+)
+
+// ShowProgress prints the practice progress of profile over the last count periods ("day" or "week").
+func ShowProgress(profile, period string, count int) {
+	logger := InitLogger()
+
+	p, err := progress.ParsePeriod(period)
+	if err != nil {
+		logger.Error(constants.MsgProgressReadError, zap.Error(err))
+		return
+	}
+	store, err := openProgressStore()
+	if err != nil {
+		logger.Error(constants.MsgProgressReadError, zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	from := now
+	for i := 1; i < count; i++ {
+		from = p.Start(from).Add(-time.Nanosecond)
+	}
+	summaries, err := store.Progress(profile, p, from, now)
+	if err != nil {
+		logger.Error(constants.MsgProgressReadError, zap.String("profile", profile), zap.Error(err))
+		return
+	}
+	printProgress(os.Stdout, profile, p, summaries)
+
+	path, err := gameStatePath(store, profile)
+	if err == nil {
+		var state *game.State
+		if state, err = game.LoadState(path); err == nil {
+			printGameState(os.Stdout, state, now)
+		}
+	}
+	if err != nil {
+		logger.Error(constants.MsgProgressReadError, zap.String("profile", profile), zap.Error(err))
+	}
+}
+
+// printGameState writes the points, streak and unlocked achievements of a player.
+func printGameState(out io.Writer, state *game.State, now time.Time) {
+	fmt.Fprintf(out, "\nPoints: %d (best session %d, best combo %d)\n", state.TotalPoints, state.BestSession, state.BestCombo)
+	fmt.Fprintf(out, "Daily goal streak: %d day(s) (best %d, goal met on %d day(s))\n",
+		state.CurrentStreak(now), state.BestStreak, state.GoalsMet)
+	if len(state.Unlocked) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Achievements:\n")
+	for _, u := range state.Unlocked {
+		fmt.Fprintf(out, "  %s  %s (%s)\n", u.At.Local().Format(time.DateOnly), u.Name, u.Description)
+	}
+}
+
+// printProgress writes a progress table, one row per period, followed by the totals.
+func printProgress(out io.Writer, profile string, period progress.Period, summaries []progress.Summary) {
+	fmt.Fprintf(out, "Practice progress of %q by %s\n", profile, period)
+	fmt.Fprintf(out, "%-12s %8s %10s %8s %8s\n", "Period", "Sessions", "Practiced", "Notes", "Chords")
+
+	var total progress.Summary
+	for _, summary := range summaries {
+		fmt.Fprintf(out, "%-12s %8d %10s %8d %8d\n", summary.Start.Format(time.DateOnly),
+			summary.Sessions, summary.Practiced.Round(time.Second), summary.Notes, summary.Chords)
+		total.Sessions += summary.Sessions
+		total.Practiced += summary.Practiced
+		total.Notes += summary.Notes
+		total.Chords += summary.Chords
+	}
+	fmt.Fprintf(out, "%-12s %8d %10s %8d %8d\n", "Total",
+		total.Sessions, total.Practiced.Round(time.Second), total.Notes, total.Chords)
+}
+
+// saveProgress appends the session counted by tracker, with the outcome of the exercise if any, to the
+// profile, then adds its score to the player state and prints the points, daily goal and achievements.
+// Sessions where nothing was played are not saved.
+func saveProgress(logger *zap.Logger, options Options, tracker *progress.Tracker, scorer *game.Scorer, achievements []game.Achievement, e exercise) {
+	profile := options.Profile
+	session := tracker.Session()
+	if session.Notes == 0 {
+		return
+	}
+	session.Exercise = exerciseSummary(e)
+
+	store, err := openProgressStore()
+	if err == nil {
+		err = store.Append(profile, session)
+	}
+	if err != nil {
+		logger.Error(constants.MsgProgressSaveError, zap.String("profile", profile), zap.Error(err))
+		return
+	}
+	logger.Info(constants.MsgProgressSaved,
+		zap.String("profile", profile),
+		zap.Int("notes", session.Notes),
+		zap.Int("chords", session.Chords),
+		zap.Duration("practiced", session.Practiced))
+
+	outcome, err := recordScore(store, options, achievements, game.SessionResult{
+		End:       session.End,
+		Practiced: session.Practiced,
+		Notes:     session.Notes,
+		Chords:    session.Chords,
+		Score:     scorer.Score(),
+	})
+	if err != nil {
+		logger.Error(constants.MsgGameSaveError, zap.String("profile", profile), zap.Error(err))
+		return
+	}
+	printOutcome(os.Stdout, outcome)
+}
+
+// loadAchievements returns the achievements configured in options, or the built-in ones.
+func loadAchievements(options Options) ([]game.Achievement, error) {
+	if options.Achievements == "" {
+		return game.DefaultAchievements(), nil
+	}
+	return game.LoadAchievements(options.Achievements)
+}
+
+// recordScore adds a session to the player state of the profile, unlocking achievements, and saves it.
+func recordScore(store *progress.Store, options Options, achievements []game.Achievement, session game.SessionResult) (game.Outcome, error) {
+	path, err := gameStatePath(store, options.Profile)
+	if err != nil {
+		return game.Outcome{}, err
+	}
+	state, err := game.LoadState(path)
+	if err != nil {
+		return game.Outcome{}, err
+	}
+	outcome := state.Record(session, options.DailyGoal, achievements)
+	return outcome, state.Save(path)
+}
+
+// gameStatePath returns the player state file of profile.
+func gameStatePath(store *progress.Store, profile string) (string, error) {
+	dir, err := store.ProfileDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, game.StateFile), nil
+}
+
+// printOutcome writes the score of a session, the daily goal and the achievements it unlocked.
+func printOutcome(out io.Writer, outcome game.Outcome) {
+	score := outcome.Score
+	fmt.Fprintf(out, "\nScore: %d points (%d hits, %d misses, best combo %d)\n",
+		score.Points, score.Hits, score.Misses, score.BestCombo)
+	switch {
+	case outcome.GoalJustMet:
+		fmt.Fprintf(out, "Daily goal of %s met! Streak: %d day(s)\n", outcome.DailyGoal, outcome.Streak)
+	case outcome.GoalMet:
+		fmt.Fprintf(out, "Daily goal already met today. Streak: %d day(s)\n", outcome.Streak)
+	default:
+		fmt.Fprintf(out, "Daily goal: %s of %s practiced today\n",
+			outcome.DayPracticed.Round(time.Second), outcome.DailyGoal)
+	}
+	for _, u := range outcome.Unlocked {
+		fmt.Fprintf(out, "Achievement unlocked: %s (%s)\n", u.Name, u.Description)
+	}
+}
+
+// openProgressStore opens the progress store in its default directory.
+func openProgressStore() (*progress.Store, error) {
+	dir, err := progress.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return progress.Open(dir)
+}
+
+// exerciseSummary returns the outcome of a lesson or drill, or nil without an exercise.
+func exerciseSummary(e exercise) *progress.ExerciseSummary {
+	switch e := e.(type) {
+	case *lesson.Runner:
+		summary := &progress.ExerciseSummary{Name: "lesson: " + e.Title()}
+		for _, result := range e.Results() {
+			if result.Attempts == 0 && !result.Passed {
+				continue
+			}
+			summary.Prompts++
+			if result.Passed {
+				summary.Passed++
+				if result.Attempts == 1 {
+					summary.FirstTry++
+				}
+			}
+		}
+		return summary
+	case *drill.Drill:
+		summary := &progress.ExerciseSummary{Name: "drill: " + e.Name}
+		for _, stats := range e.Results() {
+			summary.Prompts += stats.Prompts
+			summary.Passed += stats.Passed
+			summary.FirstTry += stats.FirstTry
+		}
+		return summary
+	default:
+		return nil
+	}
+}
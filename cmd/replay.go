@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/smf"
+	"go.uber.org/zap"
+)
+
+// Replay reads a Standard MIDI File and feeds its events through the same pipeline used for live capture.
+// When realTime is false, events are processed as fast as possible. Sink options apply as for Start, and a
+// configured duration stops playback early.
+func Replay(path string, realTime bool, opts ...Option) {
+	replay(path, realTime, loadOptions(opts...))
+}
+
+// Analyze processes a Standard MIDI File as fast as possible. Results are written to the configured sinks and
+// WebSocket server, or to stdout as JSON Lines when there are none.
+func Analyze(path string, opts ...Option) {
+	options := loadOptions(opts...)
+	if len(options.Sinks) == 0 && options.WebSocketAddr == "" {
+		options.Sinks = []string{"jsonl:stdout"}
+	}
+	replay(path, false, options)
+}
+
+// replay feeds the events of the Standard MIDI File at path through the pipeline configured in options.
+func replay(path string, realTime bool, options Options) {
+	logger, err := newLogger(options)
+	if err != nil {
+		InitLogger().Error(constants.MsgLoggerSetupError, zap.Error(err))
+		return
+	}
+
+	file, err := smf.ReadFile(path)
+	if err != nil {
+		logger.Error(constants.MsgSMFReadError, zap.String("path", path), zap.Error(err))
+		return
+	}
+	logger.Info(constants.MsgSMFLoaded,
+		zap.String("path", path),
+		zap.Int("format", int(file.Format)),
+		zap.Int("tracks", len(file.Tracks)),
+		zap.Duration("duration", file.Duration()))
+
+	// Create a cancellable context so an interrupt stops playback early.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if options.Duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.Duration)
+		defer cancel()
+	}
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalChan)
+	go func() {
+		select {
+		case <-signalChan:
+			logger.Info("Received shutdown signal, stopping replay...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	eventChannel := make(chan contracts.MIDI, options.BufferSize)
+	exercise, err := newExercise(logger, options, os.Stdout)
+	if err != nil {
+		logger.Error(constants.MsgPipelineSetupError, zap.Error(err))
+		return
+	}
+	pipelineProcessor, err := newProcessor(logger, options, exerciseSinks(exercise)...)
+	if err != nil {
+		logger.Error(constants.MsgPipelineSetupError, zap.Error(err))
+		return
+	}
+	if err := pipelineProcessor.Start(ctx); err != nil {
+		logger.Error(constants.MsgStageStartError, zap.Error(err))
+		return
+	}
+	if exercise != nil {
+		// Stop playback once the lesson or drill is complete.
+		exercise.Start()
+		go func() {
+			select {
+			case <-exercise.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	events := processEvents(ctx, logger, pipelineProcessor, eventChannel, nil)
+
+	player := smf.NewPlayer(file,
+		smf.WithRealTime(realTime),
+		smf.WithMIDIEventFilter(eventFilter(options)),
+	)
+
+	logger.Info(constants.MsgSMFReplayStarted, zap.Bool("realTime", realTime))
+	if err := player.Play(ctx, eventChannel); err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		logger.Error(constants.MsgSMFReplayError, zap.Error(err))
+	}
+
+	// Close the event channel so the pipeline drains the remaining events.
+	// Every event of the file is analyzed, so only stopping the stages is bounded by the shutdown timeout.
+	close(eventChannel)
+	<-events.done
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), options.ShutdownTimeout)
+	defer cancelStop()
+	stopProcessor(stopCtx, logger, pipelineProcessor)
+
+	logger.Info("Replay complete")
+}
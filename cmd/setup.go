@@ -1,17 +1,25 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/leandrodaf/midi/sdk/contracts"
 	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/device"
 	"go.uber.org/zap"
+	"golang.org/x/term"
 )
 
-// SetupDevice selects and configures the MIDI device.
-func SetupDevice(ctx context.Context, adapter contracts.ClientMIDI) (int, error) {
+// SetupDevice selects and configures the MIDI device. The device is chosen by spec (see device.Find), or by
+// the PIANALYZE_DEVICE environment variable when spec is empty. Without either, the user is prompted to
+// choose one, provided stdin is a terminal.
+func SetupDevice(ctx context.Context, adapter contracts.ClientMIDI, spec string) (int, error) {
 	devices, err := adapter.ListDevices()
 	if err != nil {
 		return 0, err
@@ -19,26 +27,37 @@ func SetupDevice(ctx context.Context, adapter contracts.ClientMIDI) (int, error)
 	if len(devices) == 0 {
 		return 0, fmt.Errorf(constants.ErrNoMIDIDevices)
 	}
-	fmt.Println("Available MIDI devices:")
-	for i, device := range devices {
-		fmt.Printf("[%d] %s\n", i, device.Name)
+	if spec == "" {
+		spec = os.Getenv(constants.EnvDevice)
+	}
+	if spec != "" {
+		deviceID, err := device.Find(devices, spec)
+		if err != nil {
+			return 0, err
+		}
+		return deviceID, adapter.SelectDevice(deviceID)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return 0, fmt.Errorf(constants.ErrNoDeviceSelected)
 	}
 
+	fmt.Println("Available MIDI devices:")
+	printDevices(os.Stdout, devices)
+
 	// Canal para receber a entrada do usuário.
-	inputChan := make(chan int)
+	inputChan := make(chan string)
 	// Canal para receber erros da leitura de entrada.
 	errorChan := make(chan error)
 
-	// Goroutine para ler a entrada do usuário.
+	// Goroutine para ler a entrada do usuário (índice ou nome do dispositivo).
 	go func() {
-		var deviceID int
-		fmt.Print("Choose a MIDI device: ")
-		_, err := fmt.Scanf("%d", &deviceID)
-		if err != nil {
+		fmt.Print("Choose a MIDI device (index or name): ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
 			errorChan <- err
 			return
 		}
-		inputChan <- deviceID
+		inputChan <- strings.TrimSpace(line)
 	}()
 
 	select {
@@ -46,15 +65,45 @@ func SetupDevice(ctx context.Context, adapter contracts.ClientMIDI) (int, error)
 		return 0, fmt.Errorf("selection canceled: %w", ctx.Err())
 	case err := <-errorChan:
 		return 0, err
-	case deviceID := <-inputChan:
-		if deviceID < 0 || deviceID >= len(devices) {
-			return deviceID, fmt.Errorf(constants.ErrInvalidDeviceID)
-		}
-		err = adapter.SelectDevice(deviceID)
+	case choice := <-inputChan:
+		deviceID, err := device.Find(devices, choice)
 		if err != nil {
-			return deviceID, err
+			return 0, err
 		}
-		return deviceID, nil
+		return deviceID, adapter.SelectDevice(deviceID)
+	}
+}
+
+// ListDevices prints the available MIDI devices with the index used to select them.
+func ListDevices(opts ...Option) {
+	options := loadOptions(opts...)
+	logger, err := newLogger(options)
+	if err != nil {
+		InitLogger().Error(constants.MsgLoggerSetupError, zap.Error(err))
+		return
+	}
+
+	midiClient, err := newMIDIClient(options)
+	if err != nil {
+		logger.Error(constants.MsgMIDIClientSetupError, zap.Error(err))
+		return
+	}
+	devices, err := midiClient.ListDevices()
+	if err != nil {
+		logger.Error(constants.MsgMIDIClientSetupError, zap.Error(err))
+		return
+	}
+	if len(devices) == 0 {
+		fmt.Println(constants.ErrNoMIDIDevices)
+		return
+	}
+	printDevices(os.Stdout, devices)
+}
+
+// printDevices writes one line per device with its index.
+func printDevices(out io.Writer, devices []contracts.DeviceInfo) {
+	for i, device := range devices {
+		fmt.Fprintf(out, "[%d] %s\n", i, device.Name)
 	}
 }
 
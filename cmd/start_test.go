@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"github.com/leandrodaf/pianalyze/internal/smf"
+)
+
+// testScript plays a C major chord, a G7 chord in the key of C and a single note.
+const testScript = `
+device Test Piano
+chord C4 E4 G4 vel=90
+wait 20ms
+release C4 E4 G4
+chord G3 B3 D4 F4
+wait 20ms
+release G3 B3 D4 F4
+on A4 70
+wait 20ms
+off A4
+`
+
+// isolateConfig points the configuration and progress directories at an empty directory, so tests neither
+// read the configuration nor write the practice progress of the user running them.
+func isolateConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("PIANALYZE_CONFIG", "")
+}
+
+func TestStartWithVirtualClient(t *testing.T) {
+	isolateConfig(t)
+	dir := t.TempDir()
+	scriptPath := filepath.Join(dir, "script.txt")
+	if err := os.WriteFile(scriptPath, []byte(testScript), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outputPath := filepath.Join(dir, "events.jsonl")
+	recordPath := filepath.Join(dir, "session.mid")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Start(
+			WithVirtualScript(scriptPath),
+			WithDevice("first"),
+			WithSinks("jsonl:"+outputPath),
+			WithRecordPath(recordPath),
+			WithKey("C major"),
+			WithLogLevel("error"),
+		)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("session did not end with the virtual script")
+	}
+
+	snapshots := readSnapshots(t, outputPath)
+	if len(snapshots) != 16 {
+		t.Fatalf("got %d snapshots, want one per event (16)", len(snapshots))
+	}
+	symbols := map[string]string{}
+	for _, snapshot := range snapshots {
+		if snapshot.ChordSymbol != "" {
+			symbols[snapshot.ChordSymbol] = snapshot.RomanNumeral
+		}
+	}
+	if symbols["C"] != "I" || symbols["G7"] != "V7" {
+		t.Errorf("chords and roman numerals = %v, want C as I and G7 as V7", symbols)
+	}
+	last := snapshots[len(snapshots)-1]
+	if last.NoteName != "A4" || len(last.PressedNotes) != 0 {
+		t.Errorf("last snapshot = %s with %v pressed, want the release of A4", last.NoteName, last.PressedNotes)
+	}
+
+	recording, err := smf.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("reading the recording: %v", err)
+	}
+	if events := recording.Events(); len(events) != len(snapshots) {
+		t.Errorf("recorded %d events, want %d", len(events), len(snapshots))
+	}
+	if _, err := os.Stat(filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "pianalyze", "profiles")); !os.IsNotExist(err) {
+		t.Errorf("progress saved without a profile: %v", err)
+	}
+}
+
+// readSnapshots decodes the snapshots of a JSON lines file.
+func readSnapshots(t *testing.T, path string) []sink.Snapshot {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var snapshots []sink.Snapshot
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var snapshot sink.Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			t.Fatalf("line %d: %v", len(snapshots)+1, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return snapshots
+}
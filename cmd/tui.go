@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+)
+
+// tuiLogPath returns the file logs are written to while the terminal UI is active.
+func tuiLogPath() string {
+	return filepath.Join(os.TempDir(), "pianalyze.log")
+}
+
+// deviceName returns the name of the selected device, or an empty string if it cannot be listed.
+func deviceName(client contracts.ClientMIDI, deviceID int) string {
+	devices, err := client.ListDevices()
+	if err != nil || deviceID < 0 || deviceID >= len(devices) {
+		return ""
+	}
+	return devices[deviceID].Name
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/leandrodaf/pianalyze/cmd"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/drill"
+	"github.com/leandrodaf/pianalyze/internal/game"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+)
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// isSet reports whether the named flag was given on the command line, even with its default value.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// captureFlags registers the flags of the commands capturing from a device: device selection, session length,
+// terminal UI and practice profile, along with the output and analysis flags. The returned function builds
+// the options once the flags are parsed.
+func captureFlags(fs *flag.FlagSet) func() []cmd.Option {
+	device := fs.String("device", "", "MIDI device to capture from: index (see \"devices\"), name, part of a name or \"first\"; defaults to $"+constants.EnvDevice+", then prompts in a terminal")
+	virtualScript := fs.String("virtual", "", "Capture from a virtual MIDI client playing the given script instead of hardware")
+	duration := fs.Duration("duration", 0, "Stop capturing after this long (0 runs until Ctrl+C or the end of the exercise)")
+	tuiMode := fs.Bool("tui", false, "Show a full-screen terminal UI with a live keyboard while capturing (logs go to a file)")
+	profile := fs.String("profile", "", "Practice profile the session is saved to (e.g. \"default\"); sessions are not saved unless set")
+	dailyGoal := fs.Duration("goal", game.DefaultDailyGoal, "Practice time per day that meets the daily goal")
+	achievements := fs.String("achievements", "", "JSON file with the achievements to unlock instead of the built-in ones")
+	output := outputFlags(fs)
+	analysis := analysisFlags(fs)
+	configuration := configFlags(fs)
+
+	return func() []cmd.Option {
+		opts := []cmd.Option{
+			configuration(),
+			cmd.WithVirtualScript(*virtualScript),
+			cmd.WithTUI(*tuiMode),
+			cmd.WithProfile(*profile),
+			cmd.WithGoals(*dailyGoal, *achievements),
+		}
+		// Settings the configuration file can provide are only given when set, so the profile fills the others.
+		if isSet(fs, "device") {
+			opts = append(opts, cmd.WithDevice(*device))
+		}
+		if isSet(fs, "duration") {
+			opts = append(opts, cmd.WithDuration(*duration))
+		}
+		return append(append(opts, output()...), analysis()...)
+	}
+}
+
+// configFlags registers the flags choosing the configuration file and profile.
+func configFlags(fs *flag.FlagSet) func() cmd.Option {
+	path := fs.String("config", "", "YAML configuration file; defaults to $"+constants.EnvConfig+", then pianalyze/config.yaml in the user configuration directory")
+	profile := fs.String("config-profile", "", "Profile of the configuration file to use; defaults to its default profile")
+
+	return func() cmd.Option {
+		return cmd.WithConfig(*path, *profile)
+	}
+}
+
+// outputFlags registers the flags choosing where results and logs go.
+func outputFlags(fs *flag.FlagSet) func() []cmd.Option {
+	var sinks stringList
+	fs.Var(&sinks, "sink", "Output sink for processed events: jsonl:stdout, jsonl:<file>, csv:<file> or an http(s) URL (repeatable)")
+	wsAddr := fs.String("ws", "", "Serve live analysis to WebSocket clients on the given address (e.g. :8080)")
+	var wsOrigins stringList
+	fs.Var(&wsOrigins, "ws-origin", "Origin of web pages allowed to connect to the WebSocket server besides localhost (e.g. https://example.com, or * for any; repeatable)")
+	logLevel := fs.String("log-level", "", "Minimum level logged: debug, info, warn or error")
+
+	return func() []cmd.Option {
+		var opts []cmd.Option
+		if isSet(fs, "sink") {
+			opts = append(opts, cmd.WithSinks(sinks...))
+		}
+		if isSet(fs, "ws") {
+			opts = append(opts, cmd.WithWebSocket(*wsAddr))
+		}
+		if len(wsOrigins) > 0 {
+			opts = append(opts, cmd.WithWebSocketOrigins(wsOrigins...))
+		}
+		if isSet(fs, "log-level") {
+			opts = append(opts, cmd.WithLogLevel(*logLevel))
+		}
+		return opts
+	}
+}
+
+// analysisFlags registers the flags configuring the analysis: key, metronome and reference score.
+func analysisFlags(fs *flag.FlagSet) func() []cmd.Option {
+	key := fs.String("key", "", "Key for Roman numeral analysis (e.g. \"C major\", \"F#m\"); defaults to the detected key")
+	metronome := fs.Float64("metronome", 0, "Score the timing of every onset against a metronome at this tempo (BPM)")
+	subdivision := fs.Int("subdivision", rhythm.DefaultSubdivision, "Metronome grid points per beat (2 = eighth notes, 4 = sixteenth notes)")
+	tolerance := fs.Duration("tolerance", rhythm.DefaultTimingTolerance, "Largest timing error counted as on time")
+	scorePath := fs.String("score", "", "Follow the performance along a reference piece (Standard MIDI File or JSON score)")
+
+	return func() []cmd.Option {
+		return []cmd.Option{
+			cmd.WithKey(*key),
+			cmd.WithMetronome(*metronome, *subdivision, *tolerance),
+			cmd.WithScore(*scorePath),
+		}
+	}
+}
+
+// drillFlags registers the settings of the chord and scale drills. The returned function builds the option
+// running the drill of the given kind.
+func drillFlags(fs *flag.FlagSet) func(kind string) (cmd.Option, error) {
+	rounds := fs.Int("rounds", drill.DefaultRounds, "Prompts in the drill")
+	difficulty := fs.String("difficulty", string(drill.Triads), "Chords prompted by \"drill chords\": triads, sevenths or extensions")
+	inversions := fs.Bool("inversions", true, "Require inversions in chord prompts, not only root position")
+	scale := fs.String("scale", "", "Scale prompted by \"drill scales\" (e.g. major, \"harmonic minor\", dorian); any scale when empty")
+	tonic := fs.String("tonic", "", "Starting note of \"drill scales\" (e.g. C4 or Eb); any tonic when empty")
+	octaves := fs.Int("octaves", drill.DefaultOctaves, "Octaves covered by \"drill scales\"")
+
+	return func(kind string) (cmd.Option, error) {
+		switch kind {
+		case "chords":
+			return cmd.WithChordDrill(*difficulty, *rounds, *inversions), nil
+		case "scales":
+			return cmd.WithScaleDrill(*scale, *tonic, *octaves, *rounds), nil
+		default:
+			return nil, fmt.Errorf("unknown drill %q: want chords or scales", kind)
+		}
+	}
+}
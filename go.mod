@@ -3,8 +3,11 @@ module github.com/leandrodaf/pianalyze
 go 1.23.2
 
 require (
+	github.com/gorilla/websocket v1.5.3
 	github.com/leandrodaf/midi v1.0.2
 	go.uber.org/zap v1.27.0
+	golang.org/x/term v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,60 @@
+package analysis
+
+import "time"
+
+// Articulation describes how a note connects to the note that follows it.
+type Articulation string
+
+// Articulations assigned to completed notes.
+const (
+	Legato      Articulation = "legato"      // The next note starts as this one ends, with at most a slight overlap.
+	Overlapping Articulation = "overlapping" // The next note starts well before this one ends.
+	Staccato    Articulation = "staccato"    // The note is short and released before the next note starts.
+	Detached    Articulation = "detached"    // The note is released before the next note starts, without being short.
+)
+
+// Default thresholds for articulation classification.
+const (
+	DefaultLegatoMaxOverlap    = 100 * time.Millisecond // Longest overlap still heard as legato.
+	DefaultStaccatoMaxDuration = 200 * time.Millisecond // Longest note considered staccato.
+	DefaultChordWindow         = 30 * time.Millisecond  // Onsets closer than this belong to the same chord, not to a neighbouring note.
+)
+
+// CompletedNote is a note from press to release.
+type CompletedNote struct {
+	Note         int           `json:"note"`
+	NoteOn       uint64        `json:"noteOn"`  // Unix nanoseconds.
+	NoteOff      uint64        `json:"noteOff"` // Unix nanoseconds.
+	Duration     time.Duration `json:"duration"`
+	Velocity     byte          `json:"velocity"`
+	Articulation Articulation  `json:"articulation"`
+}
+
+// ArticulationThresholds configures ClassifyArticulation.
+type ArticulationThresholds struct {
+	LegatoMaxOverlap    time.Duration
+	StaccatoMaxDuration time.Duration
+}
+
+// DefaultArticulationThresholds returns the default articulation thresholds.
+func DefaultArticulationThresholds() ArticulationThresholds {
+	return ArticulationThresholds{
+		LegatoMaxOverlap:    DefaultLegatoMaxOverlap,
+		StaccatoMaxDuration: DefaultStaccatoMaxDuration,
+	}
+}
+
+// ClassifyArticulation classifies a note released at noteOff. nextOnset is the onset of the following
+// note when it started before the release, or 0 when no following note has started yet.
+func ClassifyArticulation(noteOn, noteOff, nextOnset uint64, thresholds ArticulationThresholds) Articulation {
+	if nextOnset != 0 && nextOnset <= noteOff {
+		if time.Duration(noteOff-nextOnset) <= thresholds.LegatoMaxOverlap {
+			return Legato
+		}
+		return Overlapping
+	}
+	if noteOff >= noteOn && time.Duration(noteOff-noteOn) <= thresholds.StaccatoMaxDuration {
+		return Staccato
+	}
+	return Detached
+}
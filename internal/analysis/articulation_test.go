@@ -0,0 +1,27 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyArticulation(t *testing.T) {
+	ms := func(d int) uint64 { return uint64(time.Duration(d) * time.Millisecond) }
+	tests := []struct {
+		name                       string
+		noteOn, noteOff, nextOnset uint64
+		want                       Articulation
+	}{
+		{"next note starts as this one ends", ms(0), ms(500), ms(500), Legato},
+		{"slight overlap", ms(0), ms(500), ms(450), Legato},
+		{"long overlap", ms(0), ms(500), ms(200), Overlapping},
+		{"short and released", ms(0), ms(150), 0, Staccato},
+		{"short, next note later", ms(0), ms(200), ms(300), Staccato},
+		{"long and released", ms(0), ms(400), 0, Detached},
+	}
+	for _, tt := range tests {
+		if got := ClassifyArticulation(tt.noteOn, tt.noteOff, tt.nextOnset, DefaultArticulationThresholds()); got != tt.want {
+			t.Errorf("%s: ClassifyArticulation() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/leandrodaf/pianalyze/internal/midi"
+)
+
+// Triad types used to classify chords for functional analysis.
+const (
+	triadMajor      = "major"
+	triadMinor      = "minor"
+	triadDiminished = "diminished"
+	triadAugmented  = "augmented"
+	triadSuspended  = "suspended"
+)
+
+// Seventh types used to classify chords for functional analysis.
+const (
+	seventhNone       = ""
+	seventhMinor      = "minor"      // Minor seventh above the root (V7, ii7, viiø7).
+	seventhMajor      = "major"      // Major seventh above the root (Imaj7).
+	seventhDiminished = "diminished" // Diminished seventh above the root (vii°7).
+)
+
+// chordType is the triad and seventh that determine the Roman numeral of a chord.
+type chordType struct {
+	triad   string
+	seventh string
+}
+
+// diatonicChord is the chord built on a scale degree of a key.
+type diatonicChord struct {
+	interval int // Semitones above the tonic.
+	triad    string
+	sevenths []string // Sevenths considered diatonic on this degree.
+}
+
+// Diatonic chords of the major and minor modes. The minor mode includes the chords of the harmonic
+// minor (V, vii°) next to those of the natural minor (v, VII), since both are common practice.
+var diatonicChords = map[midi.Mode][]diatonicChord{
+	midi.Major: {
+		{0, triadMajor, []string{seventhMajor}},
+		{2, triadMinor, []string{seventhMinor}},
+		{4, triadMinor, []string{seventhMinor}},
+		{5, triadMajor, []string{seventhMajor}},
+		{7, triadMajor, []string{seventhMinor}},
+		{9, triadMinor, []string{seventhMinor}},
+		{11, triadDiminished, []string{seventhMinor}},
+	},
+	midi.Minor: {
+		{0, triadMinor, []string{seventhMinor, seventhMajor}},
+		{2, triadDiminished, []string{seventhMinor}},
+		{3, triadMajor, []string{seventhMajor}},
+		{3, triadAugmented, []string{seventhMajor}},
+		{5, triadMinor, []string{seventhMinor}},
+		{7, triadMajor, []string{seventhMinor}},
+		{7, triadMinor, []string{seventhMinor}},
+		{8, triadMajor, []string{seventhMajor}},
+		{10, triadMajor, []string{seventhMinor}},
+		{11, triadDiminished, []string{seventhDiminished, seventhMinor}},
+	},
+}
+
+// Scale degrees used to name numerals, indexed by interval above the tonic. Degrees are named after the
+// major scale in major keys and after the natural minor in minor keys, where the leading tone keeps the
+// plain "VII" by convention. Chromatic degrees carry the customary accidental.
+var degreeNames = map[midi.Mode][12]string{
+	midi.Major: {"I", "bII", "II", "bIII", "III", "IV", "#IV", "V", "bVI", "VI", "bVII", "VII"},
+	midi.Minor: {"I", "bII", "II", "III", "#III", "IV", "#IV", "V", "VI", "#VI", "VII", "VII"},
+}
+
+// Inversion figures for triads and seventh chords, indexed by inversion.
+var (
+	triadFigures   = []string{"", "6", "64"}
+	seventhFigures = []string{"7", "65", "43", "42"}
+)
+
+// Numeral is the Roman numeral analysis of a chord in a key.
+type Numeral struct {
+	Symbol    string // Full numeral with quality, figure and target (e.g. "V65/V", "bVI", "viiø7").
+	Degree    string // Scale degree with accidental and case (e.g. "bVI", "ii").
+	Figure    string // Inversion figure (e.g. "6", "43"); empty in root position.
+	Secondary bool   // True for secondary dominants and leading-tone chords.
+	Target    string // Numeral the secondary chord resolves to (e.g. "V"); empty otherwise.
+	Borrowed  bool   // True for chords borrowed from the parallel mode.
+	Chromatic bool   // True for chords that are neither diatonic, secondary nor borrowed.
+}
+
+// RomanNumeral analyzes a chord in a key. Chords are tried, in order, as diatonic chords, chords borrowed
+// from the parallel mode (iv or bVI in major, IV or I in minor), secondary dominants (V/x, V7/x) and
+// secondary leading-tone chords (vii°/x, vii°7/x); anything else is spelled chromatically relative to the tonic.
+// Returns false if the chord quality is unknown.
+func RomanNumeral(chord midi.ChordMatch, key midi.Key) (Numeral, bool) {
+	kind, ok := classify(chord.Quality)
+	if !ok {
+		return Numeral{}, false
+	}
+	interval := (chord.Root - key.Tonic + 12) % 12
+	figure := inversionFigure(kind, chord.Inversion)
+
+	if isDiatonic(kind, interval, key.Mode) {
+		return newNumeral(degree(interval, key.Mode), kind, figure, ""), true
+	}
+
+	parallel := midi.Minor
+	if key.Mode == midi.Minor {
+		parallel = midi.Major
+	}
+	if isDiatonic(kind, interval, parallel) {
+		numeral := newNumeral(degree(interval, key.Mode), kind, figure, "")
+		numeral.Borrowed = true
+		return numeral, true
+	}
+
+	if target, ok := secondaryTarget(kind, interval, key.Mode); ok {
+		var base string
+		if kind.triad == triadDiminished {
+			base = "vii"
+		} else {
+			base = "V"
+		}
+		numeral := newNumeral(base, kind, figure, target)
+		numeral.Secondary = true
+		return numeral, true
+	}
+
+	numeral := newNumeral(degree(interval, key.Mode), kind, figure, "")
+	numeral.Chromatic = true
+	return numeral, true
+}
+
+// newNumeral assembles a numeral from its degree, chord type, inversion figure and optional target.
+func newNumeral(degree string, kind chordType, figure, target string) Numeral {
+	// Only the numeral changes case; the accidental prefix is kept as is.
+	numeral := strings.TrimLeft(degree, "b#")
+	accidental := degree[:len(degree)-len(numeral)]
+	if kind.triad == triadMinor || kind.triad == triadDiminished {
+		degree = accidental + strings.ToLower(numeral)
+	} else {
+		degree = accidental + strings.ToUpper(numeral)
+	}
+
+	symbol := degree + qualityMark(kind) + figure
+	if target != "" {
+		symbol += "/" + target
+	}
+	return Numeral{
+		Symbol: symbol,
+		Degree: degree,
+		Figure: strings.TrimPrefix(figure, "7"),
+		Target: target,
+	}
+}
+
+// qualityMark returns the sign written after the degree for the chord quality.
+func qualityMark(kind chordType) string {
+	switch {
+	case kind.triad == triadDiminished && kind.seventh == seventhMinor:
+		return "ø"
+	case kind.triad == triadDiminished:
+		return "°"
+	case kind.triad == triadAugmented && kind.seventh == seventhMajor:
+		return "+maj"
+	case kind.triad == triadAugmented:
+		return "+"
+	case kind.triad == triadSuspended && kind.seventh == seventhNone:
+		return "sus"
+	case kind.seventh == seventhMajor:
+		return "maj"
+	default:
+		return ""
+	}
+}
+
+// inversionFigure returns the figured-bass figure for a chord type and inversion. Chords with an
+// extension in the bass get the root-position figure.
+func inversionFigure(kind chordType, inversion int) string {
+	figures := triadFigures
+	if kind.seventh != seventhNone {
+		figures = seventhFigures
+	}
+	if inversion < 0 || inversion >= len(figures) {
+		return figures[0]
+	}
+	return figures[inversion]
+}
+
+// classify derives the triad and seventh of a chord quality from its intervals.
+func classify(quality string) (chordType, bool) {
+	intervals := midi.ChordIntervals(quality)
+	if intervals == nil {
+		return chordType{}, false
+	}
+	has := make(map[int]bool, len(intervals))
+	for _, interval := range intervals {
+		has[interval] = true
+	}
+
+	kind := chordType{}
+	switch {
+	case has[4] && has[8] && !has[7]:
+		kind.triad = triadAugmented
+	case has[4]:
+		kind.triad = triadMajor
+	case has[3] && has[6] && !has[7]:
+		kind.triad = triadDiminished
+	case has[3]:
+		kind.triad = triadMinor
+	default:
+		kind.triad = triadSuspended
+	}
+
+	switch {
+	case has[10]:
+		kind.seventh = seventhMinor
+	case has[11]:
+		kind.seventh = seventhMajor
+	case has[9] && kind.triad == triadDiminished:
+		kind.seventh = seventhDiminished
+	}
+	return kind, true
+}
+
+// isDiatonic reports whether a chord type built on interval belongs to the mode. Suspended chords have
+// no third, so they are diatonic on any scale degree.
+func isDiatonic(kind chordType, interval int, mode midi.Mode) bool {
+	for _, chord := range diatonicChords[mode] {
+		if chord.interval != interval {
+			continue
+		}
+		if chord.triad != kind.triad && kind.triad != triadSuspended {
+			continue
+		}
+		if kind.seventh == seventhNone {
+			return true
+		}
+		for _, seventh := range chord.sevenths {
+			if seventh == kind.seventh {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// secondaryTarget returns the numeral of the diatonic chord tonicized by a secondary dominant (a major
+// triad or dominant seventh a fifth above it) or a secondary leading-tone chord (a diminished chord a
+// semitone below it). Only major and minor diatonic triads other than the tonic can be tonicized.
+func secondaryTarget(kind chordType, interval int, mode midi.Mode) (string, bool) {
+	var targetInterval int
+	switch {
+	case kind.triad == triadMajor && (kind.seventh == seventhNone || kind.seventh == seventhMinor):
+		targetInterval = (interval + 5) % 12
+	case kind.triad == triadDiminished:
+		targetInterval = (interval + 1) % 12
+	default:
+		return "", false
+	}
+	if targetInterval == 0 {
+		return "", false
+	}
+
+	for _, chord := range diatonicChords[mode] {
+		if chord.interval != targetInterval {
+			continue
+		}
+		if chord.triad != triadMajor && chord.triad != triadMinor {
+			continue
+		}
+		return newNumeral(degree(targetInterval, mode), chordType{triad: chord.triad}, "", "").Symbol, true
+	}
+	return "", false
+}
+
+// degree names the scale degree at interval above the tonic, in uppercase.
+func degree(interval int, mode midi.Mode) string {
+	return degreeNames[mode][interval]
+}
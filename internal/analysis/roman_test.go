@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/leandrodaf/pianalyze/internal/midi"
+)
+
+func TestRomanNumeral(t *testing.T) {
+	tests := []struct {
+		key   string
+		notes []int
+		want  string
+		check func(Numeral) bool
+	}{
+		// Diatonic chords and inversions.
+		{"C major", []int{60, 64, 67}, "I", nil},
+		{"C major", []int{62, 65, 69}, "ii", nil},
+		{"C major", []int{64, 67, 72}, "I6", nil},
+		{"C major", []int{55, 60, 64}, "I64", nil},
+		{"C major", []int{55, 59, 62, 65}, "V7", nil},
+		{"C major", []int{53, 55, 59, 62}, "V42", nil},
+		{"C major", []int{59, 62, 65}, "vii°", nil},
+		{"C major", []int{59, 62, 65, 69}, "viiø7", nil},
+		{"C major", []int{53, 57, 60, 64}, "IVmaj7", nil},
+		// Secondary dominants and leading-tone chords.
+		{"C major", []int{62, 66, 69}, "V/V", func(n Numeral) bool { return n.Secondary && n.Target == "V" }},
+		{"C major", []int{64, 68, 71, 74}, "V7/vi", func(n Numeral) bool { return n.Secondary && n.Target == "vi" }},
+		{"C major", []int{66, 69, 72}, "vii°/V", func(n Numeral) bool { return n.Secondary }},
+		// Borrowed from the parallel minor.
+		{"C major", []int{65, 68, 72}, "iv", func(n Numeral) bool { return n.Borrowed }},
+		{"C major", []int{56, 60, 63}, "bVI", func(n Numeral) bool { return n.Borrowed }},
+		// Minor keys.
+		{"A minor", []int{57, 60, 64}, "i", nil},
+		{"A minor", []int{62, 65, 69}, "iv", nil},
+		{"A minor", []int{60, 64, 67}, "III", nil},
+		{"A minor", []int{57, 61, 64}, "I", func(n Numeral) bool { return n.Borrowed }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.want, func(t *testing.T) {
+			key, err := midi.ParseKey(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chord, ok := midi.DetectChord(tt.notes)
+			if !ok {
+				t.Fatalf("no chord in %v", tt.notes)
+			}
+			numeral, ok := RomanNumeral(chord, key)
+			if !ok || numeral.Symbol != tt.want {
+				t.Fatalf("RomanNumeral(%s in %s) = %q (%v), want %q", chord.Name, tt.key, numeral.Symbol, ok, tt.want)
+			}
+			if tt.check != nil && !tt.check(numeral) {
+				t.Errorf("RomanNumeral(%s in %s) = %+v", chord.Name, tt.key, numeral)
+			}
+		})
+	}
+}
+
+func TestRomanNumeralUnknownQuality(t *testing.T) {
+	key, _ := midi.ParseKey("C major")
+	if _, ok := RomanNumeral(midi.ChordMatch{Quality: "Unknown"}, key); ok {
+		t.Error("RomanNumeral of an unknown quality succeeded")
+	}
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/pipeline"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidConfig is returned when a configuration file cannot be loaded or fails validation.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Logging modes, matching the build modes of the application.
+const (
+	ModeDevelopment = "development"
+	ModeProduction  = constants.BuildModeProduction
+)
+
+// events maps the names accepted in event filters to their MIDI commands.
+var events = map[string]contracts.MIDICommand{
+	"noteOn":          contracts.NoteOn,
+	"noteOff":         contracts.NoteOff,
+	"polyPressure":    0xA0,
+	"controlChange":   constants.ControlChangeCommand,
+	"programChange":   0xC0,
+	"channelPressure": 0xD0,
+	"pitchBend":       0xE0,
+}
+
+// Config is a configuration file: named profiles of settings, one of which is used by default.
+//
+//	default: home
+//	profiles:
+//	  home:
+//	    device: yamaha
+//	    duration: 30m
+//	    sinks: [jsonl:sessions.jsonl]
+//	  studio:
+//	    device: first
+//	    stages:
+//	      - noteDuration
+//	      - beat
+//	      - name: timing
+//	        options: {bpm: 90, subdivision: 2}
+//	      - chord
+//	    disabledStages: [interval]
+//	    bufferSize: 500
+//	    shutdownTimeout: 10s
+//	    events: [noteOn, noteOff]
+//	    logging: {level: warn, mode: production, output: /var/log/pianalyze.log}
+type Config struct {
+	Default  string             `yaml:"default"`  // Profile used when none is chosen.
+	Profiles map[string]Profile `yaml:"profiles"` // Profiles by name.
+}
+
+// Profile is a named set of settings. Empty settings keep their defaults.
+type Profile struct {
+	Device          string        `yaml:"device"`          // Device to capture from (see device.Find).
+	Stages          []Stage       `yaml:"stages"`          // Pipeline stages to run, in order; the built-in stages when empty.
+	DisabledStages  []string      `yaml:"disabledStages"`  // Pipeline stages not to run.
+	Sinks           []string      `yaml:"sinks"`           // Output sink specifications (see sink.Parse).
+	WebSocket       string        `yaml:"websocket"`       // Address of the live analysis WebSocket server.
+	Duration        time.Duration `yaml:"duration"`        // Length of capture sessions; 0 runs until interrupted.
+	PollInterval    time.Duration `yaml:"pollInterval"`    // How often the selected device is checked to still be connected.
+	BufferSize      int           `yaml:"bufferSize"`      // Capacity of the captured event channel.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"` // Time allowed at shutdown to process the remaining events, then to stop the stages.
+	Events          []string      `yaml:"events"`          // MIDI messages captured (see EventNames).
+	Logging         Logging       `yaml:"logging"`
+}
+
+// Stage selects a registered pipeline stage (see pipeline.Register), either by name alone or with options
+// decoded into the options type of the stage.
+type Stage struct {
+	Name    string
+	Options yaml.Node
+}
+
+// UnmarshalYAML reads a stage name, or a mapping with the name and options of the stage.
+func (s *Stage) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Name)
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: a stage is a name or a mapping with its name and options", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "name":
+			if err := value.Decode(&s.Name); err != nil {
+				return err
+			}
+		case "options":
+			s.Options = *value
+		default:
+			return fmt.Errorf("line %d: unknown stage setting %q (want name or options)", key.Line, key.Value)
+		}
+	}
+	return nil
+}
+
+// Spec returns the pipeline stage selected, with its options rejecting unknown settings.
+func (s Stage) Spec() pipeline.StageSpec {
+	spec := pipeline.StageSpec{Name: s.Name}
+	if !s.Options.IsZero() {
+		options := s.Options
+		spec.Decode = func(target any) error { return decodeStrict(&options, target) }
+	}
+	return spec
+}
+
+// decodeStrict decodes node into v, rejecting unknown settings like Parse does, which yaml.Node.Decode does not.
+// Errors refer to the line of node, as the lines of the re-encoded node do not match the file.
+func decodeStrict(node *yaml.Node, v any) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(v)
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	problems := make([]string, 0, len(typeErr.Errors))
+	for _, problem := range typeErr.Errors {
+		if _, message, ok := strings.Cut(problem, ": "); ok && strings.HasPrefix(problem, "line ") {
+			problem = message
+		}
+		problems = append(problems, problem)
+	}
+	return fmt.Errorf("line %d: %s", node.Line, strings.Join(problems, "; "))
+}
+
+// Logging configures the logger.
+type Logging struct {
+	Level  string `yaml:"level"`  // Minimum level logged: debug, info, warn or error.
+	Mode   string `yaml:"mode"`   // Log format: development (console) or production (JSON).
+	Output string `yaml:"output"` // stdout, stderr or a file path.
+}
+
+// DefaultPath returns the default configuration file, pianalyze/config.yaml under the user configuration directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pianalyze", "config.yaml"), nil
+}
+
+// EventNames returns the names accepted in event filters, in alphabetical order.
+func EventNames() []string {
+	names := make([]string, 0, len(events))
+	for name := range events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EventCommands returns the MIDI commands of the named events.
+func EventCommands(names []string) ([]contracts.MIDICommand, error) {
+	commands := make([]contracts.MIDICommand, 0, len(names))
+	for _, name := range names {
+		command, ok := events[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown event %q (want one of %s)", name, strings.Join(EventNames(), ", "))
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// Load reads and validates the configuration file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Parse reads and validates a YAML configuration. Unknown settings are rejected, so that typos do not go
+// unnoticed.
+func Parse(r io.Reader) (*Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks every profile, reporting all the problems found, one per line.
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Default != "" {
+		if _, ok := c.Profiles[c.Default]; !ok {
+			problems = append(problems, fmt.Sprintf("default: profile %q is not defined", c.Default))
+		}
+	}
+	for _, name := range c.names() {
+		for _, problem := range c.Profiles[name].problems() {
+			problems = append(problems, fmt.Sprintf("profiles.%s.%s", name, problem))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w:\n  %s", ErrInvalidConfig, strings.Join(problems, "\n  "))
+}
+
+// Profile returns the named profile, or the default profile when name is empty. Without a default profile,
+// an empty name returns an empty profile.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: profile %q is not defined (available: %s)",
+			ErrInvalidConfig, name, strings.Join(c.names(), ", "))
+	}
+	return profile, nil
+}
+
+// names returns the profile names in alphabetical order.
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// problems describes every invalid setting of the profile.
+func (p Profile) problems() []string {
+	var problems []string
+	seen := make(map[string]bool, len(p.Stages))
+	for _, stage := range p.Stages {
+		if seen[stage.Name] {
+			problems = append(problems, fmt.Sprintf("stages: stage %q is listed twice", stage.Name))
+		}
+		seen[stage.Name] = true
+		if err := pipeline.CheckStage(stage.Spec()); err != nil {
+			problems = append(problems, "stages: "+err.Error())
+		}
+	}
+	for _, name := range p.DisabledStages {
+		if err := pipeline.CheckDisabled(name); err != nil {
+			problems = append(problems, "disabledStages: "+err.Error())
+		}
+	}
+	for _, spec := range p.Sinks {
+		if err := sink.Validate(spec); err != nil {
+			problems = append(problems, "sinks: "+err.Error())
+		}
+	}
+	if p.Duration < 0 {
+		problems = append(problems, "duration: must not be negative")
+	}
+	if p.PollInterval < 0 {
+		problems = append(problems, "pollInterval: must not be negative")
+	}
+	if p.ShutdownTimeout < 0 {
+		problems = append(problems, "shutdownTimeout: must not be negative")
+	}
+	if p.BufferSize < 0 {
+		problems = append(problems, "bufferSize: must not be negative")
+	}
+	if _, err := EventCommands(p.Events); err != nil {
+		problems = append(problems, "events: "+err.Error())
+	}
+	if p.Logging.Level != "" {
+		if _, err := zapcore.ParseLevel(p.Logging.Level); err != nil {
+			problems = append(problems, "logging.level: "+err.Error())
+		}
+	}
+	switch p.Logging.Mode {
+	case "", ModeDevelopment, ModeProduction:
+	default:
+		problems = append(problems, fmt.Sprintf("logging.mode: unknown mode %q (want %s or %s)",
+			p.Logging.Mode, ModeDevelopment, ModeProduction))
+	}
+	return problems
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/pipeline"
+)
+
+const validConfig = `
+default: studio
+profiles:
+  studio:
+    device: yamaha
+    stages:
+      - beat
+      - name: timing
+        options: {bpm: 90, subdivision: 2, tolerance: 20ms}
+      - chord
+    disabledStages: [interval]
+    sinks: [jsonl:stdout]
+    duration: 30m
+    events: [noteOn, noteOff]
+    logging: {level: warn, mode: production}
+  quiet: {}
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(validConfig))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	profile, err := cfg.Profile("")
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if profile.Device != "yamaha" || profile.Duration != 30*time.Minute || profile.Logging.Level != "warn" {
+		t.Errorf("default profile = %+v", profile)
+	}
+	if len(profile.Stages) != 3 || profile.Stages[1].Name != pipeline.StageTiming {
+		t.Fatalf("stages = %+v", profile.Stages)
+	}
+
+	var timing pipeline.TimingOptions
+	if err := profile.Stages[1].Spec().Decode(&timing); err != nil {
+		t.Fatalf("decoding the timing options: %v", err)
+	}
+	if timing.BPM != 90 || timing.Subdivision != 2 || timing.Tolerance != 20*time.Millisecond {
+		t.Errorf("timing options = %+v", timing)
+	}
+	if profile.Stages[0].Spec().Decode != nil {
+		t.Error("a stage given by name alone has options to decode")
+	}
+
+	commands, err := EventCommands(profile.Events)
+	if err != nil || len(commands) != 2 || commands[0] != contracts.NoteOn {
+		t.Errorf("EventCommands(%v) = %v, %v", profile.Events, commands, err)
+	}
+
+	if quiet, err := cfg.Profile("quiet"); err != nil || quiet.Device != "" {
+		t.Errorf("Profile(quiet) = %+v, %v", quiet, err)
+	}
+	if _, err := cfg.Profile("missing"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Profile(missing) error = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string // Parts of the error message.
+	}{
+		{
+			name:   "unknown setting",
+			config: "profiles:\n  p:\n    devise: yamaha\n",
+			want:   []string{"line 3", "devise"},
+		},
+		{
+			name:   "undefined default profile",
+			config: "default: live\nprofiles:\n  p: {}\n",
+			want:   []string{`default: profile "live" is not defined`},
+		},
+		{
+			name:   "unknown stage",
+			config: "profiles:\n  p:\n    stages: [reverb]\n",
+			want:   []string{"profiles.p.stages", `unknown stage "reverb"`},
+		},
+		{
+			name:   "stage listed twice",
+			config: "profiles:\n  p:\n    stages: [chord, chord]\n",
+			want:   []string{`stage "chord" is listed twice`},
+		},
+		{
+			name: "invalid stage options",
+			config: "profiles:\n  p:\n    stages:\n      - name: timing\n" +
+				"        options:\n          bpm: fast\n",
+			want: []string{"profiles.p.stages", "line 6"},
+		},
+		{
+			name: "unknown stage option",
+			config: "profiles:\n  p:\n    stages:\n      - name: timing\n" +
+				"        options: {tempo: 90}\n",
+			want: []string{"profiles.p.stages", "tempo"},
+		},
+		{
+			name:   "always enabled stage disabled",
+			config: "profiles:\n  p:\n    disabledStages: [final]\n",
+			want:   []string{"profiles.p.disabledStages", "always enabled"},
+		},
+		{
+			name: "every problem reported at once",
+			config: "profiles:\n  p:\n    duration: -1s\n    bufferSize: -5\n    events: [noteUp]\n" +
+				"    logging: {level: loud, mode: verbose}\n    sinks: [ftp://host]\n",
+			want: []string{
+				"profiles.p.duration", "profiles.p.bufferSize", "profiles.p.events",
+				"profiles.p.logging.level", "profiles.p.logging.mode", "profiles.p.sinks",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.config))
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("Parse() error = %v, want ErrInvalidConfig", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Parse() error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 // Default values for chord detection in the pipeline context
 const (
 	DefaultKey       = "Unknown Key"
@@ -13,31 +15,79 @@ const (
 
 // Logger messages for various stages
 const (
-	MsgMIDIClientSetupError      = "Failed to set up MIDI client"
-	MsgMIDIClientSetupSuccess    = "MIDI client setup successfully"
-	MsgMIDIEventCaptureStarted   = "Capturing MIDI events. Press Ctrl+C to stop."
-	MsgDeviceSelectionError      = "Failed to select MIDI device"
-	MsgMIDIProcessingError       = "Pipeline processing error"
-	MsgNoPreviousEvent           = "No previous event, interval set to 0"
-	MsgNoteOnDetected            = "Note On event detected"
-	MsgNoteOffDetected           = "Note Off event detected"
-	MsgNoteOffViaVelocity0       = "Note Off via NoteOn with Velocity 0"
-	MsgChordAndInversionDetected = "Chord and inversion identified"
-	MsgTriadIdentified           = "Triad identified"
-	MsgNotTriad                  = "Chord is not a triad"
-	MsgUnknownChord              = "Chord not identified, set to Unknown Chord"
-	MsgUnknownTriad              = "Triad not identified, set to Unknown Triad"
-	MsgPipelineContextMIDI       = "PipelineContext MIDI Event"
-	MsgPipelineAdditionalDetails = "PipelineContext Additional Details"
-	MsgStatePressedNotes         = "State: Pressed Notes"
-	MsgStateLastNoteTime         = "State: Last Note Time"
-	MsgIntervalCalculated        = "Interval calculated"
+	MsgMIDIClientSetupError        = "Failed to set up MIDI client"
+	MsgMIDIClientSetupSuccess      = "MIDI client setup successfully"
+	MsgMIDIEventCaptureStarted     = "Capturing MIDI events. Press Ctrl+C to stop."
+	MsgDeviceSelectionError        = "Failed to select MIDI device"
+	MsgMIDIProcessingError         = "Pipeline processing error"
+	MsgNoPreviousEvent             = "No previous event, interval set to 0"
+	MsgNoteOnDetected              = "Note On event detected"
+	MsgNoteOffDetected             = "Note Off event detected"
+	MsgNoteOffViaVelocity0         = "Note Off via NoteOn with Velocity 0"
+	MsgChordAndInversionDetected   = "Chord and inversion identified"
+	MsgTriadIdentified             = "Triad identified"
+	MsgNotTriad                    = "Chord is not a triad"
+	MsgUnknownChord                = "Chord not identified, set to Unknown Chord"
+	MsgUnknownTriad                = "Triad not identified, set to Unknown Triad"
+	MsgPipelineContextMIDI         = "PipelineContext MIDI Event"
+	MsgPipelineAdditionalDetails   = "PipelineContext Additional Details"
+	MsgStatePressedNotes           = "State: Pressed Notes"
+	MsgStateLastNoteTime           = "State: Last Note Time"
+	MsgIntervalCalculated          = "Interval calculated"
+	MsgSMFLoaded                   = "MIDI file loaded"
+	MsgSMFReadError                = "Failed to read MIDI file"
+	MsgSMFReplayStarted            = "Replaying MIDI file. Press Ctrl+C to stop."
+	MsgSMFReplayError              = "MIDI file replay error"
+	MsgRecordingStarted            = "Recording session to MIDI file"
+	MsgRecordingSaved              = "Session recording saved"
+	MsgRecordingSaveError          = "Failed to save session recording"
+	MsgSustainPedalPressed         = "Sustain pedal pressed"
+	MsgSustainPedalReleased        = "Sustain pedal released"
+	MsgSinkWriteError              = "Failed to write snapshot to sink"
+	MsgPipelineSetupError          = "Failed to set up the processing pipeline"
+	MsgWebSocketServerStarted      = "WebSocket server listening"
+	MsgWebSocketServerError        = "WebSocket server error"
+	MsgWebSocketUpgradeError       = "WebSocket upgrade failed"
+	MsgWebSocketClientConnected    = "WebSocket client connected"
+	MsgWebSocketClientDisconnected = "WebSocket client disconnected"
+	MsgWebSocketClientSlow         = "WebSocket client too slow, disconnecting"
+	MsgWebSocketOriginRejected     = "WebSocket connection from a foreign origin rejected"
+	MsgKeyDetected                 = "Key detected"
+	MsgKeyChanged                  = "Key change detected"
+	MsgRomanNumeral                = "Roman numeral analysis"
+	MsgNoteCompleted               = "Note completed"
+	MsgBeatTracked                 = "Beat position estimated"
+	MsgTempoSummary                = "Session tempo summary"
+	MsgTimingScored                = "Onset timing scored"
+	MsgTimingSummary               = "Session timing accuracy"
+	MsgScoreLoaded                 = "Reference score loaded"
+	MsgScoreNote                   = "Note aligned to score"
+	MsgScoreFinished               = "Reached the end of the score"
+	MsgScoreSummary                = "Score performance summary"
+	MsgLessonLoaded                = "Lesson loaded"
+	MsgDrillStarted                = "Drill started"
+	MsgProgressSaved               = "Practice session saved"
+	MsgProgressSaveError           = "Failed to save the practice session"
+	MsgProgressReadError           = "Failed to read the practice progress"
+	MsgGameSaveError               = "Failed to update the score and achievements"
+	MsgAchievementsLoadError       = "Failed to load the achievements"
+	MsgLoggerSetupError            = "Failed to set up the logger"
+	MsgDeviceDisconnected          = "MIDI device disconnected, waiting for it to be plugged back in"
+	MsgDeviceReconnected           = "MIDI device reconnected, capture resumed"
+	MsgDeviceReconnectError        = "Failed to reconnect MIDI device"
+	MsgConfigError                 = "Failed to load the configuration"
+	MsgPipelineAssembled           = "Pipeline stages assembled"
+	MsgStageOptionsIgnored         = "Options given for a pipeline stage that is not run"
+	MsgStageStartError             = "Failed to start the pipeline stages"
+	MsgStageStopError              = "Pipeline stage failed to stop"
+	MsgDrainTimeout                = "Shutdown deadline reached before every captured event was processed"
+	MsgEventStuck                  = "A pipeline stage is stuck processing an event, shutting down without it"
 )
 
 // Errors and Warnings
 const (
 	ErrNoMIDIDevices        = "no MIDI devices found"
-	ErrInvalidDeviceID      = "invalid device ID selected"
+	ErrNoDeviceSelected     = "no MIDI device selected: set -device or " + EnvDevice + ", or run in a terminal to choose one"
 	ErrLoggerInitialization = "Error initializing logger"
 )
 
@@ -46,8 +96,26 @@ const (
 	BuildModeProduction = "production"
 )
 
+// Environment variables read by the application.
+const (
+	EnvDevice = "PIANALYZE_DEVICE" // MIDI device used when none is given on the command line.
+	EnvConfig = "PIANALYZE_CONFIG" // Configuration file used when none is given on the command line.
+)
+
 // Other default constants
 const (
-	MIDIChannelBufferSize = 100
-	OutOfRangeNote        = "Out of Range"
+	MIDIChannelBufferSize  = 100
+	DefaultShutdownTimeout = 5 * time.Second // Time allowed at shutdown to process the remaining events, then to stop the stages.
+	OutOfRangeNote         = "Out of Range"
+)
+
+// MIDI status bytes not defined by the MIDI SDK contracts.
+const (
+	ControlChangeCommand = 0xB0
+)
+
+// Damper (sustain) pedal controller number and the value from which it is considered pressed.
+const (
+	SustainPedalController = 64
+	SustainPedalThreshold  = 64
 )
@@ -0,0 +1,90 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+)
+
+// First selects the first available device.
+const First = "first"
+
+// Errors returned by Find.
+var (
+	ErrNoDevices = errors.New("no MIDI devices found")
+	ErrNotFound  = errors.New("no MIDI device matches")
+	ErrAmbiguous = errors.New("several MIDI devices match")
+)
+
+// Find returns the index in devices of the device selected by spec, which is one of:
+//   - "first", the first device;
+//   - an index in devices, as listed by the devices command;
+//   - the name of a device, compared ignoring case;
+//   - part of the name of a single device, ignoring case, spaces and punctuation (e.g. "yamaha p45").
+func Find(devices []contracts.DeviceInfo, spec string) (int, error) {
+	if len(devices) == 0 {
+		return 0, ErrNoDevices
+	}
+	spec = strings.TrimSpace(spec)
+	if strings.EqualFold(spec, First) {
+		return 0, nil
+	}
+	if index, err := strconv.Atoi(spec); err == nil {
+		if index < 0 || index >= len(devices) {
+			return 0, fmt.Errorf("%w index %d: %d device(s) available", ErrNotFound, index, len(devices))
+		}
+		return index, nil
+	}
+
+	for i, device := range devices {
+		if strings.EqualFold(device.Name, spec) {
+			return i, nil
+		}
+	}
+
+	query := fold(spec)
+	var matches []int
+	for i, device := range devices {
+		if query != "" && strings.Contains(fold(device.Name), query) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("%w %q (available: %s)", ErrNotFound, spec, names(devices, nil))
+	case 1:
+		return matches[0], nil
+	default:
+		return 0, fmt.Errorf("%w %q: %s", ErrAmbiguous, spec, names(devices, matches))
+	}
+}
+
+// fold lowercases a name and drops everything but letters and digits, so that "Digital Piano" matches
+// "digital-piano".
+func fold(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// names lists the names of the devices at indexes, or of every device when indexes is nil.
+func names(devices []contracts.DeviceInfo, indexes []int) string {
+	if indexes == nil {
+		for i := range devices {
+			indexes = append(indexes, i)
+		}
+	}
+	quoted := make([]string, len(indexes))
+	for i, index := range indexes {
+		quoted[i] = fmt.Sprintf("[%d] %q", index, devices[index].Name)
+	}
+	return strings.Join(quoted, ", ")
+}
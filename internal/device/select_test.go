@@ -0,0 +1,52 @@
+package device
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+)
+
+func TestFind(t *testing.T) {
+	devices := []contracts.DeviceInfo{
+		{Name: "Yamaha P-45 MIDI 1"},
+		{Name: "Digital Piano"},
+		{Name: "Digital Piano Bluetooth"},
+		{Name: "IAC Driver Bus 1"},
+	}
+	tests := []struct {
+		name    string
+		devices []contracts.DeviceInfo
+		spec    string
+		want    int
+		wantErr error
+	}{
+		{name: "first", devices: devices, spec: "first", want: 0},
+		{name: "first ignoring case and spaces", devices: devices, spec: " First ", want: 0},
+		{name: "index", devices: devices, spec: "2", want: 2},
+		{name: "index out of range", devices: devices, spec: "4", wantErr: ErrNotFound},
+		{name: "negative index", devices: devices, spec: "-1", wantErr: ErrNotFound},
+		{name: "exact name ignoring case", devices: devices, spec: "digital piano", want: 1},
+		{name: "part of a name", devices: devices, spec: "yamaha p45", want: 0},
+		{name: "part of a name with punctuation", devices: devices, spec: "iac-driver", want: 3},
+		{name: "part of a single name", devices: devices, spec: "bluetooth", want: 2},
+		{name: "ambiguous", devices: devices, spec: "piano", wantErr: ErrAmbiguous},
+		{name: "not found", devices: devices, spec: "roland", wantErr: ErrNotFound},
+		{name: "only punctuation", devices: devices, spec: "--", wantErr: ErrNotFound},
+		{name: "no devices", spec: "first", wantErr: ErrNoDevices},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Find(tt.devices, tt.spec)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Find(%q) error = %v, want %v", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Find(%q) = %d, %v, want %d", tt.spec, got, err, tt.want)
+			}
+		})
+	}
+}
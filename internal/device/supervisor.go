@@ -0,0 +1,264 @@
+package device
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"go.uber.org/zap"
+)
+
+// DefaultPollInterval is how often the supervisor checks that the selected device is still connected.
+const DefaultPollInterval = time.Second
+
+// Supervisor is a contracts.ClientMIDI keeping the capture alive while the selected device is unplugged and
+// plugged back in. It polls the device list; when the device disappears, the keys and the sustain pedal still
+// held are released so no note is left sounding. Once a device of the same name is listed again, it is
+// selected on a client from connect and capture resumes into the same event channel, so the pipeline state and
+// recordings carry on as if nothing happened.
+type Supervisor struct {
+	PollInterval time.Duration
+
+	logger  *zap.Logger
+	connect func() (contracts.ClientMIDI, error)
+
+	mu        sync.Mutex
+	client    contracts.ClientMIDI
+	name      string // Name of the selected device.
+	capturing bool
+	stop      chan struct{}
+	done      chan struct{}
+	stopErr   error
+}
+
+// NewSupervisor supervises client. connect returns the client a reconnected device is selected on: a new
+// hardware client, since the connection of the lost one is gone, or client itself when it survives unplugs.
+func NewSupervisor(logger *zap.Logger, client contracts.ClientMIDI, connect func() (contracts.ClientMIDI, error)) *Supervisor {
+	return &Supervisor{
+		PollInterval: DefaultPollInterval,
+		logger:       logger,
+		connect:      connect,
+		client:       client,
+	}
+}
+
+// ListDevices lists the devices of the current client.
+func (s *Supervisor) ListDevices() ([]contracts.DeviceInfo, error) {
+	return s.current().ListDevices()
+}
+
+// SelectDevice selects a device by its index in ListDevices, remembering its name to find it again.
+func (s *Supervisor) SelectDevice(deviceID int) error {
+	client := s.current()
+	devices, err := client.ListDevices()
+	if err != nil {
+		return err
+	}
+	if err := client.SelectDevice(deviceID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if deviceID >= 0 && deviceID < len(devices) {
+		s.name = devices[deviceID].Name
+	}
+	return nil
+}
+
+// StartCapture starts capturing into eventChannel and supervising the selected device. A capture already in
+// progress, or still stopping, is stopped first.
+func (s *Supervisor) StartCapture(eventChannel chan contracts.MIDI) {
+	if eventChannel == nil {
+		return
+	}
+	s.mu.Lock()
+	started := s.done != nil
+	s.mu.Unlock()
+	if started {
+		_ = s.Stop()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Events pass through the supervisor, which keeps track of the keys held.
+	in := make(chan contracts.MIDI, max(cap(eventChannel), 1))
+	s.capturing = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	s.client.StartCapture(in)
+	go s.run(s.client, s.name, in, eventChannel, s.stop, s.done)
+}
+
+// Stop stops supervising and capturing. No event is sent to the capture channel once it returns, including when
+// another Stop is already in progress: every caller waits for the capture to be stopped.
+func (s *Supervisor) Stop() error {
+	s.mu.Lock()
+	if s.done == nil {
+		client := s.client
+		s.mu.Unlock()
+		return client.Stop()
+	}
+	if s.capturing {
+		s.capturing = false
+		close(s.stop)
+	}
+	done := s.done
+	s.mu.Unlock()
+
+	<-done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopErr
+}
+
+// current returns the client in use.
+func (s *Supervisor) current() contracts.ClientMIDI {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// run forwards events from in to out and polls the device list until stop is closed.
+func (s *Supervisor) run(client contracts.ClientMIDI, name string, in, out chan contracts.MIDI, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	held := newHeldKeys()
+	connected := true
+	for {
+		select {
+		case event := <-in:
+			held.track(event)
+			out <- event
+		case <-ticker.C:
+			index := indexOf(client, name)
+			switch {
+			case connected && index < 0:
+				connected = false
+				s.logger.Warn(constants.MsgDeviceDisconnected, zap.String("device", name))
+				for _, event := range held.release() {
+					out <- event
+				}
+			case !connected && index >= 0:
+				reconnected, err := s.reconnect(client, name, in)
+				if err != nil {
+					s.logger.Error(constants.MsgDeviceReconnectError, zap.String("device", name), zap.Error(err))
+					continue
+				}
+				client, connected = reconnected, true
+				s.logger.Info(constants.MsgDeviceReconnected, zap.String("device", name))
+			}
+		case <-stop:
+			// Keep forwarding while the client stops, since it may be waiting to deliver an event.
+			stopped := make(chan error, 1)
+			go func() { stopped <- client.Stop() }()
+			for {
+				select {
+				case event := <-in:
+					out <- event
+				case err := <-stopped:
+					for len(in) > 0 {
+						out <- <-in
+					}
+					s.mu.Lock()
+					s.stopErr = err
+					s.mu.Unlock()
+					return
+				}
+			}
+		}
+	}
+}
+
+// reconnect selects the device named name on a client from connect and resumes capturing into in. The lost
+// client is stopped when connect returns another one.
+func (s *Supervisor) reconnect(lost contracts.ClientMIDI, name string, in chan contracts.MIDI) (contracts.ClientMIDI, error) {
+	client, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	fresh := client != lost
+	if fresh {
+		_ = lost.Stop()
+	}
+
+	index := indexOf(client, name)
+	if index < 0 {
+		err = ErrNotFound
+	} else {
+		err = client.SelectDevice(index)
+	}
+	if err != nil {
+		if fresh {
+			_ = client.Stop()
+		}
+		return nil, err
+	}
+
+	if fresh {
+		client.StartCapture(in)
+	}
+	s.mu.Lock()
+	s.client = client
+	s.mu.Unlock()
+	return client, nil
+}
+
+// indexOf returns the index of the device named name in the list of client, or -1 if it is not listed.
+func indexOf(client contracts.ClientMIDI, name string) int {
+	devices, err := client.ListDevices()
+	if err != nil {
+		return -1
+	}
+	for i, device := range devices {
+		if device.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// heldKeys tracks the keys and sustain pedal held on the device.
+type heldKeys struct {
+	notes   map[byte]bool
+	sustain bool
+}
+
+func newHeldKeys() *heldKeys {
+	return &heldKeys{notes: make(map[byte]bool)}
+}
+
+// track updates the held keys with an event.
+func (h *heldKeys) track(event contracts.MIDI) {
+	switch {
+	case event.Command == byte(contracts.NoteOn) && event.Velocity > 0:
+		h.notes[event.Note] = true
+	case event.Command == byte(contracts.NoteOn) || event.Command == byte(contracts.NoteOff):
+		delete(h.notes, event.Note)
+	case event.Command == constants.ControlChangeCommand && event.Note == constants.SustainPedalController:
+		h.sustain = event.Velocity >= constants.SustainPedalThreshold
+	}
+}
+
+// release returns the events releasing every held key and the sustain pedal, and forgets them.
+func (h *heldKeys) release() []contracts.MIDI {
+	timestamp := uint64(time.Now().UTC().UnixNano())
+	var events []contracts.MIDI
+	for _, note := range slices.Sorted(maps.Keys(h.notes)) {
+		events = append(events, contracts.MIDI{Timestamp: timestamp, Command: byte(contracts.NoteOff), Note: note})
+	}
+	if h.sustain {
+		events = append(events, contracts.MIDI{
+			Timestamp: timestamp,
+			Command:   constants.ControlChangeCommand,
+			Note:      constants.SustainPedalController,
+		})
+	}
+	h.notes, h.sustain = make(map[byte]bool), false
+	return events
+}
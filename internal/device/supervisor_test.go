@@ -0,0 +1,282 @@
+package device
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/virtual"
+	"go.uber.org/zap"
+)
+
+// slowClient is a virtual client whose first Stop blocks until release is closed.
+type slowClient struct {
+	*virtual.Client
+	stopping chan struct{}
+	release  chan struct{}
+	once     sync.Once
+}
+
+func (c *slowClient) Stop() error {
+	first := false
+	c.once.Do(func() { first = true })
+	if first {
+		close(c.stopping)
+		<-c.release
+	}
+	return c.Client.Stop()
+}
+
+func TestSupervisorConcurrentStop(t *testing.T) {
+	client := &slowClient{
+		Client:   virtual.NewMIDIClient(),
+		stopping: make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+	supervisor := NewSupervisor(zap.NewNop(), client, func() (contracts.ClientMIDI, error) { return client, nil })
+	if err := supervisor.SelectDevice(0); err != nil {
+		t.Fatalf("SelectDevice: %v", err)
+	}
+	events := make(chan contracts.MIDI, 8)
+	supervisor.StartCapture(events)
+
+	first := make(chan error, 1)
+	go func() { first <- supervisor.Stop() }()
+	<-client.stopping
+
+	// A second Stop, racing the first, must not return while the capture is still being stopped.
+	second := make(chan error, 1)
+	go func() { second <- supervisor.Stop() }()
+	select {
+	case <-second:
+		t.Fatal("second Stop returned before the client was stopped")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(client.release)
+	for _, stopped := range []chan error{first, second} {
+		select {
+		case err := <-stopped:
+			if err != nil {
+				t.Errorf("Stop: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Stop did not return once the client was stopped")
+		}
+	}
+	// Closing the capture channel once every Stop returned must be safe.
+	close(events)
+	if err := supervisor.Stop(); err != nil {
+		t.Errorf("Stop after stopping: %v", err)
+	}
+}
+
+func TestSupervisorStopsEveryCaller(t *testing.T) {
+	client := virtual.NewMIDIClient()
+	supervisor := NewSupervisor(zap.NewNop(), client, func() (contracts.ClientMIDI, error) { return client, nil })
+	if err := supervisor.SelectDevice(0); err != nil {
+		t.Fatalf("SelectDevice: %v", err)
+	}
+	events := make(chan contracts.MIDI, 1)
+	supervisor.StartCapture(events)
+
+	// Keep the client sending so the supervisor is forwarding while it stops.
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for client.NoteOn(60, 100) == nil {
+		}
+	}()
+	go func() {
+		for range events {
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = supervisor.Stop()
+		}()
+	}
+	wg.Wait()
+	<-sent
+	close(events)
+}
+
+// testPollInterval makes the supervisor notice unplugs and plugs quickly.
+const testPollInterval = 5 * time.Millisecond
+
+var piano = []contracts.DeviceInfo{{Name: "Digital Piano"}}
+
+// receiveEvent returns the next captured event, failing the test if none arrives in time.
+func receiveEvent(t *testing.T, events chan contracts.MIDI) contracts.MIDI {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event captured")
+		return contracts.MIDI{}
+	}
+}
+
+// waitForNote plays note on client until it is captured, as the device is reconnected after a poll.
+func waitForNote(t *testing.T, client *virtual.Client, events chan contracts.MIDI, note byte) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		_ = client.NoteOn(note, 100)
+		select {
+		case event := <-events:
+			if event.Note != note {
+				t.Fatalf("captured %+v, want note %d", event, note)
+			}
+			return
+		case <-time.After(2 * testPollInterval):
+		}
+	}
+	t.Fatalf("note %d not captured: the device was not reconnected", note)
+}
+
+// startSupervisor selects the only device of client and starts capturing into a new channel.
+func startSupervisor(t *testing.T, client *virtual.Client, connect func() (contracts.ClientMIDI, error)) (*Supervisor, chan contracts.MIDI) {
+	t.Helper()
+	supervisor := NewSupervisor(zap.NewNop(), client, connect)
+	supervisor.PollInterval = testPollInterval
+	if err := supervisor.SelectDevice(0); err != nil {
+		t.Fatalf("SelectDevice: %v", err)
+	}
+	events := make(chan contracts.MIDI, 8)
+	supervisor.StartCapture(events)
+	return supervisor, events
+}
+
+func TestSupervisorReleasesKeysOnDisconnect(t *testing.T) {
+	client := virtual.NewMIDIClient()
+	client.SetDevices(piano)
+	supervisor, events := startSupervisor(t, client, func() (contracts.ClientMIDI, error) { return client, nil })
+	defer supervisor.Stop()
+
+	_ = client.NoteOn(60, 100)
+	_ = client.NoteOn(64, 100)
+	_ = client.NoteOn(67, 100)
+	_ = client.NoteOff(64)
+	_ = client.ControlChange(constants.SustainPedalController, 127)
+	for range 5 {
+		receiveEvent(t, events)
+	}
+
+	client.SetDevices(nil)
+	want := []contracts.MIDI{
+		{Command: byte(contracts.NoteOff), Note: 60},
+		{Command: byte(contracts.NoteOff), Note: 67},
+		{Command: constants.ControlChangeCommand, Note: constants.SustainPedalController},
+	}
+	for _, w := range want {
+		got := receiveEvent(t, events)
+		if got.Command != w.Command || got.Note != w.Note || got.Velocity != 0 {
+			t.Errorf("released %+v, want %+v", got, w)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("captured %+v after the keys held were released", event)
+	case <-time.After(10 * testPollInterval):
+	}
+}
+
+func TestSupervisorReconnects(t *testing.T) {
+	client := virtual.NewMIDIClient()
+	client.SetDevices(piano)
+	supervisor, events := startSupervisor(t, client, func() (contracts.ClientMIDI, error) { return client, nil })
+	defer supervisor.Stop()
+
+	_ = client.NoteOn(60, 100)
+	receiveEvent(t, events)
+	client.SetDevices(nil)
+	if event := receiveEvent(t, events); event.Command != byte(contracts.NoteOff) || event.Note != 60 {
+		t.Fatalf("captured %+v, want C4 released", event)
+	}
+
+	// The device comes back after another one, at a different index.
+	client.SetDevices([]contracts.DeviceInfo{{Name: "Synth"}, piano[0]})
+	waitForNote(t, client, events, 62)
+	if selected := client.SelectedDevice(); selected != 1 {
+		t.Errorf("selected device %d, want the piano at index 1", selected)
+	}
+}
+
+func TestSupervisorReconnectsOnNewClient(t *testing.T) {
+	lost := virtual.NewMIDIClient()
+	lost.SetDevices(piano)
+	fresh := virtual.NewMIDIClient()
+	fresh.SetDevices(piano)
+	connects := 0
+	supervisor, events := startSupervisor(t, lost, func() (contracts.ClientMIDI, error) {
+		// The first attempt fails, as when the driver is not ready yet; the supervisor tries again.
+		if connects++; connects == 1 {
+			return nil, errors.New("driver not ready")
+		}
+		return fresh, nil
+	})
+
+	_ = lost.NoteOn(60, 100)
+	receiveEvent(t, events)
+	lost.SetDevices(nil)
+	receiveEvent(t, events) // C4 released: the supervisor saw the device go.
+	lost.SetDevices(piano)
+	waitForNote(t, fresh, events, 62)
+	if connects != 2 {
+		t.Errorf("connected %d times, want 2", connects)
+	}
+	if err := lost.NoteOn(60, 100); !errors.Is(err, virtual.ErrNotCapturing) {
+		t.Errorf("lost client NoteOn = %v, want it stopped", err)
+	}
+
+	// Stopping the supervisor stops the client in use.
+	if err := supervisor.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if err := fresh.NoteOn(60, 100); !errors.Is(err, virtual.ErrNotCapturing) {
+		t.Errorf("fresh client NoteOn after Stop = %v, want it stopped", err)
+	}
+}
+
+func TestSupervisorStop(t *testing.T) {
+	client := virtual.NewMIDIClient()
+	supervisor := NewSupervisor(zap.NewNop(), client, func() (contracts.ClientMIDI, error) { return client, nil })
+	// Stopping before capture starts stops the client.
+	if err := supervisor.Stop(); err != nil {
+		t.Fatalf("Stop before capture: %v", err)
+	}
+
+	supervisor, events := startSupervisor(t, client, func() (contracts.ClientMIDI, error) { return client, nil })
+	_ = client.NoteOn(60, 100)
+	if err := supervisor.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	// Events sent before Stop are forwarded; none are sent after it returns.
+	if len(events) != 1 {
+		t.Errorf("%d events captured, want the note played before Stop", len(events))
+	}
+	if err := client.NoteOn(62, 100); !errors.Is(err, virtual.ErrNotCapturing) {
+		t.Errorf("NoteOn after Stop = %v, want ErrNotCapturing", err)
+	}
+	close(events)
+
+	// Capture can start again after stopping.
+	restarted := make(chan contracts.MIDI, 1)
+	supervisor.StartCapture(restarted)
+	_ = client.NoteOn(64, 100)
+	if event := receiveEvent(t, restarted); event.Note != 64 {
+		t.Errorf("captured %+v after restarting, want E4", event)
+	}
+	if err := supervisor.Stop(); err != nil {
+		t.Errorf("Stop: %v", err)
+	}
+}
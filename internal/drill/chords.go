@@ -0,0 +1,162 @@
+package drill
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// ErrInvalidDifficulty is returned for an unknown difficulty level.
+var ErrInvalidDifficulty = errors.New("invalid difficulty")
+
+// Difficulty selects the chord qualities a chord drill prompts.
+type Difficulty string
+
+// Difficulty levels, each including the chords of the previous ones.
+const (
+	Triads     Difficulty = "triads"     // Three-note chords.
+	Sevenths   Difficulty = "sevenths"   // Triads and four-note chords within the octave (sixths and sevenths).
+	Extensions Difficulty = "extensions" // Every chord of the dictionary, including ninths, elevenths and thirteenths.
+)
+
+// ParseDifficulty parses a difficulty level name.
+func ParseDifficulty(name string) (Difficulty, error) {
+	switch difficulty := Difficulty(strings.ToLower(strings.TrimSpace(name))); difficulty {
+	case Triads, Sevenths, Extensions:
+		return difficulty, nil
+	default:
+		return "", fmt.Errorf("%w: %q (want triads, sevenths or extensions)", ErrInvalidDifficulty, name)
+	}
+}
+
+// includes reports whether a chord with the given intervals belongs to the difficulty level.
+func (d Difficulty) includes(intervals []int) bool {
+	switch d {
+	case Triads:
+		return len(intervals) == 3
+	case Sevenths:
+		return len(intervals) <= 4 && intervals[len(intervals)-1] < 12
+	default:
+		return true
+	}
+}
+
+// ChordPrompt is a chord the student is asked to play.
+type ChordPrompt struct {
+	Root      int    // Pitch class of the root.
+	Quality   string // Chord quality as named in the chord dictionary.
+	Inversion int    // Required inversion; 0 is root position.
+}
+
+// String describes the chord, e.g. "Cmaj7 (1st inversion)".
+func (p ChordPrompt) String() string {
+	return midi.ChordSymbol(p.Root, p.Quality) + " (" + midi.InversionName(p.Inversion) + ")"
+}
+
+// Voicing returns a close voicing of the chord with the required inversion in the bass, around middle C.
+func (p ChordPrompt) Voicing() []int {
+	intervals := midi.ChordIntervals(p.Quality)
+	notes := make([]int, len(intervals))
+	for i, interval := range intervals {
+		note := 60 + p.Root + interval
+		if i < p.Inversion {
+			note += 12
+		}
+		notes[i] = note
+	}
+	return notes
+}
+
+// ChordPrompts returns every quality and inversion a chord drill at difficulty may prompt, with a root of C.
+// Only chords named back by midi.GetChordName when voiced in that inversion are kept, so every prompt can be
+// answered; qualities that share their notes with a more common chord are left out.
+func ChordPrompts(difficulty Difficulty, inversions bool) []ChordPrompt {
+	var prompts []ChordPrompt
+	for _, quality := range midi.ChordQualities() {
+		intervals := midi.ChordIntervals(quality)
+		if !difficulty.includes(intervals) {
+			continue
+		}
+		for inversion := 0; inversion < len(intervals) && intervals[inversion] < 12; inversion++ {
+			if inversion > 0 && !inversions {
+				break
+			}
+			prompt := ChordPrompt{Quality: quality, Inversion: inversion}
+			name, inversionName, root, found := midi.GetChordName(prompt.Voicing())
+			if found && name == quality && root == 0 && inversionName == midi.InversionName(inversion) {
+				prompts = append(prompts, prompt)
+			}
+		}
+	}
+	return prompts
+}
+
+// ChordGenerator draws random chord tasks from prompts, transposed to a random root. Qualities are drawn
+// evenly, whatever the number of inversions each one allows. Returns ErrNoTasks when prompts is empty, such as
+// when no chord of a difficulty is left once filtered.
+func ChordGenerator(prompts []ChordPrompt, rng *rand.Rand) (Generator, error) {
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("%w: no chord prompts", ErrNoTasks)
+	}
+	byQuality := make(map[string][]ChordPrompt)
+	var qualities []string
+	for _, prompt := range prompts {
+		if _, ok := byQuality[prompt.Quality]; !ok {
+			qualities = append(qualities, prompt.Quality)
+		}
+		byQuality[prompt.Quality] = append(byQuality[prompt.Quality], prompt)
+	}
+
+	return func() Task {
+		candidates := byQuality[qualities[rng.IntN(len(qualities))]]
+		prompt := candidates[rng.IntN(len(candidates))]
+		prompt.Root = rng.IntN(12)
+		return &ChordTask{Chord: prompt}
+	}, nil
+}
+
+// ChordTask asks for a chord in a given inversion. The chord held down is named with midi.GetChordName;
+// the task passes once it matches, and an attempt fails when every key is released after another chord.
+type ChordTask struct {
+	Chord ChordPrompt
+
+	heard string // Last other chord held during the attempt.
+}
+
+// Prompt describes the chord to play.
+func (t *ChordTask) Prompt() string {
+	return t.Chord.String()
+}
+
+// Category groups the statistics by chord quality.
+func (t *ChordTask) Category() string {
+	return t.Chord.Quality
+}
+
+// Evaluate names the chord held down and compares it with the prompt.
+func (t *ChordTask) Evaluate(snapshot *sink.Snapshot) (Outcome, string) {
+	if snapshot.Command == byte(contracts.NoteOn) && snapshot.Velocity > 0 && len(snapshot.PressedNotes) >= 3 {
+		name, inversion, root, found := midi.GetChordName(snapshot.PressedNotes)
+		switch {
+		case !found:
+			t.heard = "an unknown chord"
+		case name == t.Chord.Quality && root == t.Chord.Root && inversion == midi.InversionName(t.Chord.Inversion):
+			t.heard = ""
+			return Passed, ""
+		default:
+			t.heard = midi.ChordSymbol(root, name) + " (" + inversion + ")"
+		}
+	}
+
+	if len(snapshot.PressedNotes) == 0 && t.heard != "" {
+		feedback := "Heard " + t.heard + "."
+		t.heard = ""
+		return Failed, feedback
+	}
+	return Pending, ""
+}
@@ -0,0 +1,133 @@
+package drill
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+func TestParseDifficulty(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Difficulty
+		wantErr bool
+	}{
+		{"triads", Triads, false},
+		{" Sevenths ", Sevenths, false},
+		{"EXTENSIONS", Extensions, false},
+		{"", "", true},
+		{"ninths", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDifficulty(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseDifficulty(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidDifficulty) {
+			t.Errorf("ParseDifficulty(%q) error = %v, want ErrInvalidDifficulty", tt.name, err)
+		}
+	}
+}
+
+func TestChordPrompts(t *testing.T) {
+	tests := []struct {
+		difficulty Difficulty
+		inversions bool
+		include    []string
+		exclude    []string
+	}{
+		{Triads, false, []string{"Major", "Minor", "Diminished"}, []string{"Dominant 7th", "Add 9"}},
+		{Sevenths, false, []string{"Major", "Dominant 7th", "Major 6th"}, []string{"Add 9", "Major 9th"}},
+		{Extensions, false, []string{"Major", "Dominant 7th", "Add 9"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.difficulty), func(t *testing.T) {
+			qualities := map[string]bool{}
+			for _, prompt := range ChordPrompts(tt.difficulty, tt.inversions) {
+				qualities[prompt.Quality] = true
+				if prompt.Inversion != 0 {
+					t.Errorf("%s prompted in inversion %d without inversions", prompt.Quality, prompt.Inversion)
+				}
+				// Every prompt must be answerable: its voicing is named back as the prompted chord.
+				name, inversion, root, found := midi.GetChordName(prompt.Voicing())
+				if !found || name != prompt.Quality || root != prompt.Root || inversion != midi.InversionName(prompt.Inversion) {
+					t.Errorf("%v is heard as %s %s on %d", prompt, name, inversion, root)
+				}
+			}
+			for _, quality := range tt.include {
+				if !qualities[quality] {
+					t.Errorf("%s missing", quality)
+				}
+			}
+			for _, quality := range tt.exclude {
+				if qualities[quality] {
+					t.Errorf("%s prompted", quality)
+				}
+			}
+		})
+	}
+}
+
+func TestChordPromptsInversions(t *testing.T) {
+	inversions := map[int]bool{}
+	for _, prompt := range ChordPrompts(Triads, true) {
+		if prompt.Quality == "Major" {
+			inversions[prompt.Inversion] = true
+		}
+	}
+	if len(inversions) != 3 {
+		t.Errorf("major triad prompted in inversions %v, want 0, 1 and 2", inversions)
+	}
+}
+
+func TestChordGenerator(t *testing.T) {
+	if _, err := ChordGenerator(nil, newTestRand()); !errors.Is(err, ErrNoTasks) {
+		t.Fatalf("ChordGenerator(nil) error = %v, want ErrNoTasks", err)
+	}
+
+	prompts := []ChordPrompt{{Quality: "Major"}, {Quality: "Minor"}, {Quality: "Minor", Inversion: 1}}
+	next, err := ChordGenerator(prompts, newTestRand())
+	if err != nil {
+		t.Fatalf("ChordGenerator: %v", err)
+	}
+	qualities := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		task := next().(*ChordTask)
+		if task.Chord.Root < 0 || task.Chord.Root > 11 {
+			t.Fatalf("drew root %d", task.Chord.Root)
+		}
+		qualities[task.Chord.Quality]++
+	}
+	// Qualities are drawn evenly, even though minor has two prompts.
+	if qualities["Major"] < 400 || qualities["Minor"] < 400 {
+		t.Errorf("drew qualities %v, want them about even", qualities)
+	}
+}
+
+func TestChordTask(t *testing.T) {
+	task := &ChordTask{Chord: ChordPrompt{Root: 7, Quality: "Major", Inversion: 1}}
+
+	// G major in root position is the wrong inversion; releasing it fails the attempt.
+	if outcome, _ := task.Evaluate(chord(55, 59, 62)); outcome != Pending {
+		t.Fatalf("wrong inversion held: outcome %v, want pending", outcome)
+	}
+	if outcome, feedback := task.Evaluate(&sink.Snapshot{Command: byte(contracts.NoteOff)}); outcome != Failed || feedback == "" {
+		t.Fatalf("wrong inversion released: outcome %v %q, want failed with feedback", outcome, feedback)
+	}
+	if outcome, _ := task.Evaluate(chord(59, 62, 67)); outcome != Passed {
+		t.Errorf("G/B held: outcome %v, want passed", outcome)
+	}
+}
+
+// chord returns the snapshot of the last Note On of notes held together.
+func chord(notes ...int) *sink.Snapshot {
+	return &sink.Snapshot{
+		Command:      byte(contracts.NoteOn),
+		Note:         byte(notes[len(notes)-1]),
+		Velocity:     80,
+		PressedNotes: notes,
+	}
+}
@@ -0,0 +1,251 @@
+package drill
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// Default drill settings.
+const (
+	DefaultRounds      = 10 // Prompts in a drill.
+	DefaultMaxAttempts = 3  // Failed attempts before the answer is shown and the drill moves on.
+)
+
+// Outcome is the result of evaluating a task against a processed event.
+type Outcome int
+
+// Task outcomes.
+const (
+	Pending Outcome = iota // The attempt is still in progress.
+	Passed                 // The task was played correctly.
+	Failed                 // The attempt is over and was wrong.
+)
+
+// Task is a single prompt of a drill.
+type Task interface {
+	// Prompt describes what the student must play.
+	Prompt() string
+	// Category groups tasks in the statistics (e.g. the chord quality).
+	Category() string
+	// Evaluate judges the attempt in progress after a processed event. It may return a progress note with
+	// Pending or Passed; with Failed it returns what went wrong and resets itself for the next attempt.
+	Evaluate(snapshot *sink.Snapshot) (Outcome, string)
+}
+
+// Generator draws the next task of a drill.
+type Generator func() Task
+
+// ErrNoTasks is returned when a generator is given nothing to draw tasks from.
+var ErrNoTasks = errors.New("no tasks to draw")
+
+// Stats are the results of a category of tasks.
+type Stats struct {
+	Category string        `json:"category"`
+	Prompts  int           `json:"prompts"`  // Tasks prompted.
+	FirstTry int           `json:"firstTry"` // Tasks passed on the first attempt.
+	Passed   int           `json:"passed"`   // Tasks passed within the allowed attempts.
+	Attempts int           `json:"attempts"` // Attempts made, passing or not.
+	Reaction time.Duration `json:"reaction"` // Total time from prompt to pass over the passed tasks.
+}
+
+// Accuracy returns the percentage of tasks passed on the first attempt.
+func (s Stats) Accuracy() float64 {
+	if s.Prompts == 0 {
+		return 0
+	}
+	return float64(s.FirstTry) / float64(s.Prompts) * 100
+}
+
+// MeanReaction returns the mean time from prompt to pass.
+func (s Stats) MeanReaction() time.Duration {
+	if s.Passed == 0 {
+		return 0
+	}
+	return s.Reaction / time.Duration(s.Passed)
+}
+
+// Drill prompts a number of tasks drawn from a generator and records the reaction time and accuracy of the
+// student per task category. It implements sink.Sink, so it is attached to the pipeline like any other
+// output, and prints prompts and feedback to the terminal.
+type Drill struct {
+	Name        string
+	Rounds      int
+	MaxAttempts int // Failed attempts before the answer is shown; 0 is unlimited.
+
+	mu        sync.Mutex
+	next      Generator
+	out       io.Writer
+	now       func() time.Time
+	round     int
+	task      Task
+	attempts  int
+	promptAt  time.Time
+	stats     map[string]*Stats
+	order     []string // Categories in order of first appearance.
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a drill named name that draws its tasks from next and writes to out.
+func New(name string, next Generator, out io.Writer) *Drill {
+	return &Drill{
+		Name:        name,
+		Rounds:      DefaultRounds,
+		MaxAttempts: DefaultMaxAttempts,
+		next:        next,
+		out:         out,
+		now:         time.Now,
+		stats:       make(map[string]*Stats),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start prints the first prompt. Call it once capture has started; Write calls it otherwise.
+func (d *Drill) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.startLocked()
+}
+
+// Done is closed once every round has been played.
+func (d *Drill) Done() <-chan struct{} {
+	return d.done
+}
+
+// Results returns the statistics of every category, in order of first appearance.
+func (d *Drill) Results() []Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	results := make([]Stats, 0, len(d.order))
+	for _, category := range d.order {
+		results = append(results, *d.stats[category])
+	}
+	return results
+}
+
+// Write evaluates the current task against a processed event.
+func (d *Drill) Write(snapshot *sink.Snapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.startLocked()
+	if d.task == nil {
+		return nil
+	}
+
+	outcome, feedback := d.task.Evaluate(snapshot)
+	if outcome != Failed && feedback != "" {
+		d.printf("  %s\n", feedback)
+	}
+	switch outcome {
+	case Passed:
+		stats := d.stats[d.task.Category()]
+		d.attempts++
+		stats.Attempts++
+		stats.Passed++
+		if d.attempts == 1 {
+			stats.FirstTry++
+		}
+		reaction := time.Duration(int64(snapshot.Timestamp) - d.promptAt.UnixNano())
+		if reaction > 0 {
+			stats.Reaction += reaction
+		}
+		d.printf("  ✔ Correct in %s.\n", reaction.Round(time.Millisecond))
+		d.nextLocked()
+	case Failed:
+		d.attempts++
+		d.stats[d.task.Category()].Attempts++
+		if d.MaxAttempts > 0 && d.attempts >= d.MaxAttempts {
+			d.printf("  ✘ %s The answer was %s.\n", feedback, d.task.Prompt())
+			d.nextLocked()
+			return nil
+		}
+		d.printf("  ✘ %s Try again.\n", feedback)
+	}
+	return nil
+}
+
+// Flush does nothing; feedback is printed as it happens.
+func (d *Drill) Flush() error {
+	return nil
+}
+
+// Close prints the results if the drill was interrupted before its last round.
+func (d *Drill) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started && d.task != nil {
+		d.printf("\nDrill stopped after %d of %d rounds.\n", d.round-1, d.Rounds)
+		d.printSummaryLocked()
+	}
+	return nil
+}
+
+// startLocked prints the drill header and the first prompt once.
+func (d *Drill) startLocked() {
+	if d.started {
+		return
+	}
+	d.started = true
+	d.printf("Drill: %s (%d rounds)\n", d.Name, d.Rounds)
+	d.nextLocked()
+}
+
+// nextLocked prompts the next task, or finishes the drill after the last round.
+func (d *Drill) nextLocked() {
+	if d.round >= d.Rounds {
+		d.task = nil
+		d.printf("\nDrill complete!\n")
+		d.printSummaryLocked()
+		d.closeOnce.Do(func() { close(d.done) })
+		return
+	}
+
+	d.round++
+	d.task = d.next()
+	d.attempts = 0
+	category := d.task.Category()
+	if _, ok := d.stats[category]; !ok {
+		d.stats[category] = &Stats{Category: category}
+		d.order = append(d.order, category)
+	}
+	d.stats[category].Prompts++
+	d.printf("\nRound %d/%d: play %s\n", d.round, d.Rounds, d.task.Prompt())
+	d.promptAt = d.now()
+}
+
+// printSummaryLocked prints the statistics of every category, weakest first.
+func (d *Drill) printSummaryLocked() {
+	results := make([]*Stats, 0, len(d.order))
+	for _, category := range d.order {
+		results = append(results, d.stats[category])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Accuracy() < results[j].Accuracy()
+	})
+
+	var prompts, firstTry int
+	for _, stats := range results {
+		prompts += stats.Prompts
+		firstTry += stats.FirstTry
+		reaction := "-"
+		if stats.Passed > 0 {
+			reaction = stats.MeanReaction().Round(time.Millisecond).String()
+		}
+		d.printf("  %-24s %d/%d first try (%.0f%%), mean reaction %s\n",
+			stats.Category, stats.FirstTry, stats.Prompts, stats.Accuracy(), reaction)
+	}
+	d.printf("%d of %d prompts played correctly on the first try.\n", firstTry, prompts)
+}
+
+// printf writes to the terminal, ignoring write errors.
+func (d *Drill) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(d.out, format, args...)
+}
@@ -0,0 +1,216 @@
+package drill
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// DefaultOctaves is the range of a scale drill.
+const DefaultOctaves = 1
+
+// RunStats describe one direction of a scale: how accurately and how evenly it was played.
+type RunStats struct {
+	Direction         string        `json:"direction"` // "ascent" or "descent".
+	Notes             int           `json:"notes"`     // Notes of the scale played correctly.
+	Wrong             int           `json:"wrong"`     // Wrong notes played along the way.
+	Duration          time.Duration `json:"duration"`
+	MeanInterval      time.Duration `json:"meanInterval"`      // Mean time between consecutive scale notes.
+	IntervalDeviation time.Duration `json:"intervalDeviation"` // Standard deviation of the time between notes.
+	MeanVelocity      float64       `json:"meanVelocity"`
+	VelocityDeviation float64       `json:"velocityDeviation"` // Standard deviation of the velocities.
+}
+
+// Evenness returns the timing evenness of the run as a percentage: 100 when every interval between notes
+// is identical, decreasing with their relative spread.
+func (r RunStats) Evenness() float64 {
+	if r.MeanInterval <= 0 {
+		return 0
+	}
+	return max(0, 100*(1-float64(r.IntervalDeviation)/float64(r.MeanInterval)))
+}
+
+// String summarizes the run, e.g. "Ascent: 8 notes in 1.75s, 0 wrong; intervals 250ms ± 12ms (95% even); velocity 80 ± 4".
+func (r RunStats) String() string {
+	direction := r.Direction
+	if direction != "" {
+		direction = strings.ToUpper(direction[:1]) + direction[1:]
+	}
+	return fmt.Sprintf("%s: %d notes in %s, %d wrong; intervals %s ± %s (%.0f%% even); velocity %.0f ± %.0f",
+		direction, r.Notes, r.Duration.Round(10*time.Millisecond), r.Wrong,
+		r.MeanInterval.Round(time.Millisecond), r.IntervalDeviation.Round(time.Millisecond), r.Evenness(),
+		r.MeanVelocity, r.VelocityDeviation)
+}
+
+// runRecorder collects the onsets and velocities of a run.
+type runRecorder struct {
+	direction  string
+	onsets     []uint64 // The first onset may belong to the previous run, so intervals span the turn.
+	velocities []float64
+	wrong      int
+}
+
+// stats computes the statistics of the recorded run.
+func (r *runRecorder) stats() RunStats {
+	stats := RunStats{Direction: r.direction, Notes: len(r.velocities), Wrong: r.wrong}
+	if len(r.onsets) > 1 {
+		intervals := make([]float64, len(r.onsets)-1)
+		for i := 1; i < len(r.onsets); i++ {
+			intervals[i-1] = float64(r.onsets[i] - r.onsets[i-1])
+		}
+		mean, deviation := meanDeviation(intervals)
+		stats.Duration = time.Duration(r.onsets[len(r.onsets)-1] - r.onsets[0])
+		stats.MeanInterval = time.Duration(mean)
+		stats.IntervalDeviation = time.Duration(deviation)
+	}
+	stats.MeanVelocity, stats.VelocityDeviation = meanDeviation(r.velocities)
+	return stats
+}
+
+// meanDeviation returns the mean and the population standard deviation of values.
+func meanDeviation(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// ScaleGenerator draws scale tasks over the given number of octaves, choosing a scale at random from
+// scales. A tonic of -1 picks a random tonic for every task, over the octave from middle C lowered by an octave
+// for every two octaves played. Every task is built up front, so an unknown scale or one that does not fit in
+// the MIDI range from one of the tonics is reported here.
+func ScaleGenerator(scales []string, tonic, octaves int, rng *rand.Rand) (Generator, error) {
+	if len(scales) == 0 {
+		return nil, fmt.Errorf("%w: no scales", ErrNoTasks)
+	}
+	tonics := []int{tonic}
+	if tonic < 0 {
+		lowest := 60 - 12*(octaves/2)
+		tonics = make([]int, 12)
+		for i := range tonics {
+			tonics[i] = lowest + i
+		}
+	}
+
+	tasks := make([][]ScaleTask, len(scales))
+	for i, scale := range scales {
+		for _, t := range tonics {
+			task, err := NewScaleTask(t, scale, octaves)
+			if err != nil {
+				return nil, err
+			}
+			tasks[i] = append(tasks[i], *task)
+		}
+	}
+
+	return func() Task {
+		task := tasks[rng.IntN(len(tasks))][rng.IntN(len(tonics))]
+		task.reset()
+		return &task
+	}, nil
+}
+
+// ScaleTask asks for a scale up and down. It follows the notes one by one, flags wrong notes and reports the
+// accuracy and evenness of the ascent and of the descent as each one ends. The scale passes when it is
+// played without wrong notes.
+type ScaleTask struct {
+	Tonic   int
+	Scale   string
+	Octaves int
+
+	ascent   []int
+	descent  []int
+	position int // Index of the next expected note in the ascent followed by the descent.
+	run      *runRecorder
+	wrong    int // Wrong notes of the whole attempt.
+}
+
+// NewScaleTask creates a task for scale from tonic over the given number of octaves.
+func NewScaleTask(tonic int, scale string, octaves int) (*ScaleTask, error) {
+	ascent, descent, err := midi.ScaleNotes(tonic, scale, octaves)
+	if err != nil {
+		return nil, err
+	}
+	t := &ScaleTask{Tonic: tonic, Scale: scale, Octaves: octaves, ascent: ascent, descent: descent}
+	t.reset()
+	return t, nil
+}
+
+// Prompt describes the scale to play.
+func (t *ScaleTask) Prompt() string {
+	octaves := "1 octave"
+	if t.Octaves > 1 {
+		octaves = fmt.Sprintf("%d octaves", t.Octaves)
+	}
+	return fmt.Sprintf("%s %s, %s up and down from %s",
+		midi.PitchClassName(t.Tonic), strings.ToLower(t.Scale), octaves, midi.GetNoteName(t.Tonic))
+}
+
+// Category groups the statistics by scale.
+func (t *ScaleTask) Category() string {
+	return t.Scale
+}
+
+// Evaluate follows the next note of the scale.
+func (t *ScaleTask) Evaluate(snapshot *sink.Snapshot) (Outcome, string) {
+	if snapshot.Command != byte(contracts.NoteOn) || snapshot.Velocity == 0 {
+		return Pending, ""
+	}
+
+	expected := t.expected()
+	if int(snapshot.Note) != expected {
+		t.run.wrong++
+		t.wrong++
+		return Pending, fmt.Sprintf("! %s is wrong, expected %s.", snapshot.NoteName, midi.GetNoteName(expected))
+	}
+
+	t.run.onsets = append(t.run.onsets, snapshot.Timestamp)
+	t.run.velocities = append(t.run.velocities, float64(snapshot.Velocity))
+	t.position++
+
+	switch t.position {
+	case len(t.ascent):
+		report := t.run.stats().String()
+		t.run = &runRecorder{direction: "descent", onsets: []uint64{snapshot.Timestamp}}
+		return Pending, report
+	case len(t.ascent) + len(t.descent):
+		report := t.run.stats().String()
+		wrong := t.wrong
+		t.reset()
+		if wrong > 0 {
+			return Failed, fmt.Sprintf("%s. %d wrong note(s) in the scale.", report, wrong)
+		}
+		return Passed, report
+	}
+	return Pending, ""
+}
+
+// expected returns the next note of the scale.
+func (t *ScaleTask) expected() int {
+	if t.position < len(t.ascent) {
+		return t.ascent[t.position]
+	}
+	return t.descent[t.position-len(t.ascent)]
+}
+
+// reset starts a new attempt from the tonic.
+func (t *ScaleTask) reset() {
+	t.position = 0
+	t.wrong = 0
+	t.run = &runRecorder{direction: "ascent"}
+}
@@ -0,0 +1,106 @@
+package drill
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+func newTestRand() *rand.Rand {
+	return rand.New(rand.NewPCG(1, 2))
+}
+
+func TestScaleGeneratorInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		scales  []string
+		tonic   int
+		octaves int
+		want    error
+	}{
+		{"no scales", nil, -1, 1, ErrNoTasks},
+		{"unknown scale", []string{"Lydian Dominant Flat 2"}, -1, 1, midi.ErrInvalidScale},
+		{"no octaves", []string{"Major"}, 60, 0, midi.ErrInvalidScale},
+		{"above the MIDI range", []string{"Major"}, 120, 1, midi.ErrInvalidScale},
+		{"random tonics above the MIDI range", []string{"Major"}, -1, 10, midi.ErrInvalidScale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScaleGenerator(tt.scales, tt.tonic, tt.octaves, newTestRand()); !errors.Is(err, tt.want) {
+				t.Errorf("ScaleGenerator() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleGeneratorDrawsFreshTasks(t *testing.T) {
+	next, err := ScaleGenerator([]string{"Major", "Natural Minor"}, -1, 2, newTestRand())
+	if err != nil {
+		t.Fatalf("ScaleGenerator: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		task := next().(*ScaleTask)
+		if task.Tonic < 48 || task.Tonic > 59 || task.Octaves != 2 {
+			t.Fatalf("drew %s from %d over %d octaves", task.Scale, task.Tonic, task.Octaves)
+		}
+		if task.position != 0 || task.wrong != 0 {
+			t.Fatalf("drew a task in progress: %+v", task)
+		}
+		// Progress on one task must not leak into the next draws.
+		task.Evaluate(noteOn(task.Tonic+1, 0))
+	}
+}
+
+func TestScaleTask(t *testing.T) {
+	c := []int{60, 62, 64, 65, 67, 69, 71, 72}
+	tests := []struct {
+		name  string
+		notes []int
+		want  Outcome
+	}{
+		{"clean", append(c, 71, 69, 67, 65, 64, 62, 60), Passed},
+		{"wrong note on the way up", append([]int{60, 61, 62, 64, 65, 67, 69, 71, 72}, 71, 69, 67, 65, 64, 62, 60), Failed},
+		{"unfinished", c, Pending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := NewScaleTask(60, "Major", 1)
+			if err != nil {
+				t.Fatalf("NewScaleTask: %v", err)
+			}
+			outcome := Pending
+			for i, note := range tt.notes {
+				outcome, _ = task.Evaluate(noteOn(note, uint64(i)*250e6))
+			}
+			if outcome != tt.want {
+				t.Errorf("outcome = %v, want %v", outcome, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStatsEvenness(t *testing.T) {
+	even := (&runRecorder{onsets: []uint64{0, 100, 200, 300}, velocities: []float64{80, 80, 80}}).stats()
+	if even.Evenness() != 100 || even.MeanInterval != 100 || even.VelocityDeviation != 0 {
+		t.Errorf("even run = %+v, evenness %v", even, even.Evenness())
+	}
+	uneven := (&runRecorder{onsets: []uint64{0, 50, 200, 250}}).stats()
+	if uneven.Evenness() >= 100 || uneven.Evenness() <= 0 {
+		t.Errorf("uneven run evenness = %v, want between 0 and 100", uneven.Evenness())
+	}
+}
+
+// noteOn returns the snapshot of a Note On of note at timestamp.
+func noteOn(note int, timestamp uint64) *sink.Snapshot {
+	return &sink.Snapshot{
+		Command:   byte(contracts.NoteOn),
+		Note:      byte(note),
+		NoteName:  midi.GetNoteName(note),
+		Velocity:  80,
+		Timestamp: timestamp,
+	}
+}
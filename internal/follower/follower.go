@@ -0,0 +1,354 @@
+package follower
+
+import (
+	"math"
+	"sort"
+)
+
+// Result classifies a played note against the score.
+type Result string
+
+// Results reported for played notes.
+const (
+	Correct Result = "correct" // The note is expected at the current position.
+	Wrong   Result = "wrong"   // The note was played instead of an expected one.
+	Extra   Result = "extra"   // The note is not in the score at this point and replaces nothing.
+)
+
+// Alignment settings.
+const (
+	DefaultMaxSkip = 4   // Most score events the follower may jump over for a single played note.
+	mismatchCost   = 1.0 // Cost of aligning a played note to an event that does not contain it.
+	skipCost       = 1.0 // Cost of each score note skipped (missed) by a jump.
+	wrongNoteRange = 12  // Largest distance, in semitones, between a wrong note and the note it replaces.
+)
+
+// noteState tracks an expected note of the score.
+type noteState byte
+
+const (
+	pending     noteState = iota // Not played yet.
+	played                       // Played correctly.
+	substituted                  // A wrong note was played in its place.
+)
+
+// Report describes how a played note was aligned to the score.
+type Report struct {
+	Note     int     `json:"note"`
+	Result   Result  `json:"result"`
+	Position int     `json:"position"`         // Index of the score event the note was aligned to; -1 before the start.
+	Expected []int   `json:"expected"`         // Notes expected next.
+	Missed   []int   `json:"missed,omitempty"` // Score notes skipped by this note.
+	Progress float64 `json:"progress"`         // Fraction of score events completed (0 to 1).
+	Finished bool    `json:"finished"`         // True once every event of the score has been played.
+}
+
+// Stats counts the results of a performance.
+type Stats struct {
+	Correct int
+	Wrong   int
+	Missed  int
+	Extra   int
+	Notes   int // Notes in the score.
+}
+
+// Accuracy returns the percentage of score notes played correctly.
+func (s Stats) Accuracy() float64 {
+	if s.Notes == 0 {
+		return 0
+	}
+	return float64(s.Correct) / float64(s.Notes) * 100
+}
+
+// Follower aligns a live performance to a score with an online dynamic time warping.
+//
+// For every played note it updates the cost of ending the alignment at each score event: staying on
+// the current event, advancing to the next one, or jumping ahead over missed notes, where each missed
+// note and each note that does not belong to its event adds to the cost. The cheapest event within
+// reach becomes the new position. The alignment only moves forward, and only a band of MaxSkip events
+// ahead of the position is evaluated, so each note costs O(MaxSkip²) regardless of the score length.
+type Follower struct {
+	MaxSkip int
+
+	score    *Score
+	states   [][]noteState
+	cost     map[int]float64 // Alignment cost per event index (-1 is the start) within the band.
+	matched  map[int]int     // Notes matched in each event of the band on the cheapest alignment ending there.
+	position int
+	stats    Stats
+}
+
+// New creates a follower positioned before the first event of score.
+func New(score *Score) *Follower {
+	states := make([][]noteState, len(score.Events))
+	for i, event := range score.Events {
+		states[i] = make([]noteState, len(event.Notes))
+	}
+	return &Follower{
+		MaxSkip:  DefaultMaxSkip,
+		score:    score,
+		states:   states,
+		cost:     map[int]float64{-1: 0},
+		matched:  map[int]int{},
+		position: -1,
+		stats:    Stats{Notes: score.NoteCount()},
+	}
+}
+
+// Play aligns a played note and reports where it fell in the score.
+func (f *Follower) Play(note int) Report {
+	previous := f.position
+	f.advance(note)
+
+	report := Report{Note: note, Position: f.position}
+	if f.position > previous {
+		report.Missed = f.collectMissed(previous, f.position)
+	}
+
+	switch {
+	case f.position < 0:
+		report.Result = Extra
+		f.stats.Extra++
+	case f.mark(note, played, pending, substituted):
+		report.Result = Correct
+		f.stats.Correct++
+	case f.mark(nearestPending(f.score.Events[f.position].Notes, f.states[f.position], note), substituted, pending):
+		report.Result = Wrong
+		f.stats.Wrong++
+	default:
+		report.Result = Extra
+		f.stats.Extra++
+	}
+
+	report.Expected = f.Expected()
+	report.Progress = f.Progress()
+	report.Finished = f.Finished()
+	return report
+}
+
+// Position returns the index of the current score event, or -1 before the first note.
+func (f *Follower) Position() int {
+	return f.position
+}
+
+// Expected returns the notes expected next: the unplayed notes of the current event, or the notes of the
+// following event once the current one is complete. Returns nil at the end of the score.
+func (f *Follower) Expected() []int {
+	if f.position >= 0 {
+		var remaining []int
+		for i, state := range f.states[f.position] {
+			if state != played {
+				remaining = append(remaining, f.score.Events[f.position].Notes[i])
+			}
+		}
+		if len(remaining) > 0 {
+			return remaining
+		}
+	}
+	if next := f.position + 1; next < len(f.score.Events) {
+		return append([]int(nil), f.score.Events[next].Notes...)
+	}
+	return nil
+}
+
+// Progress returns the fraction of score events completed.
+func (f *Follower) Progress() float64 {
+	if len(f.score.Events) == 0 {
+		return 1
+	}
+	completed := f.position
+	if f.position >= 0 && f.complete(f.position) {
+		completed++
+	}
+	if completed < 0 {
+		completed = 0
+	}
+	return float64(completed) / float64(len(f.score.Events))
+}
+
+// Finished reports whether the last event of the score has been played. An empty score is never finished.
+func (f *Follower) Finished() bool {
+	last := len(f.score.Events) - 1
+	return last >= 0 && f.position == last && f.complete(last)
+}
+
+// Stats returns the results counted so far. Notes still pending at the current position are not yet
+// counted as missed.
+func (f *Follower) Stats() Stats {
+	return f.stats
+}
+
+// Remaining returns the number of score notes still pending, which count as missed if the performance ends now.
+func (f *Follower) Remaining() int {
+	remaining := 0
+	for i := max(f.position, 0); i < len(f.score.Events); i++ {
+		for _, state := range f.states[i] {
+			if state == pending {
+				remaining++
+			}
+		}
+	}
+	return remaining
+}
+
+// advance runs one step of the online alignment and moves the position to the cheapest event in reach.
+func (f *Follower) advance(note int) {
+	last := min(f.position+f.MaxSkip+1, len(f.score.Events)-1)
+	next := make(map[int]float64, last-f.position+1)
+	matched := make(map[int]int, last-f.position+1)
+
+	for k := f.position; k <= last; k++ {
+		best, hits := math.Inf(1), 0
+		// Stay on event k: a further chord note, a repeated note or an extra note.
+		if previous, ok := f.cost[k]; ok {
+			c := f.stayCost(k, note)
+			best, hits = previous+c, f.matched[k]
+			if c == 0 {
+				hits++
+			}
+		}
+		// Advance to event k from an earlier event j, missing the notes in between.
+		for j := max(k-f.MaxSkip-1, f.position); j < k; j++ {
+			previous, ok := f.cost[j]
+			if !ok {
+				continue
+			}
+			match := f.matchCost(k, note)
+			if c := previous + f.skipCost(j, k) + match; c < best {
+				best, hits = c, 0
+				if match == 0 {
+					hits = 1
+				}
+			}
+		}
+		if !math.IsInf(best, 1) {
+			next[k], matched[k] = best, hits
+		}
+	}
+
+	// Pick the cheapest event; on ties prefer the furthest one, so repeated notes and substitutions move on.
+	chosen, chosenCost := f.position, math.Inf(1)
+	for k, c := range next {
+		if c < chosenCost || (c == chosenCost && k > chosen) {
+			chosen, chosenCost = k, c
+		}
+	}
+
+	// Commit to the chosen event: the alignment never moves back before it.
+	for k := range next {
+		if k < chosen {
+			delete(next, k)
+			delete(matched, k)
+		}
+	}
+	f.cost = next
+	f.matched = matched
+	f.position = chosen
+}
+
+// stayCost is the cost of aligning another note to event k.
+func (f *Follower) stayCost(k int, note int) float64 {
+	if k < 0 {
+		return mismatchCost
+	}
+	for i, n := range f.score.Events[k].Notes {
+		if n == note && f.states[k][i] != played {
+			return 0
+		}
+	}
+	return mismatchCost
+}
+
+// matchCost is the cost of aligning a note to event k when arriving at it.
+func (f *Follower) matchCost(k int, note int) float64 {
+	for _, n := range f.score.Events[k].Notes {
+		if n == note {
+			return 0
+		}
+	}
+	return mismatchCost
+}
+
+// skipCost is the cost of moving from event j to event k: the pending notes left behind in j and every
+// note of the events in between. Events ahead of the position are only reached by alignments not committed
+// to yet, so their notes matched along the way are counted instead of their states.
+func (f *Follower) skipCost(j, k int) float64 {
+	missed := 0
+	switch {
+	case j == f.position && j >= 0:
+		for _, state := range f.states[j] {
+			if state == pending {
+				missed++
+			}
+		}
+	case j >= 0:
+		missed = max(len(f.score.Events[j].Notes)-f.matched[j], 0)
+	}
+	for i := j + 1; i < k; i++ {
+		missed += len(f.score.Events[i].Notes)
+	}
+	return float64(missed) * skipCost
+}
+
+// collectMissed counts and returns the pending notes of the events the alignment moved past.
+func (f *Follower) collectMissed(from, to int) []int {
+	var missed []int
+	for i := max(from, 0); i < to; i++ {
+		for n, state := range f.states[i] {
+			if state == pending {
+				missed = append(missed, f.score.Events[i].Notes[n])
+			}
+		}
+	}
+	f.stats.Missed += len(missed)
+	sort.Ints(missed)
+	return missed
+}
+
+// mark sets the state of note in the current event to state if it is currently in one of from.
+func (f *Follower) mark(note int, state noteState, from ...noteState) bool {
+	if note < 0 {
+		return false
+	}
+	for i, n := range f.score.Events[f.position].Notes {
+		if n != note {
+			continue
+		}
+		for _, allowed := range from {
+			if f.states[f.position][i] == allowed {
+				f.states[f.position][i] = state
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// complete reports whether every note of event k has been played.
+func (f *Follower) complete(k int) bool {
+	for _, state := range f.states[k] {
+		if state != played {
+			return false
+		}
+	}
+	return true
+}
+
+// nearestPending returns the pending note closest in pitch to note, or -1 if none is pending within
+// wrongNoteRange.
+func nearestPending(notes []int, states []noteState, note int) int {
+	nearest, distance := -1, math.MaxInt
+	for i, n := range notes {
+		if states[i] != pending {
+			continue
+		}
+		d := n - note
+		if d < 0 {
+			d = -d
+		}
+		if d <= wrongNoteRange && d < distance {
+			nearest, distance = n, d
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,166 @@
+package follower
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// scaleScore is the C major scale up one octave, one note per beat.
+const scaleScore = `{
+  "title": "C major scale",
+  "events": [
+    {"beat": 0, "notes": ["C4"]}, {"beat": 1, "notes": ["D4"]}, {"beat": 2, "notes": ["E4"]},
+    {"beat": 3, "notes": ["F4"]}, {"beat": 4, "notes": ["G4"]}, {"beat": 5, "notes": ["A4"]},
+    {"beat": 6, "notes": ["B4"]}, {"beat": 7, "notes": ["C5"]}
+  ]
+}`
+
+func mustParse(t *testing.T, doc string) *Score {
+	t.Helper()
+	score, err := ParseJSONScore(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseJSONScore: %v", err)
+	}
+	return score
+}
+
+func TestFollower(t *testing.T) {
+	tests := []struct {
+		name        string
+		played      []int
+		wantResults []Result
+		wantStats   Stats
+	}{
+		{
+			name:        "clean",
+			played:      []int{60, 62, 64, 65, 67, 69, 71, 72},
+			wantResults: []Result{Correct, Correct, Correct, Correct, Correct, Correct, Correct, Correct},
+			wantStats:   Stats{Correct: 8, Notes: 8},
+		},
+		{
+			name:        "wrong note",
+			played:      []int{60, 62, 63, 65, 67, 69, 71, 72},
+			wantResults: []Result{Correct, Correct, Wrong, Correct, Correct, Correct, Correct, Correct},
+			wantStats:   Stats{Correct: 7, Wrong: 1, Notes: 8},
+		},
+		{
+			name:        "skipped note",
+			played:      []int{60, 62, 65, 67, 69, 71, 72},
+			wantResults: []Result{Correct, Correct, Correct, Correct, Correct, Correct, Correct},
+			wantStats:   Stats{Correct: 7, Missed: 1, Notes: 8},
+		},
+		{
+			// A jump over two notes costs more than a wrong note at first, then the alignment catches up.
+			name:        "skipped notes",
+			played:      []int{60, 62, 67, 69, 71, 72},
+			wantResults: []Result{Correct, Correct, Wrong, Correct, Correct, Correct},
+			wantStats:   Stats{Correct: 5, Wrong: 1, Missed: 2, Notes: 8},
+		},
+		{
+			// A repeated note moves on as a wrong note in place of the next one, which is still correct when played.
+			name:        "repeated note",
+			played:      []int{60, 62, 62, 64, 65, 67, 69, 71, 72},
+			wantResults: []Result{Correct, Correct, Wrong, Correct, Correct, Correct, Correct, Correct, Correct},
+			wantStats:   Stats{Correct: 8, Wrong: 1, Notes: 8},
+		},
+		{
+			name:        "extra note before the start",
+			played:      []int{30, 60, 62, 64, 65, 67, 69, 71, 72},
+			wantResults: []Result{Extra, Correct, Correct, Correct, Correct, Correct, Correct, Correct, Correct},
+			wantStats:   Stats{Correct: 8, Extra: 1, Notes: 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New(mustParse(t, scaleScore))
+			var results []Result
+			var report Report
+			for _, note := range tt.played {
+				report = f.Play(note)
+				results = append(results, report.Result)
+			}
+			if !slices.Equal(results, tt.wantResults) {
+				t.Errorf("results = %v, want %v", results, tt.wantResults)
+			}
+			if f.Stats() != tt.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", f.Stats(), tt.wantStats)
+			}
+			if !report.Finished || report.Progress != 1 || report.Expected != nil {
+				t.Errorf("last report = %+v, want the score finished", report)
+			}
+		})
+	}
+}
+
+func TestFollowerReportsMissedNotes(t *testing.T) {
+	f := New(mustParse(t, scaleScore))
+	f.Play(60)
+	f.Play(62)
+	report := f.Play(65)
+	if report.Result != Correct || report.Position != 3 || !slices.Equal(report.Missed, []int{64}) {
+		t.Errorf("Play(F4) = %+v, want F4 correct at 3 with E4 missed", report)
+	}
+	if !slices.Equal(report.Expected, []int{67}) {
+		t.Errorf("expected %v next, want G4", report.Expected)
+	}
+}
+
+func TestFollowerChords(t *testing.T) {
+	score := mustParse(t, `{"events": [
+		{"beat": 0, "notes": ["C4", "E4", "G4"]},
+		{"beat": 1, "notes": ["F4", "A4", "C5"]}
+	]}`)
+	f := New(score)
+	for _, note := range []int{64, 60} {
+		if report := f.Play(note); report.Result != Correct || report.Position != 0 {
+			t.Fatalf("Play(%d) = %+v", note, report)
+		}
+	}
+	if expected := f.Expected(); !slices.Equal(expected, []int{67}) {
+		t.Errorf("Expected() = %v, want the rest of the chord", expected)
+	}
+	if f.Progress() != 0 {
+		t.Errorf("Progress() = %v with the first chord incomplete", f.Progress())
+	}
+	f.Play(67)
+	if f.Progress() != 0.5 || f.Remaining() != 3 {
+		t.Errorf("Progress() = %v, Remaining() = %d, want 0.5 and 3", f.Progress(), f.Remaining())
+	}
+}
+
+func TestFollowerEmptyScore(t *testing.T) {
+	f := New(&Score{})
+	if f.Finished() {
+		t.Error("Finished() = true before playing an empty score")
+	}
+	if report := f.Play(60); report.Result != Extra || report.Finished || report.Expected != nil {
+		t.Errorf("report = %+v, want an extra note and the score not finished", report)
+	}
+}
+
+func TestParseJSONScore(t *testing.T) {
+	score := mustParse(t, `{"title": "Out of order", "bpm": 60, "events": [
+		{"beat": 1, "notes": ["D4"]},
+		{"beat": 0, "notes": ["64", "C4"]}
+	]}`)
+	if score.Title != "Out of order" || len(score.Events) != 2 || score.NoteCount() != 3 {
+		t.Fatalf("score = %+v", score)
+	}
+	if !slices.Equal(score.Events[0].Notes, []int{60, 64}) || score.Events[1].Time.Seconds() != 1 {
+		t.Errorf("events = %+v, want C4 and E4 at 0s, then D4 at 1s", score.Events)
+	}
+
+	for _, doc := range []string{
+		`{"events": []}`,
+		`{"events": [{"beat": 0, "notes": []}]}`,
+		`{"events": [{"beat": 0, "notes": ["H4"]}]}`,
+		`{"events": [{"beat": 0, "notes": ["128"]}]}`,
+		`not json`,
+	} {
+		if _, err := ParseJSONScore(strings.NewReader(doc)); !errors.Is(err, ErrInvalidScore) {
+			t.Errorf("ParseJSONScore(%s) error = %v, want ErrInvalidScore", doc, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package follower
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/smf"
+)
+
+// ErrInvalidScore is returned when a score cannot be loaded.
+var ErrInvalidScore = errors.New("invalid score")
+
+// Score loading settings.
+const (
+	DefaultChordWindow = 30 * time.Millisecond // Onsets closer than this in a reference file form one chord.
+	DefaultScoreBPM    = 120.0                 // Tempo of JSON scores that do not declare one.
+	drumChannel        = 9                     // General MIDI percussion channel, ignored in reference files.
+)
+
+// ScoreEvent is a set of notes that start together in the score: a single note or a chord.
+type ScoreEvent struct {
+	Time  time.Duration // Offset from the start of the piece.
+	Notes []int         // MIDI notes, ascending.
+}
+
+// Score is a reference piece the follower aligns a performance to.
+type Score struct {
+	Title  string
+	Events []ScoreEvent
+}
+
+// NoteCount returns the number of notes in the score.
+func (s *Score) NoteCount() int {
+	count := 0
+	for _, event := range s.Events {
+		count += len(event.Notes)
+	}
+	return count
+}
+
+// jsonScore is the JSON score format:
+//
+//	{
+//	  "title": "C major scale",
+//	  "bpm": 90,
+//	  "events": [
+//	    {"beat": 0, "notes": ["C4", "E4", "G4"]},
+//	    {"beat": 1, "notes": ["D4"]}
+//	  ]
+//	}
+//
+// Notes are names such as C4, F#3 or Bb2, or MIDI numbers as strings; beats are counted from 0.
+type jsonScore struct {
+	Title  string  `json:"title"`
+	BPM    float64 `json:"bpm"`
+	Events []struct {
+		Beat  float64  `json:"beat"`
+		Notes []string `json:"notes"`
+	} `json:"events"`
+}
+
+// LoadScore loads a score from a Standard MIDI File (.mid, .midi) or a JSON score (any other extension).
+func LoadScore(path string) (*Score, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mid", ".midi", ".smf":
+		file, err := smf.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		score := FromSMF(file)
+		if score.Title == "" {
+			score.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		if len(score.Events) == 0 {
+			return nil, fmt.Errorf("%w: %s has no notes", ErrInvalidScore, path)
+		}
+		return score, nil
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseJSONScore(f)
+	}
+}
+
+// FromSMF builds a score from the Note On events of a Standard MIDI File, grouping onsets closer than
+// DefaultChordWindow into chords. Percussion on channel 10 is ignored.
+func FromSMF(file *smf.File) *Score {
+	score := &Score{}
+	for _, name := range file.Names {
+		if name != "" {
+			score.Title = name
+			break
+		}
+	}
+
+	for _, event := range file.Events() {
+		if event.Command() != byte(contracts.NoteOn) || event.Data2 == 0 || event.Channel() == drumChannel {
+			continue
+		}
+		score.addNote(event.Time, int(event.Data1))
+	}
+	return score
+}
+
+// ParseJSONScore parses a score in the JSON score format.
+func ParseJSONScore(r io.Reader) (*Score, error) {
+	var doc jsonScore
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScore, err)
+	}
+	bpm := doc.BPM
+	if bpm <= 0 {
+		bpm = DefaultScoreBPM
+	}
+	beat := time.Duration(float64(time.Minute) / bpm)
+
+	sort.SliceStable(doc.Events, func(i, j int) bool {
+		return doc.Events[i].Beat < doc.Events[j].Beat
+	})
+
+	score := &Score{Title: doc.Title}
+	for i, event := range doc.Events {
+		if len(event.Notes) == 0 {
+			return nil, fmt.Errorf("%w: event %d has no notes", ErrInvalidScore, i)
+		}
+		at := time.Duration(event.Beat * float64(beat))
+		for _, name := range event.Notes {
+			note, err := parseNote(name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: event %d: %v", ErrInvalidScore, i, err)
+			}
+			score.addNote(at, note)
+		}
+	}
+	if len(score.Events) == 0 {
+		return nil, fmt.Errorf("%w: no events", ErrInvalidScore)
+	}
+	return score, nil
+}
+
+// addNote adds a note at time at, merging it into the last event when it falls within the chord window.
+// Notes must be added in time order.
+func (s *Score) addNote(at time.Duration, note int) {
+	if n := len(s.Events); n > 0 && at-s.Events[n-1].Time < DefaultChordWindow {
+		last := &s.Events[n-1]
+		for _, existing := range last.Notes {
+			if existing == note {
+				return
+			}
+		}
+		last.Notes = append(last.Notes, note)
+		sort.Ints(last.Notes)
+		return
+	}
+	s.Events = append(s.Events, ScoreEvent{Time: at, Notes: []int{note}})
+}
+
+// parseNote accepts a note name or a MIDI note number.
+func parseNote(value string) (int, error) {
+	if note, ok := midi.ParseNoteName(value); ok {
+		return note, nil
+	}
+	note, err := strconv.Atoi(value)
+	if err != nil || note < 0 || note > 127 {
+		return 0, fmt.Errorf("invalid note %q", value)
+	}
+	return note, nil
+}
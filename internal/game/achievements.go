@@ -0,0 +1,119 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrInvalidAchievements is returned when an achievements file cannot be loaded.
+var ErrInvalidAchievements = errors.New("invalid achievements")
+
+// Metric is a value of the player state an achievement is unlocked on.
+type Metric string
+
+// Metrics achievements can be defined on.
+const (
+	TotalPoints    Metric = "totalPoints"    // Points across every session.
+	SessionPoints  Metric = "sessionPoints"  // Points in a single session.
+	TotalNotes     Metric = "totalNotes"     // Notes played across every session.
+	TotalChords    Metric = "totalChords"    // Chords recognized across every session.
+	BestCombo      Metric = "bestCombo"      // Longest combo ever.
+	DayStreak      Metric = "dayStreak"      // Consecutive days the daily goal was met.
+	SessionMinutes Metric = "sessionMinutes" // Minutes practiced in a single session.
+	GoalsMet       Metric = "goalsMet"       // Days the daily goal was met.
+)
+
+// Achievement is unlocked once its metric reaches the threshold.
+type Achievement struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Metric      Metric  `json:"metric"`
+	Threshold   float64 `json:"threshold"`
+}
+
+// DefaultAchievements returns the built-in achievements.
+func DefaultAchievements() []Achievement {
+	return []Achievement{
+		{ID: "first-chord", Name: "First Chord", Description: "Play a chord that is recognized", Metric: TotalChords, Threshold: 1},
+		{ID: "chords-100", Name: "Chord Collector", Description: "Play 100 recognized chords", Metric: TotalChords, Threshold: 100},
+		{ID: "notes-1000", Name: "Thousand Notes", Description: "Play 1,000 notes", Metric: TotalNotes, Threshold: 1000},
+		{ID: "notes-10000", Name: "Ten Thousand Notes", Description: "Play 10,000 notes", Metric: TotalNotes, Threshold: 10000},
+		{ID: "combo-25", Name: "On a Roll", Description: "Reach a combo of 25 hits", Metric: BestCombo, Threshold: 25},
+		{ID: "combo-100", Name: "Unstoppable", Description: "Reach a combo of 100 hits", Metric: BestCombo, Threshold: 100},
+		{ID: "session-500", Name: "Big Session", Description: "Score 500 points in one session", Metric: SessionPoints, Threshold: 500},
+		{ID: "points-10000", Name: "High Scorer", Description: "Score 10,000 points in total", Metric: TotalPoints, Threshold: 10000},
+		{ID: "marathon", Name: "Marathon", Description: "Practice for 30 minutes in one session", Metric: SessionMinutes, Threshold: 30},
+		{ID: "first-goal", Name: "Goal!", Description: "Meet the daily practice goal", Metric: GoalsMet, Threshold: 1},
+		{ID: "streak-3", Name: "Habit Forming", Description: "Meet the daily goal 3 days in a row", Metric: DayStreak, Threshold: 3},
+		{ID: "streak-7", Name: "Week Streak", Description: "Meet the daily goal 7 days in a row", Metric: DayStreak, Threshold: 7},
+		{ID: "streak-30", Name: "Month Streak", Description: "Meet the daily goal 30 days in a row", Metric: DayStreak, Threshold: 30},
+	}
+}
+
+// LoadAchievements reads achievements from a JSON file holding an array of achievements.
+func LoadAchievements(path string) ([]Achievement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var achievements []Achievement
+	if err := json.Unmarshal(data, &achievements); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAchievements, err)
+	}
+
+	ids := make(map[string]bool, len(achievements))
+	for i, achievement := range achievements {
+		switch {
+		case achievement.ID == "" || ids[achievement.ID]:
+			return nil, fmt.Errorf("%w: achievement %d needs a unique id", ErrInvalidAchievements, i+1)
+		case !achievement.Metric.valid():
+			return nil, fmt.Errorf("%w: %s: unknown metric %q", ErrInvalidAchievements, achievement.ID, achievement.Metric)
+		}
+		ids[achievement.ID] = true
+	}
+	return achievements, nil
+}
+
+// valid reports whether the metric is known.
+func (m Metric) valid() bool {
+	switch m {
+	case TotalPoints, SessionPoints, TotalNotes, TotalChords, BestCombo, DayStreak, SessionMinutes, GoalsMet:
+		return true
+	default:
+		return false
+	}
+}
+
+// value returns the metric for the player state after a session.
+func (m Metric) value(state *State, session SessionResult) float64 {
+	switch m {
+	case TotalPoints:
+		return float64(state.TotalPoints)
+	case SessionPoints:
+		return float64(session.Score.Points)
+	case TotalNotes:
+		return float64(state.TotalNotes)
+	case TotalChords:
+		return float64(state.TotalChords)
+	case BestCombo:
+		return float64(state.BestCombo)
+	case DayStreak:
+		return float64(state.Streak)
+	case SessionMinutes:
+		return session.Practiced.Minutes()
+	case GoalsMet:
+		return float64(state.GoalsMet)
+	default:
+		return 0
+	}
+}
+
+// Unlocked is an achievement unlocked by the player.
+type Unlocked struct {
+	Achievement
+	At time.Time `json:"at"`
+}
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAchievements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "achievements.json")
+	if err := os.WriteFile(path, []byte(`[
+		{"id": "scales", "name": "Scales", "metric": "totalNotes", "threshold": 500},
+		{"id": "streak-2", "name": "Two Days", "metric": "dayStreak", "threshold": 2}
+	]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	achievements, err := LoadAchievements(path)
+	if err != nil {
+		t.Fatalf("LoadAchievements: %v", err)
+	}
+	if len(achievements) != 2 || achievements[1].Metric != DayStreak || achievements[1].Threshold != 2 {
+		t.Errorf("LoadAchievements() = %+v, want the two achievements of the file", achievements)
+	}
+}
+
+func TestLoadAchievementsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"not JSON", `[{"id": `},
+		{"not an array", `{"id": "scales"}`},
+		{"missing id", `[{"name": "Scales", "metric": "totalNotes", "threshold": 1}]`},
+		{"duplicate id", `[{"id": "a", "metric": "totalNotes"}, {"id": "a", "metric": "totalChords"}]`},
+		{"unknown metric", `[{"id": "a", "metric": "totalScales"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "achievements.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadAchievements(path); !errors.Is(err, ErrInvalidAchievements) {
+				t.Errorf("LoadAchievements() error = %v, want ErrInvalidAchievements", err)
+			}
+		})
+	}
+}
+
+func TestDefaultAchievementsAreValid(t *testing.T) {
+	ids := make(map[string]bool)
+	for _, achievement := range DefaultAchievements() {
+		if ids[achievement.ID] || !achievement.Metric.valid() || achievement.Threshold <= 0 {
+			t.Errorf("invalid built-in achievement %+v", achievement)
+		}
+		ids[achievement.ID] = true
+	}
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"math"
+	"sync"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// Rules sets the points awarded for each kind of hit and how combos multiply them.
+type Rules struct {
+	ChordPoints          int     // New chord recognized.
+	ChordNotePoints      int     // Each chord note beyond the third.
+	OnTimePoints         int     // Onset on the metronome grid, when a metronome is set.
+	OnBeatPoints         int     // Onset close to the estimated beat, without a metronome.
+	ScoreNotePoints      int     // Correct note of the reference score, when a score is followed.
+	SteadyVelocityPoints int     // Note played at a velocity close to the recent average.
+	OnBeatPhase          float64 // Largest offset from the beat, in beats, counted as on the beat.
+	VelocityTolerance    float64 // Largest difference from the recent average velocity counted as steady.
+	ComboStep            int     // Consecutive hits that raise the multiplier by one.
+	MaxMultiplier        int     // Highest combo multiplier.
+}
+
+// DefaultRules returns the default scoring rules.
+func DefaultRules() Rules {
+	return Rules{
+		ChordPoints:          10,
+		ChordNotePoints:      5,
+		OnTimePoints:         5,
+		OnBeatPoints:         2,
+		ScoreNotePoints:      3,
+		SteadyVelocityPoints: 1,
+		OnBeatPhase:          0.1,
+		VelocityTolerance:    10,
+		ComboStep:            10,
+		MaxMultiplier:        4,
+	}
+}
+
+// SessionScore is the score of a session.
+type SessionScore struct {
+	Points    int `json:"points"`
+	Hits      int `json:"hits"`   // Chords, on-time onsets and correct score notes.
+	Misses    int `json:"misses"` // Onsets off the metronome grid and wrong or extra score notes.
+	Combo     int `json:"combo"`  // Current run of hits without a miss.
+	BestCombo int `json:"bestCombo"`
+}
+
+// velocitySmoothing weights the latest velocity in the running average.
+const velocitySmoothing = 0.2
+
+// Scorer is a sink that awards points for the pipeline results: chords recognized, onsets on the metronome
+// grid or on the beat, correct notes of a followed score and steady velocity. Hits build a combo that
+// multiplies the points; a miss resets it.
+type Scorer struct {
+	rules     Rules
+	mu        sync.Mutex
+	score     SessionScore
+	lastChord string
+	velocity  float64 // Running average velocity; 0 before the first note.
+}
+
+// NewScorer creates a scorer with the given rules.
+func NewScorer(rules Rules) *Scorer {
+	return &Scorer{rules: rules}
+}
+
+// Write scores a processed event.
+func (s *Scorer) Write(snapshot *sink.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot.Command != byte(contracts.NoteOn) || snapshot.Velocity == 0 {
+		if len(snapshot.PressedNotes) == 0 {
+			s.lastChord = ""
+		}
+		return nil
+	}
+
+	points, hit, miss := 0, false, false
+	if snapshot.ChordSymbol != "" && snapshot.ChordSymbol != s.lastChord {
+		points += s.rules.ChordPoints + s.rules.ChordNotePoints*max(len(snapshot.PressedNotes)-3, 0)
+		hit = true
+	}
+	s.lastChord = snapshot.ChordSymbol
+
+	switch {
+	case snapshot.Timing != nil && snapshot.Timing.Verdict == rhythm.VerdictOnTime:
+		points += s.rules.OnTimePoints
+		hit = true
+	case snapshot.Timing != nil:
+		miss = true
+	case snapshot.Beat != nil && math.Abs(snapshot.Beat.Phase) <= s.rules.OnBeatPhase:
+		points += s.rules.OnBeatPoints
+	}
+
+	if snapshot.ScoreReport != nil {
+		if snapshot.ScoreReport.Result == follower.Correct {
+			points += s.rules.ScoreNotePoints
+			hit = true
+		} else {
+			miss = true
+		}
+	}
+
+	velocity := float64(snapshot.Velocity)
+	if s.velocity > 0 && math.Abs(velocity-s.velocity) <= s.rules.VelocityTolerance {
+		points += s.rules.SteadyVelocityPoints
+	}
+	if s.velocity == 0 {
+		s.velocity = velocity
+	} else {
+		s.velocity += velocitySmoothing * (velocity - s.velocity)
+	}
+
+	switch {
+	case miss:
+		s.score.Misses++
+		s.score.Combo = 0
+	case hit:
+		s.score.Hits++
+		s.score.Combo++
+		s.score.BestCombo = max(s.score.BestCombo, s.score.Combo)
+	}
+	s.score.Points += points * s.multiplier()
+	return nil
+}
+
+// multiplier returns the combo multiplier for the current combo.
+func (s *Scorer) multiplier() int {
+	if s.rules.ComboStep <= 0 {
+		return 1
+	}
+	return min(1+s.score.Combo/s.rules.ComboStep, max(s.rules.MaxMultiplier, 1))
+}
+
+// Flush does nothing; the score is read with Score.
+func (s *Scorer) Flush() error {
+	return nil
+}
+
+// Close does nothing; the score is read with Score.
+func (s *Scorer) Close() error {
+	return nil
+}
+
+// Score returns the score of the session so far.
+func (s *Scorer) Score() SessionScore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.score
+}
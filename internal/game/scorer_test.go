@@ -0,0 +1,124 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// played returns the snapshot of a Note On at velocity 80 with pressed held, recognized as symbol.
+func played(symbol string, pressed ...int) *sink.Snapshot {
+	return &sink.Snapshot{Command: byte(contracts.NoteOn), Velocity: 80, ChordSymbol: symbol, PressedNotes: pressed}
+}
+
+func withTiming(snapshot *sink.Snapshot, verdict string) *sink.Snapshot {
+	snapshot.Timing = &rhythm.Timing{Verdict: verdict}
+	return snapshot
+}
+
+func withScore(snapshot *sink.Snapshot, result follower.Result) *sink.Snapshot {
+	snapshot.ScoreReport = &follower.Report{Result: result}
+	return snapshot
+}
+
+func TestScorer(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  Rules
+		played []*sink.Snapshot
+		want   SessionScore
+	}{
+		{
+			name:   "chord",
+			played: []*sink.Snapshot{played("C", 60, 64, 67)},
+			want:   SessionScore{Points: 10, Hits: 1, Combo: 1, BestCombo: 1},
+		},
+		{
+			name:   "notes beyond the third",
+			played: []*sink.Snapshot{played("Cmaj9", 60, 64, 67, 71, 74)},
+			want:   SessionScore{Points: 20, Hits: 1, Combo: 1, BestCombo: 1},
+		},
+		{
+			name: "held chord scored once, then steady velocity",
+			played: []*sink.Snapshot{
+				played("C", 60, 64, 67), played("C", 60, 64, 67, 72),
+				{Command: byte(contracts.NoteOff), PressedNotes: []int{60}},
+			},
+			want: SessionScore{Points: 11, Hits: 1, Combo: 1, BestCombo: 1},
+		},
+		{
+			name: "chord played again after a release",
+			played: []*sink.Snapshot{
+				played("C", 60, 64, 67), {Command: byte(contracts.NoteOff)}, played("C", 60, 64, 67),
+			},
+			want: SessionScore{Points: 21, Hits: 2, Combo: 2, BestCombo: 2},
+		},
+		{
+			name:   "on time",
+			played: []*sink.Snapshot{withTiming(played("", 60), rhythm.VerdictOnTime)},
+			want:   SessionScore{Points: 5, Hits: 1, Combo: 1, BestCombo: 1},
+		},
+		{
+			name: "off the grid breaks the combo",
+			played: []*sink.Snapshot{
+				withTiming(played("C", 60, 64, 67), rhythm.VerdictOnTime),
+				withTiming(played("", 62), rhythm.VerdictLate),
+			},
+			want: SessionScore{Points: 16, Hits: 1, Misses: 1, BestCombo: 1},
+		},
+		{
+			name:   "on the beat is not a hit",
+			played: []*sink.Snapshot{{Command: byte(contracts.NoteOn), Velocity: 80, Beat: &rhythm.Beat{Phase: -0.05}}},
+			want:   SessionScore{Points: 2},
+		},
+		{
+			name:   "off the beat",
+			played: []*sink.Snapshot{{Command: byte(contracts.NoteOn), Velocity: 80, Beat: &rhythm.Beat{Phase: 0.3}}},
+			want:   SessionScore{},
+		},
+		{
+			name: "score notes",
+			played: []*sink.Snapshot{
+				withScore(played("", 60), follower.Correct),
+				withScore(played("", 61), follower.Wrong),
+				withScore(played("", 62), follower.Correct),
+			},
+			want: SessionScore{Points: 8, Hits: 2, Misses: 1, Combo: 1, BestCombo: 1},
+		},
+		{
+			name:  "combo multiplier",
+			rules: Rules{ChordPoints: 10, ComboStep: 2, MaxMultiplier: 3},
+			played: []*sink.Snapshot{
+				played("C", 60, 64, 67), played("F", 60, 65, 69), played("C", 60, 64, 67),
+				played("F", 60, 65, 69), played("C", 60, 64, 67),
+			},
+			// Multipliers 1, 2, 2, 3 and 3, capped at MaxMultiplier.
+			want: SessionScore{Points: 110, Hits: 5, Combo: 5, BestCombo: 5},
+		},
+		{
+			name:   "note off and zero velocity are not scored",
+			played: []*sink.Snapshot{{Command: byte(contracts.NoteOff), ChordSymbol: "C"}, {Command: byte(contracts.NoteOn), ChordSymbol: "C"}},
+			want:   SessionScore{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			if rules == (Rules{}) {
+				rules = DefaultRules()
+			}
+			scorer := NewScorer(rules)
+			for _, snapshot := range tt.played {
+				if err := scorer.Write(snapshot); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if got := scorer.Score(); got != tt.want {
+				t.Errorf("Score() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
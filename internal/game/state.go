@@ -0,0 +1,146 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateFile is the name of the player state file in a profile directory.
+const StateFile = "game.json"
+
+// DefaultDailyGoal is the practice time that meets the daily goal.
+const DefaultDailyGoal = 10 * time.Minute
+
+// dayLayout formats the days of the state.
+const dayLayout = time.DateOnly
+
+// SessionResult is what a session contributes to the player state.
+type SessionResult struct {
+	End       time.Time
+	Practiced time.Duration
+	Notes     int
+	Chords    int
+	Score     SessionScore
+}
+
+// State is the persistent progress of a player: points, daily goal streak and unlocked achievements.
+type State struct {
+	TotalPoints int    `json:"totalPoints"`
+	TotalNotes  int    `json:"totalNotes"`
+	TotalChords int    `json:"totalChords"`
+	BestCombo   int    `json:"bestCombo"`
+	BestSession int    `json:"bestSession"` // Most points in a session.
+	Streak      int    `json:"streak"`      // Consecutive days the daily goal was met, up to LastGoalDay.
+	BestStreak  int    `json:"bestStreak"`
+	GoalsMet    int    `json:"goalsMet"`    // Days the daily goal was met.
+	LastGoalDay string `json:"lastGoalDay"` // Last day the daily goal was met (YYYY-MM-DD).
+
+	Day          string        `json:"day"`          // Day of the practice counted in DayPracticed.
+	DayPracticed time.Duration `json:"dayPracticed"` // Practice so far on Day.
+	DayPoints    int           `json:"dayPoints"`    // Points so far on Day.
+
+	Unlocked []Unlocked `json:"unlocked"`
+}
+
+// Outcome is the effect of a session on the player state.
+type Outcome struct {
+	Score        SessionScore
+	DailyGoal    time.Duration
+	DayPracticed time.Duration
+	GoalMet      bool // The daily goal is met for the day of the session.
+	GoalJustMet  bool // The session met the daily goal.
+	Streak       int
+	Unlocked     []Unlocked // Achievements unlocked by the session.
+}
+
+// LoadState reads the player state at path. A missing file is a new player.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Save writes the player state to path, replacing the previous file atomically.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), StateFile+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// CurrentStreak returns the streak as of day: it is broken once a whole day passes without meeting the goal.
+func (s *State) CurrentStreak(day time.Time) int {
+	today := day.Format(dayLayout)
+	yesterday := day.AddDate(0, 0, -1).Format(dayLayout)
+	if s.LastGoalDay == today || s.LastGoalDay == yesterday {
+		return s.Streak
+	}
+	return 0
+}
+
+// Record adds a session to the state, updating the daily goal and streak and unlocking achievements.
+func (s *State) Record(session SessionResult, dailyGoal time.Duration, achievements []Achievement) Outcome {
+	day := session.End.Format(dayLayout)
+	if s.Day != day {
+		s.Day, s.DayPracticed, s.DayPoints = day, 0, 0
+	}
+	s.DayPracticed += session.Practiced
+	s.DayPoints += session.Score.Points
+
+	s.TotalPoints += session.Score.Points
+	s.TotalNotes += session.Notes
+	s.TotalChords += session.Chords
+	s.BestCombo = max(s.BestCombo, session.Score.BestCombo)
+	s.BestSession = max(s.BestSession, session.Score.Points)
+
+	outcome := Outcome{Score: session.Score, DailyGoal: dailyGoal, DayPracticed: s.DayPracticed}
+	if s.LastGoalDay != day && s.DayPracticed >= dailyGoal {
+		s.Streak = s.CurrentStreak(session.End) + 1
+		s.BestStreak = max(s.BestStreak, s.Streak)
+		s.GoalsMet++
+		s.LastGoalDay = day
+		outcome.GoalJustMet = true
+	}
+	outcome.GoalMet = s.LastGoalDay == day
+	outcome.Streak = s.CurrentStreak(session.End)
+
+	unlocked := make(map[string]bool, len(s.Unlocked))
+	for _, u := range s.Unlocked {
+		unlocked[u.ID] = true
+	}
+	for _, achievement := range achievements {
+		if unlocked[achievement.ID] || achievement.Metric.value(s, session) < achievement.Threshold {
+			continue
+		}
+		u := Unlocked{Achievement: achievement, At: session.End}
+		s.Unlocked = append(s.Unlocked, u)
+		outcome.Unlocked = append(outcome.Unlocked, u)
+	}
+	return outcome
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStateRecordStreak(t *testing.T) {
+	first := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		day          int // Days after the first session.
+		practiced    time.Duration
+		dayPracticed time.Duration
+		goalMet      bool
+		goalJustMet  bool
+		streak       int
+	}{
+		{name: "short of the goal", practiced: 5 * time.Minute, dayPracticed: 5 * time.Minute},
+		{name: "goal met over two sessions", practiced: 6 * time.Minute, dayPracticed: 11 * time.Minute, goalMet: true, goalJustMet: true, streak: 1},
+		{name: "goal already met today", practiced: 20 * time.Minute, dayPracticed: 31 * time.Minute, goalMet: true, streak: 1},
+		{name: "next day", day: 1, practiced: 10 * time.Minute, dayPracticed: 10 * time.Minute, goalMet: true, goalJustMet: true, streak: 2},
+		{name: "short of the goal the day after keeps the streak", day: 2, practiced: time.Minute, dayPracticed: time.Minute, streak: 2},
+		{name: "a day missed restarts the streak", day: 3, practiced: 15 * time.Minute, dayPracticed: 15 * time.Minute, goalMet: true, goalJustMet: true, streak: 1},
+	}
+	state := &State{}
+	for _, tt := range tests {
+		outcome := state.Record(SessionResult{End: first.AddDate(0, 0, tt.day), Practiced: tt.practiced}, DefaultDailyGoal, nil)
+		if outcome.DayPracticed != tt.dayPracticed || outcome.GoalMet != tt.goalMet || outcome.GoalJustMet != tt.goalJustMet || outcome.Streak != tt.streak {
+			t.Errorf("%s: outcome = %+v, want %v practiced today, goal met %v (just %v), streak %d",
+				tt.name, outcome, tt.dayPracticed, tt.goalMet, tt.goalJustMet, tt.streak)
+		}
+	}
+	if state.BestStreak != 2 || state.GoalsMet != 3 || state.LastGoalDay != "2024-03-07" {
+		t.Errorf("state = %+v, want best streak 2 and 3 goals met, the last on 2024-03-07", state)
+	}
+	for day, want := range map[int]int{3: 1, 4: 1, 5: 0} {
+		if got := state.CurrentStreak(first.AddDate(0, 0, day)); got != want {
+			t.Errorf("CurrentStreak(day %d) = %d, want %d", day, got, want)
+		}
+	}
+}
+
+func TestStateRecordTotals(t *testing.T) {
+	end := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	state := &State{}
+	state.Record(SessionResult{End: end, Notes: 100, Chords: 10, Score: SessionScore{Points: 300, BestCombo: 12}}, DefaultDailyGoal, nil)
+	state.Record(SessionResult{End: end, Notes: 50, Chords: 5, Score: SessionScore{Points: 200, BestCombo: 20}}, DefaultDailyGoal, nil)
+	want := State{
+		TotalPoints: 500, TotalNotes: 150, TotalChords: 15, BestCombo: 20, BestSession: 300,
+		Day: "2024-03-04", DayPoints: 500,
+	}
+	if !reflect.DeepEqual(*state, want) {
+		t.Errorf("state = %+v, want %+v", *state, want)
+	}
+}
+
+func TestStateRecordUnlocksAchievements(t *testing.T) {
+	achievements := []Achievement{
+		{ID: "first-chord", Metric: TotalChords, Threshold: 1},
+		{ID: "session-500", Metric: SessionPoints, Threshold: 500},
+		{ID: "notes-100", Metric: TotalNotes, Threshold: 100},
+		{ID: "marathon", Metric: SessionMinutes, Threshold: 30},
+		{ID: "first-goal", Metric: GoalsMet, Threshold: 1},
+	}
+	first := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	sessions := []struct {
+		session SessionResult
+		want    []string
+	}{
+		{SessionResult{End: first, Notes: 60, Chords: 1, Score: SessionScore{Points: 400}}, []string{"first-chord"}},
+		// Totals add up across sessions; achievements already unlocked are not unlocked again.
+		{SessionResult{End: first.Add(time.Hour), Notes: 40, Chords: 3, Score: SessionScore{Points: 100}}, []string{"notes-100"}},
+		{SessionResult{End: first.AddDate(0, 0, 1), Practiced: 30 * time.Minute, Score: SessionScore{Points: 500}}, []string{"session-500", "marathon", "first-goal"}},
+		{SessionResult{End: first.AddDate(0, 0, 2), Practiced: time.Hour, Score: SessionScore{Points: 900}}, nil},
+	}
+	state := &State{}
+	for i, s := range sessions {
+		var got []string
+		for _, u := range state.Record(s.session, DefaultDailyGoal, achievements).Unlocked {
+			if !u.At.Equal(s.session.End) {
+				t.Errorf("session %d: %s unlocked at %v, want the end of the session", i+1, u.ID, u.At)
+			}
+			got = append(got, u.ID)
+		}
+		if !reflect.DeepEqual(got, s.want) {
+			t.Errorf("session %d unlocked %v, want %v", i+1, got, s.want)
+		}
+	}
+	if len(state.Unlocked) != len(achievements) {
+		t.Errorf("%d achievements unlocked, want %d", len(state.Unlocked), len(achievements))
+	}
+}
+
+func TestStateSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFile)
+	loaded, err := LoadState(path)
+	if err != nil || !reflect.DeepEqual(loaded, &State{}) {
+		t.Fatalf("LoadState of a new player = %+v, %v, want an empty state", loaded, err)
+	}
+
+	state := &State{}
+	end := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	state.Record(SessionResult{End: end, Practiced: 12 * time.Minute, Notes: 200, Chords: 20, Score: SessionScore{Points: 600, BestCombo: 30}},
+		DefaultDailyGoal, DefaultAchievements())
+	for range 2 { // Saving again replaces the file.
+		if err := state.Save(path); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if loaded, err = LoadState(path); err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Errorf("LoadState() = %+v, want %+v", loaded, state)
+	}
+	if matches, _ := filepath.Glob(path + ".*"); len(matches) != 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
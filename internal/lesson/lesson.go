@@ -0,0 +1,221 @@
+package lesson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+)
+
+// ErrInvalidLesson is returned when a lesson file cannot be loaded.
+var ErrInvalidLesson = errors.New("invalid lesson")
+
+// Step types.
+const (
+	StepChord  = "chord"  // Play a chord, optionally with a given root and inversion.
+	StepNote   = "note"   // Play a single note.
+	StepMelody = "melody" // Play a sequence of notes or chords in order.
+	StepKey    = "key"    // Play until the detected key matches.
+)
+
+// Lesson is an ordered list of steps, each with its pass criteria.
+//
+// Lessons are JSON documents:
+//
+//	{
+//	  "title": "Triads",
+//	  "steps": [
+//	    {"type": "chord", "prompt": "Play a C major triad in 1st inversion", "root": "C", "quality": "Major", "inversion": 1},
+//	    {"type": "note", "notes": ["C4"]},
+//	    {"type": "melody", "notes": ["C4", "D4", "E4", "C4+E4+G4"], "maxMistakes": 1},
+//	    {"type": "key", "key": "G major", "maxAttempts": 3}
+//	  ]
+//	}
+//
+// Chord qualities use the names of the chord dictionary (e.g. "Major", "Minor 7th"). Inversions are
+// 0 for root position and 1-3 for inversions. Melody notes are names such as C4 or Bb3, or MIDI numbers;
+// notes joined with '+' are played together. A prompt is generated for steps that do not declare one.
+type Lesson struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Steps       []Step `json:"steps"`
+}
+
+// Step is a single exercise of a lesson.
+type Step struct {
+	Type   string `json:"type"`
+	Prompt string `json:"prompt,omitempty"`
+
+	Root      string `json:"root,omitempty"`      // Chord root (e.g. "C", "F#"); any root when empty.
+	Quality   string `json:"quality,omitempty"`   // Chord quality (e.g. "Major").
+	Inversion *int   `json:"inversion,omitempty"` // Chord inversion; any inversion when omitted.
+
+	Notes       []string `json:"notes,omitempty"`       // Note or melody to play.
+	MaxMistakes int      `json:"maxMistakes,omitempty"` // Wrong or extra notes tolerated in a melody attempt.
+
+	Key string `json:"key,omitempty"` // Key to play in (e.g. "G major").
+
+	MaxAttempts int `json:"maxAttempts,omitempty"` // Failed attempts before the step is given up; 0 is unlimited.
+
+	root  int             // Pitch class of Root, or -1.
+	score *follower.Score // Notes of a note or melody step.
+	key   midi.Key        // Parsed Key.
+}
+
+// Load reads and validates the lesson file at path.
+func Load(path string) (*Lesson, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads and validates a lesson in JSON format.
+func Parse(r io.Reader) (*Lesson, error) {
+	var lesson Lesson
+	if err := json.NewDecoder(r).Decode(&lesson); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLesson, err)
+	}
+	if len(lesson.Steps) == 0 {
+		return nil, fmt.Errorf("%w: no steps", ErrInvalidLesson)
+	}
+	for i := range lesson.Steps {
+		if err := lesson.Steps[i].prepare(); err != nil {
+			return nil, fmt.Errorf("%w: step %d: %v", ErrInvalidLesson, i+1, err)
+		}
+	}
+	return &lesson, nil
+}
+
+// HasStep reports whether the lesson has a step of the given type.
+func (l *Lesson) HasStep(stepType string) bool {
+	for _, step := range l.Steps {
+		if step.Type == stepType {
+			return true
+		}
+	}
+	return false
+}
+
+// prepare validates the step, parses its criteria and fills in a default prompt.
+func (s *Step) prepare() error {
+	s.root = -1
+	switch s.Type {
+	case StepChord:
+		if midi.ChordIntervals(s.Quality) == nil {
+			return fmt.Errorf("unknown chord quality %q", s.Quality)
+		}
+		if s.Root != "" {
+			note, ok := midi.ParseNoteName(s.Root + "4")
+			if !ok {
+				return fmt.Errorf("invalid chord root %q", s.Root)
+			}
+			s.root = note % 12
+		}
+		if s.Inversion != nil && (*s.Inversion < 0 || *s.Inversion >= midi.ChordInversions(s.Quality)) {
+			return fmt.Errorf("invalid inversion %d for %s chord", *s.Inversion, s.Quality)
+		}
+	case StepNote, StepMelody:
+		if len(s.Notes) == 0 || (s.Type == StepNote && len(s.Notes) != 1) {
+			return fmt.Errorf("%s step needs notes", s.Type)
+		}
+		score := &follower.Score{Title: s.Prompt}
+		for _, group := range s.Notes {
+			event := follower.ScoreEvent{}
+			for _, name := range strings.Split(group, "+") {
+				note, err := parseNote(name)
+				if err != nil {
+					return err
+				}
+				event.Notes = append(event.Notes, note)
+			}
+			sort.Ints(event.Notes)
+			score.Events = append(score.Events, event)
+		}
+		s.score = score
+	case StepKey:
+		key, err := midi.ParseKey(s.Key)
+		if err != nil {
+			return err
+		}
+		s.key = key
+	default:
+		return fmt.Errorf("unknown step type %q", s.Type)
+	}
+
+	if s.Prompt == "" {
+		s.Prompt = s.defaultPrompt()
+	}
+	return nil
+}
+
+// Expected returns a short description of what the step asks for.
+func (s *Step) Expected() string {
+	switch s.Type {
+	case StepChord:
+		var name string
+		if s.root >= 0 {
+			name = midi.PitchClassName(s.root) + " " + s.Quality
+		} else {
+			name = "any " + s.Quality + " chord"
+		}
+		if s.Inversion != nil {
+			name += " (" + midi.InversionName(*s.Inversion) + ")"
+		}
+		return name
+	case StepKey:
+		return s.key.String()
+	default:
+		return strings.Join(s.Notes, " ")
+	}
+}
+
+// defaultPrompt describes the step when the lesson does not provide a prompt.
+func (s *Step) defaultPrompt() string {
+	switch s.Type {
+	case StepChord:
+		return "Play " + s.Expected()
+	case StepNote:
+		return "Play the note " + s.Notes[0]
+	case StepMelody:
+		return "Play the melody " + s.Expected()
+	default:
+		return "Play something in " + s.Expected()
+	}
+}
+
+// chordName returns the chord name accepted by a chord step with a root, as written in snapshots: the
+// symbol, with a slash bass when an inversion is required. Returns "" when the step accepts any root.
+func (s *Step) chordName() string {
+	if s.root < 0 {
+		return ""
+	}
+	symbol := midi.ChordSymbol(s.root, s.Quality)
+	if s.Inversion == nil || *s.Inversion == 0 {
+		return symbol
+	}
+	interval := midi.ChordIntervals(s.Quality)[*s.Inversion]
+	return symbol + "/" + midi.PitchClassName((s.root+interval)%12)
+}
+
+// parseNote accepts a note name or a MIDI note number.
+func parseNote(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if note, ok := midi.ParseNoteName(value); ok {
+		return note, nil
+	}
+	note, err := strconv.Atoi(value)
+	if err != nil || note < 0 || note > 127 {
+		return 0, fmt.Errorf("invalid note %q", value)
+	}
+	return note, nil
+}
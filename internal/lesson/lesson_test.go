@@ -0,0 +1,90 @@
+package lesson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// parseSteps parses a lesson made of the given JSON steps.
+func parseSteps(steps string) (*Lesson, error) {
+	return Parse(strings.NewReader(`{"title": "Test", "steps": [` + steps + `]}`))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		step       string
+		wantPrompt string
+	}{
+		{"chord", `{"type": "chord", "quality": "Minor"}`, "Play any Minor chord"},
+		{"chord with root and inversion", `{"type": "chord", "root": "F", "quality": "Major 7th", "inversion": 2}`, "Play F Major 7th (2nd inversion)"},
+		{"note", `{"type": "note", "notes": ["C4"]}`, "Play the note C4"},
+		{"melody", `{"type": "melody", "notes": ["C4", "62", "E4+G4"]}`, "Play the melody C4 62 E4+G4"},
+		{"key", `{"type": "key", "key": "G major"}`, "Play something in G major"},
+		{"prompt kept", `{"type": "note", "notes": ["C4"], "prompt": "Find middle C"}`, "Find middle C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := parseSteps(tt.step)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := l.Steps[0].Prompt; got != tt.wantPrompt {
+				t.Errorf("prompt = %q, want %q", got, tt.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestParseMelodyNotes(t *testing.T) {
+	l, err := parseSteps(`{"type": "melody", "notes": ["C4", "62", "G4+E4"]}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	events := l.Steps[0].score.Events
+	if len(events) != 3 || events[1].Notes[0] != 62 || len(events[2].Notes) != 2 || events[2].Notes[0] != 64 {
+		t.Errorf("score events = %+v, want C4, D4 and E4+G4", events)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		lesson string
+	}{
+		{"not JSON", `{"title": `},
+		{"no steps", `{"title": "Empty", "steps": []}`},
+		{"unknown step type", `{"steps": [{"type": "arpeggio"}]}`},
+		{"unknown chord quality", `{"steps": [{"type": "chord", "quality": "Mega"}]}`},
+		{"invalid chord root", `{"steps": [{"type": "chord", "root": "H", "quality": "Major"}]}`},
+		{"negative inversion", `{"steps": [{"type": "chord", "quality": "Major", "inversion": -1}]}`},
+		{"inversion beyond the chord tones", `{"steps": [{"type": "chord", "quality": "Major", "inversion": 3}]}`},
+		{"added 6th in the bass", `{"steps": [{"type": "chord", "quality": "Major 6th", "inversion": 3}]}`},
+		{"note step without notes", `{"steps": [{"type": "note"}]}`},
+		{"note step with several notes", `{"steps": [{"type": "note", "notes": ["C4", "D4"]}]}`},
+		{"melody without notes", `{"steps": [{"type": "melody", "notes": []}]}`},
+		{"invalid note", `{"steps": [{"type": "melody", "notes": ["C4", "X9"]}]}`},
+		{"note out of range", `{"steps": [{"type": "note", "notes": ["128"]}]}`},
+		{"invalid key", `{"steps": [{"type": "key", "key": "H major"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.lesson)); !errors.Is(err, ErrInvalidLesson) {
+				t.Errorf("Parse() error = %v, want ErrInvalidLesson", err)
+			}
+		})
+	}
+}
+
+func TestHasStep(t *testing.T) {
+	l, err := parseSteps(`{"type": "note", "notes": ["C4"]}, {"type": "key", "key": "C major"}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for stepType, want := range map[string]bool{StepNote: true, StepKey: true, StepChord: false, StepMelody: false} {
+		if got := l.HasStep(stepType); got != want {
+			t.Errorf("HasStep(%q) = %v, want %v", stepType, got, want)
+		}
+	}
+}
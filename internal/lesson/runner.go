@@ -0,0 +1,305 @@
+package lesson
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// StepResult is the outcome of a lesson step.
+type StepResult struct {
+	Prompt   string
+	Passed   bool
+	Attempts int // Attempts made, including the passing one.
+}
+
+// Runner drives a lesson from the pipeline results and prints feedback for the student.
+// It implements sink.Sink, so it is attached to the pipeline like any other output.
+//
+// Chord steps pass as soon as the expected chord is heard; a different chord counts as a failed
+// attempt once every key is released. Note and melody steps follow the notes in order and fail when
+// more than MaxMistakes wrong or extra notes are played. Key steps pass when the detected key matches.
+type Runner struct {
+	mu      sync.Mutex
+	lesson  *Lesson
+	out     io.Writer
+	current int
+	results []StepResult
+
+	follower  *follower.Follower // Progress of the current note or melody step.
+	mistakes  int
+	heard     string // Last chord heard in a chord step while keys are held.
+	lastKey   string
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRunner creates a runner for lesson, writing feedback to out.
+func NewRunner(lesson *Lesson, out io.Writer) *Runner {
+	results := make([]StepResult, len(lesson.Steps))
+	for i, step := range lesson.Steps {
+		results[i].Prompt = step.Prompt
+	}
+	return &Runner{
+		lesson:  lesson,
+		out:     out,
+		results: results,
+		done:    make(chan struct{}),
+	}
+}
+
+// Title returns the title of the lesson.
+func (r *Runner) Title() string {
+	return r.lesson.Title
+}
+
+// Start prints the lesson title and the first step. Call it once capture has started, so the student
+// sees the first prompt before playing; Write calls it otherwise.
+func (r *Runner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.startLocked()
+}
+
+// Lesson returns the lesson being run.
+func (r *Runner) Lesson() *Lesson {
+	return r.lesson
+}
+
+// Done is closed once every step has been passed or given up.
+func (r *Runner) Done() <-chan struct{} {
+	return r.done
+}
+
+// Results returns the outcome of every step so far.
+func (r *Runner) Results() []StepResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]StepResult(nil), r.results...)
+}
+
+// Write evaluates the current step against a processed event.
+func (r *Runner) Write(snapshot *sink.Snapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.startLocked()
+	if r.current >= len(r.lesson.Steps) {
+		return nil
+	}
+
+	step := &r.lesson.Steps[r.current]
+	switch step.Type {
+	case StepChord:
+		r.evaluateChord(step, snapshot)
+	case StepNote, StepMelody:
+		r.evaluateNotes(step, snapshot)
+	case StepKey:
+		r.evaluateKey(step, snapshot)
+	}
+	return nil
+}
+
+// Flush does nothing; feedback is printed as it happens.
+func (r *Runner) Flush() error {
+	return nil
+}
+
+// Close prints the lesson summary if the lesson was interrupted before its end.
+func (r *Runner) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started && r.current < len(r.lesson.Steps) {
+		r.printf("Lesson stopped at step %d of %d.\n", r.current+1, len(r.lesson.Steps))
+		r.printSummaryLocked()
+	}
+	return nil
+}
+
+// evaluateChord passes the step when the expected chord sounds, and fails an attempt when the student
+// releases every key after playing a different chord.
+func (r *Runner) evaluateChord(step *Step, snapshot *sink.Snapshot) {
+	if snapshot.ChordSymbol != "" {
+		if chordMatches(step, snapshot) {
+			r.passLocked()
+			return
+		}
+		heard := snapshot.ChordSymbol
+		if snapshot.Inversion != "" {
+			heard += " (" + snapshot.Inversion + ")"
+		}
+		r.heard = heard
+	}
+
+	if snapshot.Command != byte(contracts.NoteOn) && len(snapshot.PressedNotes) == 0 && r.heard != "" {
+		r.failLocked(fmt.Sprintf("Heard %s, expected %s.", r.heard, step.Expected()))
+	}
+}
+
+// evaluateNotes follows the notes of a note or melody step.
+func (r *Runner) evaluateNotes(step *Step, snapshot *sink.Snapshot) {
+	if snapshot.Command != byte(contracts.NoteOn) || snapshot.Velocity == 0 {
+		return
+	}
+	if r.follower == nil {
+		r.follower = follower.New(step.score)
+	}
+
+	report := r.follower.Play(int(snapshot.Note))
+	if report.Result != follower.Correct || len(report.Missed) > 0 {
+		r.mistakes++
+		if r.mistakes > step.MaxMistakes {
+			r.failLocked(fmt.Sprintf("Played %s, expected %s.", midi.GetNoteName(report.Note), noteNames(step, report)))
+			return
+		}
+		r.printf("  ! %s is not right (%d of %d mistakes allowed).\n",
+			midi.GetNoteName(report.Note), r.mistakes, step.MaxMistakes)
+	}
+	if report.Finished {
+		r.passLocked()
+	}
+}
+
+// evaluateKey passes the step when the detected key matches, mentioning other keys as they are detected.
+func (r *Runner) evaluateKey(step *Step, snapshot *sink.Snapshot) {
+	if snapshot.Key == "" || snapshot.Key == r.lastKey {
+		return
+	}
+	r.lastKey = snapshot.Key
+	if key, err := midi.ParseKey(snapshot.Key); err == nil && key.SameKey(step.key) {
+		r.passLocked()
+		return
+	}
+	r.printf("  ~ Sounds like %s so far, keep playing in %s.\n", snapshot.Key, step.key)
+}
+
+// startLocked prints the lesson header and the first prompt once.
+func (r *Runner) startLocked() {
+	if r.started {
+		return
+	}
+	r.started = true
+	r.printf("Lesson: %s (%d steps)\n", r.lesson.Title, len(r.lesson.Steps))
+	if r.lesson.Description != "" {
+		r.printf("%s\n", r.lesson.Description)
+	}
+	r.promptLocked()
+}
+
+// promptLocked prints the current step.
+func (r *Runner) promptLocked() {
+	r.printf("\nStep %d/%d: %s\n", r.current+1, len(r.lesson.Steps), r.lesson.Steps[r.current].Prompt)
+}
+
+// passLocked records a passed attempt and moves to the next step.
+func (r *Runner) passLocked() {
+	result := &r.results[r.current]
+	result.Attempts++
+	result.Passed = true
+	r.printf("  ✔ Passed!\n")
+	r.nextLocked()
+}
+
+// failLocked records a failed attempt with feedback, giving up the step after MaxAttempts.
+func (r *Runner) failLocked(feedback string) {
+	step := &r.lesson.Steps[r.current]
+	result := &r.results[r.current]
+	result.Attempts++
+	r.resetAttemptLocked()
+
+	if step.MaxAttempts > 0 && result.Attempts >= step.MaxAttempts {
+		r.printf("  ✘ %s Moving on after %d attempts.\n", feedback, result.Attempts)
+		r.nextLocked()
+		return
+	}
+	r.printf("  ✘ %s Try again.\n", feedback)
+}
+
+// nextLocked moves to the next step, or finishes the lesson after the last one.
+func (r *Runner) nextLocked() {
+	r.resetAttemptLocked()
+	r.lastKey = ""
+	r.current++
+	if r.current < len(r.lesson.Steps) {
+		r.promptLocked()
+		return
+	}
+	r.printf("\nLesson complete!\n")
+	r.printSummaryLocked()
+	r.closeOnce.Do(func() { close(r.done) })
+}
+
+// resetAttemptLocked clears the progress of the current attempt.
+func (r *Runner) resetAttemptLocked() {
+	r.follower = nil
+	r.mistakes = 0
+	r.heard = ""
+}
+
+// printSummaryLocked prints the outcome of every step.
+func (r *Runner) printSummaryLocked() {
+	passed := 0
+	for i, result := range r.results {
+		status := "not reached"
+		switch {
+		case result.Passed:
+			passed++
+			status = fmt.Sprintf("passed in %d attempt(s)", result.Attempts)
+		case result.Attempts > 0:
+			status = fmt.Sprintf("failed after %d attempt(s)", result.Attempts)
+		}
+		r.printf("  %d. %s: %s\n", i+1, r.lesson.Steps[i].Prompt, status)
+	}
+	r.printf("%d of %d steps passed.\n", passed, len(r.lesson.Steps))
+}
+
+// printf writes feedback, ignoring terminal errors.
+func (r *Runner) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(r.out, format, args...)
+}
+
+// chordMatches reports whether the chord in the snapshot satisfies a chord step. Steps with a root
+// accept any ranked interpretation of the notes, so ambiguous voicings (e.g. C6 and Am7) still pass.
+func chordMatches(step *Step, snapshot *sink.Snapshot) bool {
+	name := step.chordName()
+	if name == "" {
+		if snapshot.Chord != step.Quality {
+			return false
+		}
+		return step.Inversion == nil || snapshot.Inversion == midi.InversionName(*step.Inversion)
+	}
+
+	for _, candidate := range append([]string{snapshot.ChordSymbol}, snapshot.Alternatives...) {
+		if step.Inversion == nil {
+			// Any inversion: compare the symbols without the slash bass.
+			candidate, _, _ = strings.Cut(candidate, "/")
+		}
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// noteNames describes the notes the student should have played.
+func noteNames(step *Step, report follower.Report) string {
+	notes := report.Expected
+	if len(report.Missed) > 0 {
+		notes = report.Missed
+	}
+	if len(notes) == 0 {
+		return step.Expected()
+	}
+	names := make([]string, len(notes))
+	for i, note := range notes {
+		names[i] = midi.GetNoteName(note)
+	}
+	return strings.Join(names, "+")
+}
@@ -0,0 +1,223 @@
+package lesson
+
+import (
+	"io"
+	"testing"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// noteOn returns the snapshot of a Note On of note with nothing else held.
+func noteOn(note int) *sink.Snapshot {
+	return &sink.Snapshot{Command: byte(contracts.NoteOn), Note: byte(note), Velocity: 80, PressedNotes: []int{note}}
+}
+
+// chord returns the snapshot of the last Note On of a chord, named as the chord stage does.
+func chord(notes ...int) *sink.Snapshot {
+	snapshot := &sink.Snapshot{Command: byte(contracts.NoteOn), Note: byte(notes[len(notes)-1]), Velocity: 80, PressedNotes: notes}
+	matches := midi.IdentifyChord(notes)
+	if len(matches) == 0 {
+		return snapshot
+	}
+	snapshot.Chord, snapshot.Inversion, snapshot.ChordSymbol = matches[0].Quality, matches[0].InversionName, matches[0].Name
+	for _, alternative := range matches[1:] {
+		snapshot.Alternatives = append(snapshot.Alternatives, alternative.Name)
+	}
+	return snapshot
+}
+
+// release returns the snapshot of the last key released.
+func release() *sink.Snapshot {
+	return &sink.Snapshot{Command: byte(contracts.NoteOff)}
+}
+
+// key returns a snapshot with the detected key.
+func key(name string) *sink.Snapshot {
+	return &sink.Snapshot{Command: byte(contracts.NoteOn), Key: name}
+}
+
+func TestRunner(t *testing.T) {
+	tests := []struct {
+		name   string
+		step   string
+		played []*sink.Snapshot
+		want   StepResult // Outcome of the only step; Prompt is not compared.
+		done   bool
+	}{
+		{
+			name:   "chord with root and inversion",
+			step:   `{"type": "chord", "root": "C", "quality": "Major", "inversion": 1}`,
+			played: []*sink.Snapshot{chord(64, 67, 72)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "chord in another inversion, then right",
+			step:   `{"type": "chord", "root": "C", "quality": "Major", "inversion": 1}`,
+			played: []*sink.Snapshot{chord(60, 64, 67), release(), chord(64, 67, 72)},
+			want:   StepResult{Passed: true, Attempts: 2},
+			done:   true,
+		},
+		{
+			name:   "any inversion",
+			step:   `{"type": "chord", "root": "C", "quality": "Major"}`,
+			played: []*sink.Snapshot{chord(55, 60, 64)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "any root",
+			step:   `{"type": "chord", "quality": "Minor"}`,
+			played: []*sink.Snapshot{chord(62, 65, 69)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "ambiguous voicing accepted through the alternatives",
+			step:   `{"type": "chord", "root": "C", "quality": "Major 6th"}`,
+			played: []*sink.Snapshot{chord(57, 60, 64, 67)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "wrong chord is only a failed attempt once released",
+			step:   `{"type": "chord", "root": "C", "quality": "Major"}`,
+			played: []*sink.Snapshot{chord(60, 63, 67)},
+			want:   StepResult{},
+		},
+		{
+			name:   "chord given up after max attempts",
+			step:   `{"type": "chord", "root": "C", "quality": "Major", "maxAttempts": 2}`,
+			played: []*sink.Snapshot{chord(60, 63, 67), release(), chord(62, 65, 69), release()},
+			want:   StepResult{Attempts: 2},
+			done:   true,
+		},
+		{
+			name:   "note",
+			step:   `{"type": "note", "notes": ["C4"]}`,
+			played: []*sink.Snapshot{noteOn(60)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "wrong note, then right",
+			step:   `{"type": "note", "notes": ["C4"]}`,
+			played: []*sink.Snapshot{noteOn(62), noteOn(60)},
+			want:   StepResult{Passed: true, Attempts: 2},
+			done:   true,
+		},
+		{
+			name:   "note given up after max attempts",
+			step:   `{"type": "note", "notes": ["C4"], "maxAttempts": 1}`,
+			played: []*sink.Snapshot{noteOn(62)},
+			want:   StepResult{Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "melody",
+			step:   `{"type": "melody", "notes": ["C4", "D4", "E4"]}`,
+			played: []*sink.Snapshot{noteOn(60), noteOn(62), noteOn(64)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "melody with chords",
+			step:   `{"type": "melody", "notes": ["C4", "E4+G4"]}`,
+			played: []*sink.Snapshot{noteOn(60), noteOn(64), noteOn(67)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "mistake within max mistakes",
+			step:   `{"type": "melody", "notes": ["C4", "D4", "E4"], "maxMistakes": 1}`,
+			played: []*sink.Snapshot{noteOn(60), noteOn(61), noteOn(64)},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "mistakes beyond max mistakes restart the melody",
+			step:   `{"type": "melody", "notes": ["C4", "D4", "E4"], "maxMistakes": 1}`,
+			played: []*sink.Snapshot{noteOn(60), noteOn(61), noteOn(63), noteOn(60), noteOn(62), noteOn(64)},
+			want:   StepResult{Passed: true, Attempts: 2},
+			done:   true,
+		},
+		{
+			name:   "note off and zero velocity are not played notes",
+			step:   `{"type": "note", "notes": ["C4"]}`,
+			played: []*sink.Snapshot{release(), {Command: byte(contracts.NoteOn), Note: 62}},
+			want:   StepResult{},
+		},
+		{
+			name:   "key",
+			step:   `{"type": "key", "key": "G major"}`,
+			played: []*sink.Snapshot{key("D major"), key("E minor"), key("G major")},
+			want:   StepResult{Passed: true, Attempts: 1},
+			done:   true,
+		},
+		{
+			name:   "other keys are not failed attempts",
+			step:   `{"type": "key", "key": "G major", "maxAttempts": 1}`,
+			played: []*sink.Snapshot{key("D major"), key("C major")},
+			want:   StepResult{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := parseSteps(tt.step)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			runner := NewRunner(l, io.Discard)
+			runner.Start()
+			for _, snapshot := range tt.played {
+				if err := runner.Write(snapshot); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+
+			got := runner.Results()[0]
+			if got.Passed != tt.want.Passed || got.Attempts != tt.want.Attempts {
+				t.Errorf("result = passed %v in %d attempt(s), want passed %v in %d", got.Passed, got.Attempts, tt.want.Passed, tt.want.Attempts)
+			}
+			select {
+			case <-runner.Done():
+				if !tt.done {
+					t.Error("lesson done, want it still running")
+				}
+			default:
+				if tt.done {
+					t.Error("lesson still running, want it done")
+				}
+			}
+		})
+	}
+}
+
+func TestRunnerSteps(t *testing.T) {
+	l, err := parseSteps(`{"type": "note", "notes": ["C4"]}, {"type": "key", "key": "A minor"}, {"type": "note", "notes": ["A4"]}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	runner := NewRunner(l, io.Discard)
+	// Each step is evaluated from the snapshots arriving once it is current: a key detected earlier does not count.
+	_ = runner.Write(key("A minor"))
+	_ = runner.Write(noteOn(60))
+	if results := runner.Results(); !results[0].Passed || results[1].Passed {
+		t.Fatalf("results = %+v, want only the first step passed", results)
+	}
+	_ = runner.Write(key("C major"))
+	_ = runner.Write(key("A minor"))
+	results := runner.Results()
+	if !results[1].Passed || results[2].Attempts != 0 {
+		t.Fatalf("results = %+v, want the first two steps passed", results)
+	}
+	select {
+	case <-runner.Done():
+		t.Fatal("lesson done before its last step")
+	default:
+	}
+	_ = runner.Write(noteOn(69))
+	<-runner.Done()
+}
@@ -1,5 +1,10 @@
 package midi
 
+import (
+	"math/bits"
+	"sort"
+)
+
 // Definition of chords and their intervals as hashes for quick lookup.
 // Intervals are relative to the root (0 represents the root).
 var chordHashes = map[string]int{
@@ -85,13 +90,114 @@ var chordHashes = map[string]int{
 	"Major 13th sharp 11":           hashChord([]int{0, 4, 7, 11, 14, 18, 21}),
 }
 
-// hashToChordNames maps hashes to a list of corresponding chord names.
-var hashToChordNames = make(map[int][]string)
+// chordSymbols maps chord qualities to the suffix used in chord symbols (e.g. "maj7" in "Cmaj7").
+var chordSymbols = map[string]string{
+	"Major":                         "",
+	"Minor":                         "m",
+	"Augmented":                     "aug",
+	"Diminished":                    "dim",
+	"Suspended 2nd":                 "sus2",
+	"Suspended 4th":                 "sus4",
+	"Major 6th":                     "6",
+	"Minor 6th":                     "m6",
+	"Major 7th":                     "maj7",
+	"Minor 7th":                     "m7",
+	"Dominant 7th":                  "7",
+	"Augmented 7th":                 "aug7",
+	"Augmented Major 7th":           "maj7#5",
+	"Diminished 7th":                "dim7",
+	"Half-diminished":               "m7b5",
+	"Minor Major 7th":               "m(maj7)",
+	"Major 9th":                     "maj9",
+	"Minor 9th":                     "m9",
+	"Dominant 9th":                  "9",
+	"Dominant 7th flat 9":           "7b9",
+	"Dominant 7th sharp 9":          "7#9",
+	"Dominant 9th flat 5":           "9b5",
+	"Dominant 9th sharp 5":          "9#5",
+	"Minor Major 9th":               "m(maj9)",
+	"Major 11th":                    "maj11",
+	"Minor 11th":                    "m11",
+	"Dominant 11th":                 "11",
+	"Dominant 7th sharp 11":         "7#11",
+	"Minor 11th flat 5":             "m11b5",
+	"Minor 11th sharp 5":            "m11#5",
+	"Major 13th":                    "maj13",
+	"Minor 13th":                    "m13",
+	"Dominant 13th":                 "13",
+	"Dominant 13th flat 9":          "13b9",
+	"Dominant 13th sharp 9":         "13#9",
+	"Minor 6/9":                     "m6/9",
+	"6/9":                           "6/9",
+	"Minor 7th flat 5":              "m7b5",
+	"Major 7th sharp 5":             "maj7#5",
+	"Dominant 7th flat 9 flat 5":    "7b9b5",
+	"Dominant 7th sharp 9 sharp 5":  "7#9#5",
+	"Suspended 4th add 9":           "sus4add9",
+	"Minor 9th flat 13":             "m9b13",
+	"Dominant 7th flat 13":          "7b13",
+	"Dominant 7th sharp 13":         "7#13",
+	"Add 9":                         "add9",
+	"Minor Add 9":                   "madd9",
+	"Dominant 13th flat 9 sharp 11": "13b9#11",
+	"Dominant 9th flat 13":          "9b13",
+	"Minor 11th add 13":             "m11add13",
+	"Dominant 7th flat 9 sharp 13":  "7b9#13",
+	"Major 9th add 13":              "maj9add13",
+	"Minor 9th flat 11":             "m9b11",
+	"Minor 13th sharp 11":           "m13#11",
+	"Dominant 9th add sharp 11":     "9#11",
+	"Dominant 11th sharp 9":         "11#9",
+	"Suspended 4th add 13":          "sus4add13",
+	"Minor 9th add 13":              "m9add13",
+	"Add 9 sharp 11":                "add9#11",
+	"Minor Add 9 sharp 11":          "madd9#11",
+	"Dominant 7th flat 9 sharp 11":  "7b9#11",
+	"Dominant 7th sharp 9 sharp 11": "7#9#11",
+	"Dominant 13th sharp 9 flat 11": "13#9b11",
+	"Minor 13th add flat 9":         "m13b9",
+	"Minor 13th sharp 9":            "m13#9",
+	"Major 9th sharp 13":            "maj9#13",
+	"Major 13th sharp 11":           "maj13#11",
+}
+
+// qualityPriority orders the most common chord qualities, used to rank interpretations sharing a pitch set.
+// Qualities not listed rank after these, in alphabetical order.
+var qualityPriority = []string{
+	"Major", "Minor", "Dominant 7th", "Minor 7th", "Major 7th", "Diminished", "Augmented",
+	"Half-diminished", "Diminished 7th", "Suspended 4th", "Suspended 2nd", "Major 6th", "Minor 6th",
+	"Minor Major 7th", "Augmented 7th", "Augmented Major 7th", "Add 9", "Minor Add 9",
+}
+
+// pitchClassChords maps a 12-bit pitch-class set, relative to the root, to the chord qualities it matches.
+// Extended intervals (9ths, 11ths, 13ths) are folded into a single octave, since played voicings rarely
+// follow the textbook spacing.
+var pitchClassChords = make(map[int][]string)
 
-// init initializes the hashToChordNames map by populating it with chord names based on hashes.
+// qualityRank caches the position of each quality in the ranking order.
+var qualityRank = make(map[string]int)
+
+// init folds every chord definition into pitchClassChords and computes the quality ranking.
 func init() {
-	for name, hash := range chordHashes {
-		hashToChordNames[hash] = append(hashToChordNames[hash], name)
+	names := make([]string, 0, len(chordHashes))
+	for name := range chordHashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		qualityRank[name] = len(qualityPriority) + i
+		hash := chordHashes[name]
+		folded := foldHash(hash)
+		// Skip definitions whose extension folds onto an existing chord tone (e.g. a sharp 13 onto the 7th),
+		// since they cannot be told apart from the simpler chord once voiced in a single octave.
+		if bits.OnesCount(uint(folded)) != bits.OnesCount(uint(hash)) {
+			continue
+		}
+		pitchClassChords[folded] = append(pitchClassChords[folded], name)
+	}
+	for i, name := range qualityPriority {
+		qualityRank[name] = i
 	}
 }
 
@@ -110,116 +216,233 @@ func hashChord(intervals []int) int {
 	return hash
 }
 
-// getChordHash computes a unique hash for a set of notes.
-// Notes are normalized to a single octave and represented as a bitmask.
-func getChordHash(notes []int) int {
-	var hash int
-	for _, note := range notes {
-		normNote := note % 12
-		if normNote < 0 {
-			normNote += 12
+// foldHash folds a chord hash with intervals beyond the octave into a 12-bit pitch-class set.
+func foldHash(hash int) int {
+	var folded int
+	for interval := 0; interval < 32; interval++ {
+		if (hash>>interval)&1 == 1 {
+			folded |= 1 << (interval % 12)
 		}
-		hash |= 1 << normNote
 	}
-	if hash == 0 {
-		return 0
+	return folded
+}
+
+// pitchClass normalizes a MIDI note to its pitch class (0 = C, 11 = B).
+func pitchClass(note int) int {
+	pc := note % 12
+	if pc < 0 {
+		pc += 12
 	}
-	// Finds the root (lowest note present) to calculate relative intervals
-	root := 0
-	for root < 12 && (hash>>root)&1 == 0 {
-		root++
+	return pc
+}
+
+// ChordMatch is one interpretation of a set of notes as a chord.
+type ChordMatch struct {
+	Quality       string // Chord quality as defined in chordHashes (e.g. "Major 7th").
+	Root          int    // Pitch class of the chord root (0 = C).
+	Bass          int    // Pitch class of the lowest note played.
+	Inversion     int    // 0 for root position, 1-3 for inversions, -1 when an extension or added 6th is in the bass.
+	InversionName string // Human-readable inversion (e.g. "1st inversion").
+	Name          string // Chord symbol, with a slash bass when inverted (e.g. "Cmaj7/E").
+}
+
+// Symbol returns the chord symbol without the slash bass (e.g. "Cmaj7").
+func (m ChordMatch) Symbol() string {
+	return ChordSymbol(m.Root, m.Quality)
+}
+
+// ChordSymbol builds a chord symbol from a root pitch class and a quality, e.g. (0, "Major 7th") gives "Cmaj7".
+func ChordSymbol(root int, quality string) string {
+	suffix, ok := chordSymbols[quality]
+	if !ok {
+		suffix = " " + quality
 	}
-	relativeHash := 0
-	for i := 0; i < 12; i++ {
-		if (hash>>((root+i)%12))&1 == 1 {
-			relativeHash |= 1 << i
-		}
+	return PitchClassName(root) + suffix
+}
+
+// InversionName returns the human-readable name of an inversion number.
+func InversionName(inversion int) string {
+	switch inversion {
+	case 0:
+		return "Root position"
+	case 1:
+		return "1st inversion"
+	case 2:
+		return "2nd inversion"
+	case 3:
+		return "3rd inversion"
+	default:
+		return "Unknown inversion"
 	}
-	return relativeHash
 }
 
-// detectInversion checks the inversion of a chord and returns the inversion number (0 for root position).
-func detectInversion(notes []int, chordHash int) int {
-	var currentHash int
+// IdentifyChord returns every chord interpretation of the given notes, best first.
+// Each pitch class present is tried as the root; interpretations in root position rank first,
+// then by how common the quality is, so a pitch set such as C-E-G-A is named C6 with C in the bass
+// and Am7 with A in the bass. Qualities sharing a chord symbol, such as "Half-diminished" and
+// "Minor 7th flat 5", are listed once, under the more common name. Returns nil if fewer than three notes
+// are given or nothing matches.
+func IdentifyChord(notes []int) []ChordMatch {
+	if len(notes) < 3 {
+		return nil
+	}
+
+	var notesHash int
+	lowest := notes[0]
 	for _, note := range notes {
-		normNote := note % 12
-		if normNote < 0 {
-			normNote += 12
+		notesHash |= 1 << pitchClass(note)
+		if note < lowest {
+			lowest = note
 		}
-		currentHash |= 1 << normNote
-	}
-	if currentHash == 0 {
-		return -1
-	}
-	root := 0
-	for root < 12 && (currentHash>>root)&1 == 0 {
-		root++
-	}
-	for inversion := 0; inversion < 12; inversion++ {
-		rotatedHash := (chordHash >> inversion) | (chordHash << (12 - inversion))
-		rotatedHash &= (1 << 12) - 1
-		rotatedCurrent := (currentHash >> root) | (currentHash << (12 - root))
-		rotatedCurrent &= (1 << 12) - 1
-		if rotatedHash == rotatedCurrent {
-			return inversion
+	}
+	bass := pitchClass(lowest)
+
+	var matches []ChordMatch
+	for root := 0; root < 12; root++ {
+		if (notesHash>>root)&1 == 0 {
+			continue
+		}
+		relative := rotateHash(notesHash, root)
+		for _, quality := range pitchClassChords[relative] {
+			inversion := chordInversion(quality, (bass-root+12)%12)
+			match := ChordMatch{
+				Quality:       quality,
+				Root:          root,
+				Bass:          bass,
+				Inversion:     inversion,
+				InversionName: InversionName(inversion),
+				Name:          ChordSymbol(root, quality),
+			}
+			if bass != root {
+				match.Name += "/" + PitchClassName(bass)
+			}
+			matches = append(matches, match)
 		}
 	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if (a.Inversion == 0) != (b.Inversion == 0) {
+			return a.Inversion == 0
+		}
+		if (a.Inversion < 0) != (b.Inversion < 0) {
+			return a.Inversion >= 0
+		}
+		if qualityRank[a.Quality] != qualityRank[b.Quality] {
+			return qualityRank[a.Quality] < qualityRank[b.Quality]
+		}
+		return a.Root < b.Root
+	})
+
+	named := make(map[string]bool, len(matches))
+	unique := matches[:0]
+	for _, match := range matches {
+		if !named[match.Name] {
+			named[match.Name] = true
+			unique = append(unique, match)
+		}
+	}
+	return unique
+}
+
+// DetectChord returns the best interpretation of the given notes, if any.
+func DetectChord(notes []int) (ChordMatch, bool) {
+	matches := IdentifyChord(notes)
+	if len(matches) == 0 {
+		return ChordMatch{}, false
+	}
+	return matches[0], true
+}
+
+// rotateHash rotates a 12-bit pitch-class set so that root becomes bit 0.
+func rotateHash(hash, root int) int {
+	rotated := (hash >> root) | (hash << (12 - root))
+	return rotated & ((1 << 12) - 1)
+}
+
+// chordInversion returns the inversion implied by the bass interval above the root:
+// the index of the bass among the chord tones in stacking order, or -1 when the bass is an extension or the
+// added 6th of a sixth chord, which is not stacked in thirds: C6 over A is heard as Am7, not a C6 inversion.
+func chordInversion(quality string, bassInterval int) int {
+	hash := chordHashes[quality]
+	index := 0
+	for interval := 0; interval < 32; interval++ {
+		if (hash>>interval)&1 == 0 {
+			continue
+		}
+		if interval%12 == bassInterval {
+			if interval >= 12 || isAddedSixth(hash, interval) {
+				return -1
+			}
+			return index
+		}
+		index++
+	}
 	return -1
 }
 
+// isAddedSixth reports whether interval is a major 6th added above the perfect fifth of a chord, as in C6,
+// rather than the diminished 7th stacked on a diminished fifth, as in Cdim7.
+func isAddedSixth(hash, interval int) bool {
+	return interval == 9 && (hash>>7)&1 == 1
+}
+
+// ChordInversions returns the number of positions a chord quality has a name for: root position and one
+// inversion per chord tone stacked within the octave. Returns 0 if the quality is unknown.
+func ChordInversions(quality string) int {
+	hash := chordHashes[quality]
+	positions := 0
+	for interval := 0; interval < 12; interval++ {
+		if (hash>>interval)&1 == 1 && !isAddedSixth(hash, interval) {
+			positions++
+		}
+	}
+	return positions
+}
+
 // GetChordName checks if a set of notes matches a known chord pattern, detecting inversions and key.
-// Returns the chord name, inversion, key, and a boolean indicating if a match was found.
+// Returns the chord name, inversion, key (the pitch class of the chord root), and a boolean indicating if
+// a match was found. See IdentifyChord for the ranking used when several interpretations exist.
 func GetChordName(notes []int) (string, string, int, bool) {
-	if len(notes) < 3 {
+	match, found := DetectChord(notes)
+	if !found {
 		return "", "", -1, false
 	}
-	notesHash := getChordHash(notes)
-	key := -1
-	for _, note := range notes {
-		normNote := note % 12
-		if normNote < 0 {
-			normNote += 12
-		}
-		if key == -1 || normNote < key {
-			key = normNote
-		}
+	return match.Quality, match.InversionName, match.Root, true
+}
+
+// ChordIntervals returns the intervals above the root that define a chord quality, in ascending order.
+// Returns nil if the quality is unknown.
+func ChordIntervals(quality string) []int {
+	hash, exists := chordHashes[quality]
+	if !exists {
+		return nil
 	}
-	chordNames, exists := hashToChordNames[notesHash]
-	if exists && len(chordNames) > 0 {
-		for _, chordName := range chordNames {
-			standardHash := chordHashes[chordName]
-			inversion := detectInversion(notes, standardHash)
-			if inversion != -1 {
-				inversionName := ""
-				switch inversion {
-				case 0:
-					inversionName = "Root position"
-				case 1:
-					inversionName = "1st inversion"
-				case 2:
-					inversionName = "2nd inversion"
-				default:
-					inversionName = "Unknown inversion"
-				}
-				return chordName, inversionName, key, true
-			}
+	var intervals []int
+	for interval := 0; interval < 32; interval++ {
+		if (hash>>interval)&1 == 1 {
+			intervals = append(intervals, interval)
 		}
 	}
-	return "", "", -1, false
+	return intervals
+}
+
+// ChordQualities returns every known chord quality in alphabetical order.
+func ChordQualities() []string {
+	qualities := make([]string, 0, len(chordHashes))
+	for name := range chordHashes {
+		qualities = append(qualities, name)
+	}
+	sort.Strings(qualities)
+	return qualities
 }
 
-// IsTriad checks if a chord is a triad.
+// IsTriad checks if a chord is a triad, i.e. it has exactly three tones, counting extensions above the octave.
 // Returns true if it is a triad, false otherwise.
 func IsTriad(chordName string) bool {
 	hash, exists := chordHashes[chordName]
 	if !exists {
 		return false
 	}
-	count := 0
-	for i := 0; i < 12; i++ {
-		if (hash>>i)&1 == 1 {
-			count++
-		}
-	}
-	return count == 3
+	return bits.OnesCount(uint(hash)) == 3
 }
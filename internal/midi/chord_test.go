@@ -0,0 +1,143 @@
+package midi
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIdentifyChord(t *testing.T) {
+	tests := []struct {
+		name          string
+		notes         []int
+		wantQuality   string
+		wantSymbol    string
+		wantInversion int
+	}{
+		{"C major", []int{60, 64, 67}, "Major", "C", 0},
+		{"A minor", []int{57, 60, 64}, "Minor", "Am", 0},
+		{"C major 1st inversion", []int{64, 67, 72}, "Major", "C/E", 1},
+		{"C major 2nd inversion", []int{55, 60, 64}, "Major", "C/G", 2},
+		{"G dominant 7th", []int{55, 59, 62, 65}, "Dominant 7th", "G7", 0},
+		{"D minor 7th", []int{62, 65, 69, 72}, "Minor 7th", "Dm7", 0},
+		{"F major 7th", []int{53, 57, 60, 64}, "Major 7th", "Fmaj7", 0},
+		{"B diminished", []int{59, 62, 65}, "Diminished", "Bdim", 0},
+		{"C add 9", []int{60, 64, 67, 74}, "Add 9", "Cadd9", 0},
+		{"C minor add 9 closed", []int{60, 62, 63, 67}, "Minor Add 9", "Cmadd9", 0},
+		{"C6 with C in the bass", []int{60, 64, 67, 69}, "Major 6th", "C6", 0},
+		{"Am7 with A in the bass", []int{57, 60, 64, 67}, "Minor 7th", "Am7", 0},
+		{"doubled notes", []int{48, 60, 64, 67, 72}, "Major", "C", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := DetectChord(tt.notes)
+			if !ok {
+				t.Fatalf("DetectChord(%v) found nothing", tt.notes)
+			}
+			if match.Quality != tt.wantQuality || match.Name != tt.wantSymbol || match.Inversion != tt.wantInversion {
+				t.Errorf("DetectChord(%v) = %s %q inversion %d, want %s %q inversion %d", tt.notes,
+					match.Quality, match.Name, match.Inversion, tt.wantQuality, tt.wantSymbol, tt.wantInversion)
+			}
+		})
+	}
+}
+
+func TestIdentifyChordNoMatch(t *testing.T) {
+	for _, notes := range [][]int{nil, {60}, {60, 64}, {60, 61, 62}} {
+		if matches := IdentifyChord(notes); matches != nil {
+			t.Errorf("IdentifyChord(%v) = %v, want nil", notes, matches)
+		}
+	}
+}
+
+func TestIsTriad(t *testing.T) {
+	tests := []struct {
+		quality string
+		want    bool
+	}{
+		{"Major", true},
+		{"Minor", true},
+		{"Augmented", true},
+		{"Diminished", true},
+		{"Suspended 2nd", true},
+		{"Suspended 4th", true},
+		{"Dominant 7th", false},
+		{"Major 6th", false},
+		// Extensions above the octave are chord tones too.
+		{"Add 9", false},
+		{"Minor Add 9", false},
+		{"Suspended 4th add 9", false},
+		{"Suspended 4th add 13", false},
+		{"Unknown", false},
+	}
+	for _, tt := range tests {
+		if got := IsTriad(tt.quality); got != tt.want {
+			t.Errorf("IsTriad(%q) = %v, want %v", tt.quality, got, tt.want)
+		}
+	}
+}
+
+func TestChordIntervals(t *testing.T) {
+	got := ChordIntervals("Add 9")
+	want := []int{0, 4, 7, 14}
+	if !slices.Equal(got, want) {
+		t.Errorf("ChordIntervals(Add 9) = %v, want %v", got, want)
+	}
+	if ChordIntervals("Unknown") != nil {
+		t.Error("ChordIntervals of an unknown quality is not nil")
+	}
+}
+
+func TestIdentifyChordAlternatives(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes []int
+		want  map[string]int // Alternatives expected among the matches, by name, with their inversion.
+	}{
+		// Half-diminished and Minor 7th flat 5 share the m7b5 symbol.
+		{"Bm7b5", []int{59, 62, 65, 69}, map[string]int{"Bm7b5": 0, "Dm6/B": -1}},
+		// Augmented Major 7th and Major 7th sharp 5 share the maj7#5 symbol.
+		{"Cmaj7#5", []int{60, 64, 68, 71}, map[string]int{"Cmaj7#5": 0}},
+		// The added 6th in the bass is not an inversion of the sixth chord.
+		{"Am7 over A", []int{57, 60, 64, 67}, map[string]int{"Am7": 0, "C6/A": -1}},
+		{"Am6 over F#", []int{54, 57, 60, 64}, map[string]int{"F#m7b5": 0, "Am6/F#": -1}},
+		{"C6 over E", []int{64, 67, 69, 72}, map[string]int{"C6/E": 1, "Am7/E": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := IdentifyChord(tt.notes)
+			found := make(map[string]int, len(matches))
+			for _, match := range matches {
+				if _, ok := found[match.Name]; ok {
+					t.Errorf("%s listed twice in %v", match.Name, matches)
+				}
+				found[match.Name] = match.Inversion
+			}
+			for name, inversion := range tt.want {
+				got, ok := found[name]
+				if !ok || got != inversion {
+					t.Errorf("%s in %v: found %v, inversion %d; want inversion %d", name, matches, ok, got, inversion)
+				}
+			}
+		})
+	}
+}
+
+func TestChordInversions(t *testing.T) {
+	tests := []struct {
+		quality string
+		want    int
+	}{
+		{"Major", 3},
+		{"Dominant 7th", 4},
+		{"Major 6th", 3},
+		{"Minor 6th", 3},
+		{"Diminished 7th", 4},
+		{"Add 9", 3},
+		{"Unknown", 0},
+	}
+	for _, tt := range tests {
+		if got := ChordInversions(tt.quality); got != tt.want {
+			t.Errorf("ChordInversions(%q) = %d, want %d", tt.quality, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey is returned when a key name cannot be parsed.
+var ErrInvalidKey = errors.New("invalid key")
+
+// Mode is the mode of a key.
+type Mode int
+
+const (
+	// Major mode.
+	Major Mode = iota
+	// Minor mode.
+	Minor
+)
+
+// String returns the lowercase name of the mode.
+func (m Mode) String() string {
+	if m == Minor {
+		return "minor"
+	}
+	return "major"
+}
+
+// Key is a tonal center with the confidence of its estimation.
+type Key struct {
+	Tonic      int     // Pitch class of the tonic (0 = C).
+	Mode       Mode    // Major or minor.
+	Confidence float64 // Correlation between the played profile and the key profile (-1 to 1).
+}
+
+// String returns the key name, e.g. "C major" or "F# minor".
+func (k Key) String() string {
+	return PitchClassName(k.Tonic) + " " + k.Mode.String()
+}
+
+// SameKey reports whether two keys share tonic and mode, ignoring confidence.
+func (k Key) SameKey(other Key) bool {
+	return k.Tonic == other.Tonic && k.Mode == other.Mode
+}
+
+// ParseKey parses a key name such as "C major", "F# minor", "Bb", "Am" or "c#m".
+// A bare tonic denotes a major key. The returned key has full confidence.
+func ParseKey(name string) (Key, error) {
+	fields := strings.Fields(strings.TrimSpace(name))
+	if len(fields) == 0 || len(fields) > 2 {
+		return Key{}, fmt.Errorf("%w: %q", ErrInvalidKey, name)
+	}
+
+	tonicName, modeName := fields[0], ""
+	if len(fields) == 2 {
+		modeName = strings.ToLower(fields[1])
+	} else if len(tonicName) > 1 && strings.HasSuffix(tonicName, "m") {
+		tonicName, modeName = strings.TrimSuffix(tonicName, "m"), "minor"
+	}
+
+	mode := Major
+	switch modeName {
+	case "", "major", "maj":
+		mode = Major
+	case "minor", "min":
+		mode = Minor
+	default:
+		return Key{}, fmt.Errorf("%w: unknown mode %q", ErrInvalidKey, fields[1])
+	}
+
+	tonicName = strings.ToUpper(tonicName[:1]) + tonicName[1:]
+	// Reuse the note parser with an arbitrary octave to resolve sharps and flats.
+	note, ok := ParseNoteName(tonicName + "4")
+	if !ok {
+		return Key{}, fmt.Errorf("%w: unknown tonic %q", ErrInvalidKey, tonicName)
+	}
+	return Key{Tonic: pitchClass(note), Mode: mode, Confidence: 1}, nil
+}
+
+// Krumhansl-Kessler key profiles, indexed by interval above the tonic.
+var (
+	majorKeyProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorKeyProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// KeyCorrelation returns the Pearson correlation between a pitch-class profile and the profile of a key.
+func KeyCorrelation(profile [12]float64, tonic int, mode Mode) float64 {
+	reference := majorKeyProfile
+	if mode == Minor {
+		reference = minorKeyProfile
+	}
+	var rotated [12]float64
+	for pc := 0; pc < 12; pc++ {
+		rotated[pc] = reference[(pc-tonic+12)%12]
+	}
+	return pearson(profile, rotated)
+}
+
+// EstimateKey returns the key whose profile best correlates with the given pitch-class profile
+// (Krumhansl-Schmuckler algorithm). Returns false if the profile is empty or flat.
+func EstimateKey(profile [12]float64) (Key, bool) {
+	best := Key{Confidence: math.Inf(-1)}
+	for tonic := 0; tonic < 12; tonic++ {
+		for _, mode := range []Mode{Major, Minor} {
+			if r := KeyCorrelation(profile, tonic, mode); r > best.Confidence {
+				best = Key{Tonic: tonic, Mode: mode, Confidence: r}
+			}
+		}
+	}
+	if math.IsInf(best.Confidence, -1) || math.IsNaN(best.Confidence) {
+		return Key{}, false
+	}
+	return best, true
+}
+
+// pearson computes the correlation coefficient of two profiles, or NaN if either is constant.
+func pearson(a, b [12]float64) float64 {
+	var meanA, meanB float64
+	for i := 0; i < 12; i++ {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= 12
+	meanB /= 12
+
+	var cov, varA, varB float64
+	for i := 0; i < 12; i++ {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// KeyDetector accumulates a rolling, time-decayed pitch-class profile of the notes played.
+// Each note adds its weight to its pitch class, and the whole profile decays by half every HalfLife,
+// so the estimate follows modulations instead of averaging the entire session.
+type KeyDetector struct {
+	HalfLife   time.Duration
+	profile    [12]float64
+	lastUpdate uint64
+	notes      int
+}
+
+// NewKeyDetector creates a detector whose profile decays with the given half-life.
+func NewKeyDetector(halfLife time.Duration) *KeyDetector {
+	return &KeyDetector{HalfLife: halfLife}
+}
+
+// AddNote adds a note onset at timestamp (Unix nanoseconds), weighted by velocity (1-127).
+func (d *KeyDetector) AddNote(note int, velocity byte, timestamp uint64) {
+	d.decay(timestamp)
+	weight := 0.5 + float64(velocity)/127
+	d.profile[pitchClass(note)] += weight
+	d.notes++
+}
+
+// Profile returns the current decayed pitch-class profile.
+func (d *KeyDetector) Profile() [12]float64 {
+	return d.profile
+}
+
+// Notes returns the number of notes added since the detector was created.
+func (d *KeyDetector) Notes() int {
+	return d.notes
+}
+
+// Estimate returns the key that best matches the current profile.
+func (d *KeyDetector) Estimate() (Key, bool) {
+	return EstimateKey(d.profile)
+}
+
+// Correlation returns how well the current profile matches the given key.
+func (d *KeyDetector) Correlation(key Key) float64 {
+	return KeyCorrelation(d.profile, key.Tonic, key.Mode)
+}
+
+// decay ages the profile up to timestamp.
+func (d *KeyDetector) decay(timestamp uint64) {
+	if d.lastUpdate != 0 && timestamp > d.lastUpdate && d.HalfLife > 0 {
+		elapsed := float64(timestamp - d.lastUpdate)
+		factor := math.Pow(0.5, elapsed/float64(d.HalfLife))
+		for i := range d.profile {
+			d.profile[i] *= factor
+		}
+	}
+	if timestamp > d.lastUpdate {
+		d.lastUpdate = timestamp
+	}
+}
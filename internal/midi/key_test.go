@@ -0,0 +1,100 @@
+package midi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantTonic int
+		wantMode  Mode
+		wantErr   bool
+	}{
+		{"C major", 0, Major, false},
+		{"F# minor", 6, Minor, false},
+		{"Bb", 10, Major, false},
+		{"Am", 9, Minor, false},
+		{"c#m", 1, Minor, false},
+		{"eb min", 3, Minor, false},
+		{"G maj", 7, Major, false},
+		{"", 0, Major, true},
+		{"H major", 0, Major, true},
+		{"C dorian", 0, Major, true},
+		{"C major key", 0, Major, true},
+	}
+	for _, tt := range tests {
+		key, err := ParseKey(tt.name)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("ParseKey(%q) error = %v, want ErrInvalidKey", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || key.Tonic != tt.wantTonic || key.Mode != tt.wantMode || key.Confidence != 1 {
+			t.Errorf("ParseKey(%q) = %+v, %v; want tonic %d %s", tt.name, key, err, tt.wantTonic, tt.wantMode)
+		}
+	}
+}
+
+func TestEstimateKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes []int
+		want  string
+	}{
+		{"C major scale", []int{60, 62, 64, 65, 67, 69, 71, 72, 67, 60, 64, 67}, "C major"},
+		{"G major scale", []int{67, 69, 71, 72, 74, 76, 78, 79, 74, 67, 71, 74}, "G major"},
+		{"A harmonic minor", []int{57, 59, 60, 62, 64, 65, 68, 69, 64, 57, 60, 64}, "A minor"},
+		{"E major triad arpeggio", []int{64, 68, 71, 76, 71, 68, 64}, "E major"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var profile [12]float64
+			for _, note := range tt.notes {
+				profile[pitchClass(note)]++
+			}
+			key, ok := EstimateKey(profile)
+			if !ok || key.String() != tt.want {
+				t.Errorf("EstimateKey() = %s (%v), want %s", key, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := EstimateKey([12]float64{}); ok {
+		t.Error("EstimateKey of an empty profile found a key")
+	}
+}
+
+func TestKeyDetectorFollowsModulation(t *testing.T) {
+	detector := NewKeyDetector(2 * time.Second)
+	var now uint64 = 1
+	play := func(notes ...int) {
+		for _, note := range notes {
+			detector.AddNote(note, 100, now)
+			now += uint64(250 * time.Millisecond)
+		}
+	}
+
+	cMajor := []int{60, 62, 64, 65, 67, 69, 71, 72}
+	for i := 0; i < 3; i++ {
+		play(cMajor...)
+	}
+	if key, ok := detector.Estimate(); !ok || key.String() != "C major" {
+		t.Fatalf("after C major: %s (%v)", key, ok)
+	}
+
+	// D major adds F# and C#; with the decay, the earlier C major notes fade out.
+	dMajor := []int{62, 64, 66, 67, 69, 71, 73, 74}
+	for i := 0; i < 4; i++ {
+		play(dMajor...)
+	}
+	if key, ok := detector.Estimate(); !ok || key.String() != "D major" {
+		t.Errorf("after D major: %s (%v)", key, ok)
+	}
+	if detector.Notes() != 56 {
+		t.Errorf("Notes() = %d, want 56", detector.Notes())
+	}
+}
@@ -36,3 +36,41 @@ func GetNoteName(midiNote int) string {
 	}
 	return noteNames[midiNote]
 }
+
+// noteNumbers maps note names back to MIDI note numbers, built from noteNames.
+var noteNumbers = make(map[string]int, len(noteNames))
+
+// flatEquivalents maps flat spellings to the sharp spellings used in noteNames.
+var flatEquivalents = map[string]string{
+	"Db": "C#", "Eb": "D#", "Gb": "F#", "Ab": "G#", "Bb": "A#",
+}
+
+func init() {
+	for number, name := range noteNames {
+		noteNumbers[name] = number
+	}
+}
+
+// ParseNoteName returns the MIDI note number for a name such as "C4", "F#3" or "Bb2".
+// Returns false if the name does not correspond to a note in the MIDI range.
+func ParseNoteName(name string) (int, bool) {
+	if len(name) >= 2 {
+		if sharp, ok := flatEquivalents[name[:2]]; ok {
+			name = sharp + name[2:]
+		}
+	}
+	number, ok := noteNumbers[name]
+	return number, ok
+}
+
+// pitchClassNames maps pitch classes (0-11) to note names without octave.
+var pitchClassNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// PitchClassName returns the name of a pitch class (0 = C), normalizing values outside 0-11.
+func PitchClassName(pitchClass int) string {
+	pc := pitchClass % 12
+	if pc < 0 {
+		pc += 12
+	}
+	return pitchClassNames[pc]
+}
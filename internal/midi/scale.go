@@ -0,0 +1,93 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidScale is returned when a scale name or range is not valid.
+var ErrInvalidScale = errors.New("invalid scale")
+
+// scaleIntervals defines the ascending steps of each scale as semitones above the tonic within one octave.
+var scaleIntervals = map[string][]int{
+	"Major":          {0, 2, 4, 5, 7, 9, 11},
+	"Natural Minor":  {0, 2, 3, 5, 7, 8, 10},
+	"Harmonic Minor": {0, 2, 3, 5, 7, 8, 11},
+	"Melodic Minor":  {0, 2, 3, 5, 7, 9, 11},
+	"Dorian":         {0, 2, 3, 5, 7, 9, 10},
+	"Phrygian":       {0, 1, 3, 5, 7, 8, 10},
+	"Lydian":         {0, 2, 4, 6, 7, 9, 11},
+	"Mixolydian":     {0, 2, 4, 5, 7, 9, 10},
+	"Locrian":        {0, 1, 3, 5, 6, 8, 10},
+}
+
+// descendingScales maps scales that descend differently to the scale used on the way down. The melodic
+// minor is played in its classical form, descending as a natural minor.
+var descendingScales = map[string]string{
+	"Melodic Minor": "Natural Minor",
+}
+
+// scaleAliases maps alternative names to the scale names of scaleIntervals.
+var scaleAliases = map[string]string{
+	"ionian":  "Major",
+	"minor":   "Natural Minor",
+	"aeolian": "Natural Minor",
+}
+
+// ScaleTypes returns every known scale name in alphabetical order.
+func ScaleTypes() []string {
+	names := make([]string, 0, len(scaleIntervals))
+	for name := range scaleIntervals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseScaleType returns the scale name matching name, ignoring case, e.g. "harmonic minor" gives
+// "Harmonic Minor". Mode names such as "aeolian" and "ionian" are accepted too.
+func ParseScaleType(name string) (string, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if alias, ok := scaleAliases[normalized]; ok {
+		return alias, nil
+	}
+	for scale := range scaleIntervals {
+		if strings.ToLower(scale) == normalized {
+			return scale, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown scale %q", ErrInvalidScale, name)
+}
+
+// ScaleNotes returns the MIDI notes of a scale played from tonic over the given number of octaves: the
+// ascent from the tonic to the tonic octaves above, and the descent back down from the note below the top.
+func ScaleNotes(tonic int, scale string, octaves int) (ascent, descent []int, err error) {
+	up, ok := scaleIntervals[scale]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: unknown scale %q", ErrInvalidScale, scale)
+	}
+	top := tonic + 12*octaves
+	if octaves < 1 || tonic < 0 || top > 127 {
+		return nil, nil, fmt.Errorf("%w: %d octaves from %s is out of range", ErrInvalidScale, octaves, GetNoteName(tonic))
+	}
+	down := up
+	if name, ok := descendingScales[scale]; ok {
+		down = scaleIntervals[name]
+	}
+
+	for octave := 0; octave < octaves; octave++ {
+		for _, interval := range up {
+			ascent = append(ascent, tonic+12*octave+interval)
+		}
+	}
+	ascent = append(ascent, top)
+
+	for octave := octaves - 1; octave >= 0; octave-- {
+		for i := len(down) - 1; i >= 0; i-- {
+			descent = append(descent, tonic+12*octave+down[i])
+		}
+	}
+	return ascent, descent, nil
+}
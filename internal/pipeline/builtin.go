@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/stages"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+)
+
+// RomanNumeralOptions configures the romanNumeral stage.
+type RomanNumeralOptions struct {
+	Key string `yaml:"key"` // Fixed key (see midi.ParseKey); empty uses the detected key.
+}
+
+// Requires returns the stages whose results the romanNumeral stage reads: the chord, and the detected key
+// unless a fixed key is set.
+func (o RomanNumeralOptions) Requires() []string {
+	if o.Key != "" {
+		return []string{StageChord}
+	}
+	return []string{StageChord, StageKey}
+}
+
+// TimingOptions configures the timing stage, which only runs with a metronome tempo.
+type TimingOptions struct {
+	BPM         float64       `yaml:"bpm"`         // Tempo of the grid onsets are scored against.
+	Subdivision int           `yaml:"subdivision"` // Grid points per beat.
+	Tolerance   time.Duration `yaml:"tolerance"`   // Largest timing error counted as on time.
+}
+
+// ScoreOptions configures the score stage, which only runs with a reference score.
+type ScoreOptions struct {
+	Path  string          `yaml:"path"` // Reference score file (see follower.LoadScore).
+	Score *follower.Score `yaml:"-"`    // Loaded reference score, used instead of Path.
+}
+
+// Registers the built-in stages. Stages without options only need the logger.
+func init() {
+	Register(StageNoteState, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewNoteStateUpdaterStage(env.Logger), nil
+	})
+	Register(StageNoteDuration, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewNoteDurationStage(env.Logger), nil
+	})
+	Register(StageInterval, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewIntervalCalculatorStage(env.Logger), nil
+	})
+	Register(StageBeat, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewBeatTrackingStage(env.Logger), nil
+	})
+	Register(StageTiming, TimingOptions{
+		Subdivision: rhythm.DefaultSubdivision,
+		Tolerance:   rhythm.DefaultTimingTolerance,
+	}, newTimingStage)
+	Register(StageScore, ScoreOptions{}, newScoreStage)
+	Register(StageNote, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewNoteIdentifierStage(env.Logger), nil
+	})
+	Register(StageChord, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewChordIdentifierStage(env.Logger), nil
+	})
+	Register(StageKey, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewKeyDetectionStage(env.Logger), nil
+	})
+	Register(StageRomanNumeral, RomanNumeralOptions{}, newRomanNumeralStage)
+	Register(StageFinal, struct{}{}, func(env StageEnv, _ struct{}) (AnalysisStage, error) {
+		return stages.NewFinalStage(env.Logger, env.Sinks...), nil
+	})
+}
+
+// newTimingStage scores onsets against the metronome grid, when a tempo is set.
+func newTimingStage(env StageEnv, options TimingOptions) (AnalysisStage, error) {
+	if options.BPM <= 0 {
+		return nil, nil
+	}
+	return stages.NewTimingAccuracyStage(env.Logger, options.BPM, options.Subdivision, options.Tolerance), nil
+}
+
+// newScoreStage aligns the performance to the reference score, when one is set.
+func newScoreStage(env StageEnv, options ScoreOptions) (AnalysisStage, error) {
+	score := options.Score
+	if score == nil && options.Path != "" {
+		var err error
+		if score, err = follower.LoadScore(options.Path); err != nil {
+			return nil, err
+		}
+	}
+	if score == nil {
+		return nil, nil
+	}
+	return stages.NewScoreFollowerStage(env.Logger, score), nil
+}
+
+// newRomanNumeralStage analyzes chord functions in the fixed key, or in the detected key when none is set.
+func newRomanNumeralStage(env StageEnv, options RomanNumeralOptions) (AnalysisStage, error) {
+	if options.Key == "" {
+		return stages.NewRomanNumeralStage(env.Logger, nil), nil
+	}
+	key, err := midi.ParseKey(options.Key)
+	if err != nil {
+		return nil, err
+	}
+	return stages.NewRomanNumeralStage(env.Logger, &key), nil
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 
 	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/analysis"
 	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
 )
 
 // PipelineContext is a custom context that embeds context.Context
@@ -17,6 +22,23 @@ type PipelineContext struct {
 	Triad      *string        // Identified triad, if applicable
 	Chord      *string        // Identified full chord, if applicable
 	Inversion  *string        // Chord inversion, if applicable
+
+	ChordSymbol       *string           // Chord symbol with slash bass (e.g. "Cmaj7/E"), if applicable
+	ChordMatch        *midi.ChordMatch  // Best chord interpretation with root and bass, if applicable
+	ChordAlternatives []midi.ChordMatch // Other interpretations of the same notes, ranked
+
+	Key        *midi.Key // Detected tonal center of the performance, if estimated
+	KeyChanged bool      // True when the detected key changed with this event
+
+	RomanNumeral *analysis.Numeral // Functional analysis of the chord in the current key, if applicable
+
+	Release       *store.NoteRelease      // Note released by this event, with its press time and velocity
+	CompletedNote *analysis.CompletedNote // Duration and articulation of the note released by this event
+
+	Beat   *rhythm.Beat   // Tempo and bar/beat position of this onset, once the tempo is known
+	Timing *rhythm.Timing // Timing error of this onset against the metronome grid, when one is configured
+
+	ScoreReport *follower.Report // Alignment of this note to the reference score, when one is followed
 }
 
 // NewPipelineContext initializes a new PipelineContext with a parent context and a MIDI event.
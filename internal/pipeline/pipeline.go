@@ -1,15 +1,48 @@
 package pipeline
 
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
 // Stage represents a stage in the pipeline that processes `TContext` using `TState`.
 // Each stage performs a specific operation on the context with access to shared state.
 type Stage[TContext any, TState any] interface {
 	Process(ctx *TContext, state *TState) error
 }
 
+// Starter is implemented by stages that set up resources, such as files or goroutines, before processing.
+// The context only bounds the start itself; work running until Stop must not depend on it.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by stages that release resources or flush pending work once processing ends.
+// Stop should return once the context is done, which marks the shutdown deadline.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// StageError reports the stage that failed to start or stop.
+type StageError struct {
+	Stage string // Name the stage was added with.
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("stage %q: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
 // Pipeline represents a sequence of stages that process data of type `TContext` with shared `TState`.
 // The pipeline manages the execution of each stage in a specific order, passing along context and state.
 type Pipeline[TContext any, TState any] struct {
 	stages []Stage[TContext, TState]
+	names  []string // Names of the stages, used to report lifecycle errors.
 	state  *TState
 }
 
@@ -22,10 +55,72 @@ func NewPipeline[TContext any, TState any](state *TState) *Pipeline[TContext, TS
 	}
 }
 
-// AddStage adds a stage to the pipeline.
+// AddStage adds a stage to the pipeline, named after its type.
 // Stages are executed in the order they are added.
 func (p *Pipeline[TContext, TState]) AddStage(stage Stage[TContext, TState]) {
+	p.AddNamedStage(fmt.Sprintf("%T", stage), stage)
+}
+
+// AddNamedStage adds a stage to the pipeline under the name its lifecycle errors are reported with.
+func (p *Pipeline[TContext, TState]) AddNamedStage(name string, stage Stage[TContext, TState]) {
 	p.stages = append(p.stages, stage)
+	p.names = append(p.names, name)
+}
+
+// Start starts the stages implementing Starter, in order. If a stage fails to start, a StageError is returned
+// once the other stages are stopped in reverse order, except those after it implementing Starter, which were
+// never started. Stages without Starter hold their resources from construction, so they are stopped wherever
+// they stand.
+func (p *Pipeline[TContext, TState]) Start(ctx context.Context) error {
+	for i, stage := range p.stages {
+		starter, ok := stage.(Starter)
+		if !ok {
+			continue
+		}
+		if err := starter.Start(ctx); err != nil {
+			startErr := &StageError{Stage: p.names[i], Err: err}
+			return errors.Join(startErr, p.stop(ctx, func(j int) bool {
+				_, started := p.stages[j].(Starter)
+				return j < i || !started
+			}))
+		}
+	}
+	return nil
+}
+
+// Stop stops the stages implementing Stopper, in reverse order, until ctx is done. Stages are stopped even if
+// others fail; the returned error joins a StageError for each stage that failed, did not return before the
+// deadline or was not reached.
+func (p *Pipeline[TContext, TState]) Stop(ctx context.Context) error {
+	return p.stop(ctx, func(int) bool { return true })
+}
+
+// stop stops the stages implementing Stopper whose index is included, in reverse order.
+func (p *Pipeline[TContext, TState]) stop(ctx context.Context, included func(i int) bool) error {
+	var errs []error
+	for i := len(p.stages) - 1; i >= 0; i-- {
+		stopper, ok := p.stages[i].(Stopper)
+		if !ok || !included(i) {
+			continue
+		}
+		if ctx.Err() != nil {
+			errs = append(errs, &StageError{Stage: p.names[i], Err: fmt.Errorf("not stopped: %w", ctx.Err())})
+			continue
+		}
+		// A stage ignoring the deadline is reported and left behind rather than holding up the shutdown.
+		stopped := make(chan error, 1)
+		go func() { stopped <- stopper.Stop(ctx) }()
+		var err error
+		select {
+		case err = <-stopped:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err != nil {
+			errs = append(errs, &StageError{Stage: p.names[i], Err: err})
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Process executes the pipeline by processing the given `TContext` through each stage in sequence.
@@ -0,0 +1,191 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testContext struct{ visited []string }
+type testState struct{}
+
+// callLog records lifecycle calls, including those of stages left behind after a deadline.
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *callLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.calls, ", ")
+}
+
+// lifecycleStage records its calls in a shared log.
+type lifecycleStage struct {
+	name     string
+	log      *callLog
+	startErr error
+	stopErr  error
+	hang     chan struct{} // When not nil, Stop ignores ctx and waits for it to be closed.
+}
+
+func (s *lifecycleStage) Process(ctx *testContext, _ *testState) error {
+	ctx.visited = append(ctx.visited, s.name)
+	return nil
+}
+
+func (s *lifecycleStage) Start(context.Context) error {
+	s.log.add("start " + s.name)
+	return s.startErr
+}
+
+func (s *lifecycleStage) Stop(context.Context) error {
+	s.log.add("stop " + s.name)
+	if s.hang != nil {
+		<-s.hang
+	}
+	return s.stopErr
+}
+
+// stopOnlyStage has resources to release, but nothing to start.
+type stopOnlyStage struct {
+	name string
+	log  *callLog
+}
+
+func (s *stopOnlyStage) Process(*testContext, *testState) error { return nil }
+
+func (s *stopOnlyStage) Stop(context.Context) error {
+	s.log.add("stop " + s.name)
+	return nil
+}
+
+// plainStage has no lifecycle hooks.
+type plainStage struct{}
+
+func (plainStage) Process(*testContext, *testState) error { return nil }
+
+func newTestPipeline(stages ...*lifecycleStage) *Pipeline[testContext, testState] {
+	p := NewPipeline[testContext, testState](&testState{})
+	for _, stage := range stages {
+		p.AddNamedStage(stage.name, stage)
+		p.AddStage(plainStage{})
+	}
+	return p
+}
+
+func TestPipelineLifecycleOrder(t *testing.T) {
+	var log callLog
+	p := newTestPipeline(
+		&lifecycleStage{name: "a", log: &log},
+		&lifecycleStage{name: "b", log: &log},
+		&lifecycleStage{name: "c", log: &log},
+	)
+
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	ctx, err := p.Process(&testContext{})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if got, want := strings.Join(ctx.visited, " "), "a b c"; got != want {
+		t.Errorf("processed by %q, want %q", got, want)
+	}
+	if got, want := log.String(), "start a, start b, start c, stop c, stop b, stop a"; got != want {
+		t.Errorf("lifecycle calls %q, want %q", got, want)
+	}
+}
+
+func TestPipelineStartRollback(t *testing.T) {
+	var log callLog
+	startErr := errors.New("cannot open")
+	p := newTestPipeline(
+		&lifecycleStage{name: "a", log: &log},
+		&lifecycleStage{name: "b", log: &log},
+		&lifecycleStage{name: "c", log: &log, startErr: startErr},
+		&lifecycleStage{name: "d", log: &log},
+	)
+	p.AddNamedStage("e", &stopOnlyStage{name: "e", log: &log})
+
+	err := p.Start(context.Background())
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != "c" || !errors.Is(err, startErr) {
+		t.Fatalf("Start = %v, want a StageError of stage c", err)
+	}
+	// d was never started, but e has nothing to start and must still release its resources.
+	if got, want := log.String(), "start a, start b, start c, stop e, stop b, stop a"; got != want {
+		t.Errorf("lifecycle calls %q, want %q", got, want)
+	}
+}
+
+func TestPipelineStopReportsFailures(t *testing.T) {
+	var log callLog
+	hang := make(chan struct{})
+	defer close(hang)
+	stopErr := errors.New("flush failed")
+	p := newTestPipeline(
+		&lifecycleStage{name: "a", log: &log},
+		&lifecycleStage{name: "b", log: &log, stopErr: stopErr},
+		&lifecycleStage{name: "c", log: &log, hang: hang},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := p.Stop(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Stop took %v with a stage ignoring the deadline", elapsed)
+	}
+
+	failed := map[string]error{}
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var stageErr *StageError
+		if !errors.As(err, &stageErr) {
+			t.Fatalf("error %v is not a StageError", err)
+		}
+		failed[stageErr.Stage] = stageErr.Err
+	}
+	if !errors.Is(failed["c"], context.DeadlineExceeded) {
+		t.Errorf("stage c error = %v, want the deadline exceeded", failed["c"])
+	}
+	// Stages after the one that missed the deadline are not reached.
+	if !errors.Is(failed["b"], context.DeadlineExceeded) || !errors.Is(failed["a"], context.DeadlineExceeded) {
+		t.Errorf("stages a and b errors = %v, %v, want not stopped", failed["a"], failed["b"])
+	}
+	if got, want := log.String(), "stop c"; got != want {
+		t.Errorf("lifecycle calls %q, want %q", got, want)
+	}
+}
+
+func TestPipelineStopContinuesAfterFailure(t *testing.T) {
+	var log callLog
+	stopErr := errors.New("flush failed")
+	p := newTestPipeline(
+		&lifecycleStage{name: "a", log: &log},
+		&lifecycleStage{name: "b", log: &log, stopErr: stopErr},
+	)
+
+	err := p.Stop(context.Background())
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != "b" || !errors.Is(err, stopErr) {
+		t.Fatalf("Stop = %v, want a StageError of stage b", err)
+	}
+	if got, want := log.String(), "stop b, stop a"; got != want {
+		t.Errorf("lifecycle calls %q, want %q", got, want)
+	}
+}
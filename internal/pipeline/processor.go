@@ -1,33 +1,135 @@
 package pipeline
 
 import (
+	stdcontext "context"
+	"time"
+
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
 	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
-	"github.com/leandrodaf/pianalyze/internal/pipeline/stages"
 	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/sink"
 	"go.uber.org/zap"
 )
 
+// Names of the built-in pipeline stages, used to choose the stages a processor runs.
+const (
+	StageNoteState    = "noteState"    // Tracks pressed and sounding notes and the sustain pedal; always enabled.
+	StageNoteDuration = "noteDuration" // Measures note durations and articulation.
+	StageInterval     = "interval"     // Calculates time intervals between events.
+	StageBeat         = "beat"         // Estimates tempo and bar/beat position.
+	StageTiming       = "timing"       // Scores onsets against the metronome grid, when a metronome is set.
+	StageScore        = "score"        // Aligns the performance to the reference score, when a score is set.
+	StageNote         = "note"         // Identifies the current note.
+	StageChord        = "chord"        // Identifies chords and inversions.
+	StageKey          = "key"          // Estimates the key and detects modulations.
+	StageRomanNumeral = "romanNumeral" // Analyzes the chord function in the key.
+	StageFinal        = "final"        // Logs the final state and publishes snapshots to sinks; always enabled.
+)
+
+// StageNames returns the names of the built-in stages in the order they run by default.
+func StageNames() []string {
+	return []string{
+		StageNoteState, StageNoteDuration, StageInterval, StageBeat, StageTiming, StageScore,
+		StageNote, StageChord, StageKey, StageRomanNumeral, StageFinal,
+	}
+}
+
 // Processor manages the execution of the pipeline by processing MIDI events through a series of stages.
 type Processor struct {
 	pipeline *Pipeline[context.PipelineContext, store.State]
 }
 
-// NewProcessor initializes a new pipeline processor with pre-configured stages.
-// Each stage in the pipeline performs specific operations on the MIDI event context and shared state.
-func NewProcessor(logger *zap.Logger) *Processor {
-	state := store.NewPipelineState()
-	p := NewPipeline[context.PipelineContext, store.State](state)
+// ProcessorOptions defines the configuration options for a Processor.
+type ProcessorOptions struct {
+	Sinks []sink.Sink // Outputs receiving a snapshot of every processed event.
+
+	Stages   []StageSpec // Stages to run, in order (see Register); nil runs the built-in stages (see StageNames).
+	Disabled []string    // Names of stages not to run, even when listed in Stages.
+	Required []string    // Names of stages that must run, because the sinks read their results.
+
+	configure []stageConfigurer // Adjustments of stage options, from ConfigureStage.
+}
+
+// ProcessorOption is a function that modifies ProcessorOptions.
+type ProcessorOption func(*ProcessorOptions)
+
+// WithSinks adds outputs receiving a snapshot of every processed event.
+func WithSinks(sinks ...sink.Sink) ProcessorOption {
+	return func(opts *ProcessorOptions) {
+		opts.Sinks = append(opts.Sinks, sinks...)
+	}
+}
+
+// WithKey analyzes harmony relative to a fixed key instead of the detected one.
+func WithKey(key midi.Key) ProcessorOption {
+	return ConfigureStage(StageRomanNumeral, func(opts *RomanNumeralOptions) {
+		opts.Key = key.String()
+	})
+}
+
+// WithMetronome scores every onset against a grid of subdivision points per beat at bpm.
+// Timing errors up to tolerance count as on time.
+func WithMetronome(bpm float64, subdivision int, tolerance time.Duration) ProcessorOption {
+	return ConfigureStage(StageTiming, func(opts *TimingOptions) {
+		opts.BPM = bpm
+		opts.Subdivision = subdivision
+		opts.Tolerance = tolerance
+	})
+}
+
+// WithScore aligns the performance to a reference score.
+func WithScore(score *follower.Score) ProcessorOption {
+	return ConfigureStage(StageScore, func(opts *ScoreOptions) {
+		opts.Score = score
+	})
+}
+
+// WithStages runs the named stages, in the given order, instead of the built-in ones. The note state and final
+// stages every processor needs run first and last whether listed or not.
+func WithStages(names ...string) ProcessorOption {
+	specs := make([]StageSpec, 0, len(names))
+	for _, name := range names {
+		specs = append(specs, StageSpec{Name: name})
+	}
+	return WithStageSpecs(specs...)
+}
+
+// WithStageSpecs is like WithStages, with options for the stages, typically read from the configuration.
+func WithStageSpecs(specs ...StageSpec) ProcessorOption {
+	return func(opts *ProcessorOptions) {
+		opts.Stages = append(opts.Stages, specs...)
+	}
+}
+
+// WithoutStages does not run the named stages. Stages disabled this way leave their snapshot fields empty.
+func WithoutStages(names ...string) ProcessorOption {
+	return func(opts *ProcessorOptions) {
+		opts.Disabled = append(opts.Disabled, names...)
+	}
+}
 
-	// Adds stages to the pipeline in the required order
-	p.AddStage(stages.NewNoteStateUpdaterStage(logger))   // Updates note state based on MIDI events
-	p.AddStage(stages.NewIntervalCalculatorStage(logger)) // Calculates time intervals between events
-	p.AddStage(stages.NewNoteIdentifierStage(logger))     // Identifies the current note
-	p.AddStage(stages.NewChordIdentifierStage(logger))    // Identifies chords and inversions
-	p.AddStage(stages.NewFinalStage(logger))              // Logs final state and sends data
+// WithRequiredStages fails to assemble the processor unless the named stages run, for sinks reading their
+// results, such as a lesson following the identified chords.
+func WithRequiredStages(names ...string) ProcessorOption {
+	return func(opts *ProcessorOptions) {
+		opts.Required = append(opts.Required, names...)
+	}
+}
+
+// NewProcessor assembles a pipeline processor from the registered stages (see Register), in the order given by
+// the options. Each stage in the pipeline performs specific operations on the MIDI event context and shared state.
+func NewProcessor(logger *zap.Logger, opts ...ProcessorOption) (*Processor, error) {
+	options := ProcessorOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
 
-	return &Processor{
-		pipeline: p,
+	p := NewPipeline[context.PipelineContext, store.State](store.NewPipelineState())
+	if err := options.build(StageEnv{Logger: logger, Sinks: options.Sinks}, p); err != nil {
+		return nil, err
 	}
+	return &Processor{pipeline: p}, nil
 }
 
 // Process executes the pipeline stages on the provided MIDI event context.
@@ -36,3 +138,16 @@ func (proc *Processor) Process(ctx *context.PipelineContext) error {
 	_, err := proc.pipeline.Process(ctx)
 	return err
 }
+
+// Start starts the stages that need to set up before processing (see Starter), in pipeline order. If a stage
+// fails to start, the processor is stopped and must not be used.
+func (proc *Processor) Start(ctx stdcontext.Context) error {
+	return proc.pipeline.Start(ctx)
+}
+
+// Stop stops the stages in reverse order (see Stopper): the sinks are flushed and closed, then the session
+// summaries are logged. Each stage that fails or misses the deadline of ctx is reported with a StageError. It
+// must be called once no more events will be processed.
+func (proc *Processor) Stop(ctx stdcontext.Context) error {
+	return proc.pipeline.Stop(ctx)
+}
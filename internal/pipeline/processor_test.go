@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"go.uber.org/zap"
+)
+
+// lifecycleSink records the calls made on it.
+type lifecycleSink struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (s *lifecycleSink) record(call string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *lifecycleSink) Write(*sink.Snapshot) error { s.record("write"); return nil }
+func (s *lifecycleSink) Flush() error               { s.record("flush"); return nil }
+func (s *lifecycleSink) Close() error               { s.record("close"); return nil }
+
+func TestProcessorStopClosesSinks(t *testing.T) {
+	out := &lifecycleSink{}
+	processor, err := NewProcessor(zap.NewNop(), WithSinks(out))
+	if err != nil {
+		t.Fatalf("NewProcessor: %v", err)
+	}
+	if err := processor.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := processor.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if len(out.calls) != 2 || out.calls[0] != "flush" || out.calls[1] != "close" {
+		t.Errorf("sink calls %v, want flush then close", out.calls)
+	}
+}
@@ -0,0 +1,257 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"go.uber.org/zap"
+)
+
+// Errors returned when a pipeline cannot be assembled.
+var (
+	ErrUnknownStage = errors.New("unknown stage")
+	ErrInvalidStage = errors.New("invalid stage")
+)
+
+// AnalysisStage is a stage of the pipeline run by a Processor.
+type AnalysisStage = Stage[context.PipelineContext, store.State]
+
+// StageEnv holds what stages are built with besides their options.
+type StageEnv struct {
+	Logger *zap.Logger
+	Sinks  []sink.Sink // Outputs receiving a snapshot of every processed event.
+}
+
+// Dependent is implemented by stage options when the stage reads the results of other stages, which must then
+// run before it.
+type Dependent interface {
+	Requires() []string // Names of the stages this one needs.
+}
+
+// StageFactory builds a stage from its options. It may return a nil stage when the options leave nothing to
+// do, such as timing scoring without a metronome. Stages implementing Starter and Stopper are started and
+// stopped with the processor.
+type StageFactory[O any] func(env StageEnv, options O) (AnalysisStage, error)
+
+// registration is a stage registered by name, with its options type erased.
+type registration struct {
+	defaults func() any // Returns a pointer to a copy of the default options.
+	build    func(env StageEnv, options any) (AnalysisStage, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]registration{}
+)
+
+// Register makes a stage available to processors under name. Options read from the configuration are decoded
+// over a copy of defaults, so O is usually a struct with yaml tags; stages without options use struct{}.
+// Register is meant to be called from init functions, including those of packages outside this module compiled
+// into the binary, and panics if name is empty or already registered.
+func Register[O any](name string, defaults O, factory StageFactory[O]) {
+	if name == "" || factory == nil {
+		panic("pipeline: Register needs a stage name and factory")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("pipeline: stage %q registered twice", name))
+	}
+	registry[name] = registration{
+		defaults: func() any {
+			options := defaults
+			return &options
+		},
+		build: func(env StageEnv, options any) (AnalysisStage, error) {
+			return factory(env, *options.(*O))
+		},
+	}
+}
+
+// Registered returns the names of the registered stages in alphabetical order.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StageSpec selects a registered stage for a processor.
+type StageSpec struct {
+	Name string
+	// Decode decodes the configured options into a pointer to the options of the stage, as yaml.Node.Decode
+	// does. Nil keeps the defaults.
+	Decode func(options any) error
+}
+
+// CheckStage reports whether spec names a registered stage whose options decode, without building it.
+func CheckStage(spec StageSpec) error {
+	_, _, err := spec.options()
+	return err
+}
+
+// CheckDisabled reports whether the named stage may be disabled: it must be registered and not one of the
+// stages every processor runs.
+func CheckDisabled(name string) error {
+	if _, ok := lookup(name); !ok {
+		return unknownStage(name)
+	}
+	if name == StageNoteState || name == StageFinal {
+		return fmt.Errorf("%w %q: always enabled", ErrInvalidStage, name)
+	}
+	return nil
+}
+
+// options returns the registration of the stage and its options, decoded over the defaults.
+func (spec StageSpec) options() (registration, any, error) {
+	reg, ok := lookup(spec.Name)
+	if !ok {
+		return reg, nil, unknownStage(spec.Name)
+	}
+	options := reg.defaults()
+	if spec.Decode != nil {
+		if err := spec.Decode(options); err != nil {
+			return reg, nil, fmt.Errorf("%w %q: options: %v", ErrInvalidStage, spec.Name, err)
+		}
+	}
+	return reg, options, nil
+}
+
+// ConfigureStage adjusts the options of the named stage once they are decoded from the configuration, for
+// settings given another way, such as command line flags. O must be the options type the stage registered.
+func ConfigureStage[O any](name string, configure func(*O)) ProcessorOption {
+	return func(opts *ProcessorOptions) {
+		opts.configure = append(opts.configure, stageConfigurer{name: name, apply: func(options any) error {
+			typed, ok := options.(*O)
+			if !ok {
+				return fmt.Errorf("%w %q: options are %T, not %T", ErrInvalidStage, name, options, typed)
+			}
+			configure(typed)
+			return nil
+		}})
+	}
+}
+
+// stageConfigurer is a ConfigureStage adjustment.
+type stageConfigurer struct {
+	name  string
+	apply func(options any) error
+}
+
+// lookup returns the registration of the named stage.
+func lookup(name string) (registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	reg, ok := registry[name]
+	return reg, ok
+}
+
+// unknownStage is the error for a stage name that is not registered.
+func unknownStage(name string) error {
+	return fmt.Errorf("%w %q (want one of %s)", ErrUnknownStage, name, strings.Join(Registered(), ", "))
+}
+
+// layout returns the stages the processor runs, in order: the configured stages, or the default ones, without
+// the disabled stages. The note state and final stages always run, first and last.
+func (opts ProcessorOptions) layout() ([]StageSpec, error) {
+	specs := opts.Stages
+	if specs == nil {
+		for _, name := range StageNames() {
+			specs = append(specs, StageSpec{Name: name})
+		}
+	}
+	disabled := make(map[string]bool, len(opts.Disabled))
+	for _, name := range opts.Disabled {
+		if err := CheckDisabled(name); err != nil {
+			return nil, err
+		}
+		disabled[name] = true
+	}
+
+	layout := []StageSpec{{Name: StageNoteState}}
+	final := StageSpec{Name: StageFinal}
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("%w %q: listed twice", ErrInvalidStage, spec.Name)
+		}
+		seen[spec.Name] = true
+		switch {
+		case disabled[spec.Name]:
+		case spec.Name == StageNoteState:
+			layout[0] = spec
+		case spec.Name == StageFinal:
+			final = spec
+		default:
+			layout = append(layout, spec)
+		}
+	}
+	return append(layout, final), nil
+}
+
+// build adds the stages of the layout to p, skipping those whose options leave nothing to do.
+func (opts ProcessorOptions) build(env StageEnv, p *Pipeline[context.PipelineContext, store.State]) error {
+	layout, err := opts.layout()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(layout))
+	run := make(map[string]bool, len(layout))
+	for _, spec := range layout {
+		reg, options, err := spec.options()
+		if err != nil {
+			return err
+		}
+		for _, configurer := range opts.configure {
+			if configurer.name != spec.Name {
+				continue
+			}
+			if err := configurer.apply(options); err != nil {
+				return err
+			}
+		}
+		if dependent, ok := options.(Dependent); ok {
+			for _, name := range dependent.Requires() {
+				if !run[name] {
+					return fmt.Errorf("%w %q: needs stage %q to run before it", ErrInvalidStage, spec.Name, name)
+				}
+			}
+		}
+		run[spec.Name] = true
+
+		stage, err := reg.build(env, options)
+		if err != nil {
+			return fmt.Errorf("stage %q: %w", spec.Name, err)
+		}
+		if stage == nil {
+			continue
+		}
+		p.AddNamedStage(spec.Name, stage)
+		names = append(names, spec.Name)
+	}
+
+	for _, name := range opts.Required {
+		if !run[name] {
+			return fmt.Errorf("%w %q: required, but disabled or not listed", ErrInvalidStage, name)
+		}
+	}
+	for _, configurer := range opts.configure {
+		if !run[configurer.name] {
+			env.Logger.Warn(constants.MsgStageOptionsIgnored, zap.String("stage", configurer.name))
+		}
+	}
+	env.Logger.Debug(constants.MsgPipelineAssembled, zap.Strings("stages", names))
+	return nil
+}
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"go.uber.org/zap"
+)
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ProcessorOptions
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			options: ProcessorOptions{},
+			want:    strings.Join(StageNames(), " "),
+		},
+		{
+			name:    "listed stages keep their order",
+			options: ProcessorOptions{Stages: []StageSpec{{Name: StageKey}, {Name: StageNote}}},
+			want:    "noteState key note final",
+		},
+		{
+			name: "note state and final are moved first and last",
+			options: ProcessorOptions{Stages: []StageSpec{
+				{Name: StageFinal}, {Name: StageChord}, {Name: StageNoteState},
+			}},
+			want: "noteState chord final",
+		},
+		{
+			name: "disabled stages are dropped",
+			options: ProcessorOptions{
+				Stages:   []StageSpec{{Name: StageBeat}, {Name: StageNote}},
+				Disabled: []string{StageBeat},
+			},
+			want: "noteState note final",
+		},
+		{
+			name:    "duplicate stage",
+			options: ProcessorOptions{Stages: []StageSpec{{Name: StageNote}, {Name: StageNote}}},
+			wantErr: ErrInvalidStage,
+		},
+		{
+			name:    "unknown stage disabled",
+			options: ProcessorOptions{Disabled: []string{"reverb"}},
+			wantErr: ErrUnknownStage,
+		},
+		{
+			name:    "always enabled stage disabled",
+			options: ProcessorOptions{Disabled: []string{StageFinal}},
+			wantErr: ErrInvalidStage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := tt.options.layout()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("layout() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("layout() error = %v", err)
+			}
+			names := make([]string, 0, len(layout))
+			for _, spec := range layout {
+				names = append(names, spec.Name)
+			}
+			if got := strings.Join(names, " "); got != tt.want {
+				t.Errorf("layout() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProcessorStageDependencies(t *testing.T) {
+	key, err := midi.ParseKey("C major")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		options []ProcessorOption
+		wantErr bool
+	}{
+		{name: "defaults", options: nil},
+		{name: "chord disabled", options: []ProcessorOption{WithoutStages(StageChord)}, wantErr: true},
+		{name: "key disabled", options: []ProcessorOption{WithoutStages(StageKey)}, wantErr: true},
+		{name: "key disabled with a fixed key", options: []ProcessorOption{WithoutStages(StageKey), WithKey(key)}},
+		{
+			name:    "roman numerals before the chords",
+			options: []ProcessorOption{WithStages(StageRomanNumeral, StageChord, StageKey)},
+			wantErr: true,
+		},
+		{name: "romanNumeral disabled", options: []ProcessorOption{WithoutStages(StageChord, StageKey, StageRomanNumeral)}},
+		{
+			name:    "required stage disabled",
+			options: []ProcessorOption{WithoutStages(StageNote), WithRequiredStages(StageNote)},
+			wantErr: true,
+		},
+		{name: "required stage run", options: []ProcessorOption{WithRequiredStages(StageChord, StageKey)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, err := NewProcessor(zap.NewNop(), tt.options...)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStage) {
+					t.Fatalf("NewProcessor() error = %v, want %v", err, ErrInvalidStage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewProcessor() error = %v", err)
+			}
+			_ = processor.Stop(context.Background())
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package stages
+
+import (
+	stdcontext "context"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"go.uber.org/zap"
+
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+)
+
+// BeatTrackingStage estimates the tempo and the bar/beat position of every note onset.
+// Note Off events and notes struck together as a chord do not count as onsets.
+type BeatTrackingStage struct {
+	logger  *zap.Logger
+	tracker *rhythm.Tracker
+}
+
+// NewBeatTrackingStage creates a new instance of BeatTrackingStage with zap logger and default settings.
+func NewBeatTrackingStage(logger *zap.Logger) *BeatTrackingStage {
+	return &BeatTrackingStage{
+		logger:  logger,
+		tracker: rhythm.NewTracker(),
+	}
+}
+
+// Process feeds onsets to the tracker and writes the tempo and metrical position into the context.
+func (s *BeatTrackingStage) Process(ctx *context.PipelineContext, state *store.State) error {
+	event := ctx.MIDIEvent
+	ctx.Beat = nil
+	if event.Command != byte(contracts.NoteOn) || event.Velocity == 0 {
+		return nil
+	}
+
+	beat, ok := s.tracker.AddOnset(event.Timestamp)
+	if !ok {
+		return nil
+	}
+	ctx.Beat = &beat
+
+	s.logger.Debug(constants.MsgBeatTracked,
+		zap.Float64("bpm", beat.BPM),
+		zap.Int("bar", beat.Bar),
+		zap.Int("beat", beat.Beat),
+		zap.Float64("phase", beat.Phase))
+	return nil
+}
+
+// Stop logs how the tempo drifted over the session.
+func (s *BeatTrackingStage) Stop(_ stdcontext.Context) error {
+	stats := s.tracker.Stats()
+	if stats.Estimates == 0 {
+		return nil
+	}
+	s.logger.Info(constants.MsgTempoSummary,
+		zap.Float64("initialBPM", stats.InitialBPM),
+		zap.Float64("finalBPM", stats.CurrentBPM),
+		zap.Float64("minBPM", stats.MinBPM),
+		zap.Float64("maxBPM", stats.MaxBPM),
+		zap.Float64("meanBPM", stats.MeanBPM),
+		zap.Float64("drift", stats.Drift()))
+	return nil
+}
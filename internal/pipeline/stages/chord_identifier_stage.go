@@ -9,7 +9,7 @@ import (
 	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
 )
 
-// ChordIdentifierStage identifies chords and triads based on pressed notes.
+// ChordIdentifierStage identifies chords and triads based on sounding notes.
 type ChordIdentifierStage struct {
 	logger *zap.Logger
 }
@@ -19,18 +19,30 @@ func NewChordIdentifierStage(logger *zap.Logger) *ChordIdentifierStage {
 	return &ChordIdentifierStage{logger: logger}
 }
 
-// Process identifies the current chord and triad based on pressed notes and updates the pipeline context.
+// Process identifies the current chord and triad based on sounding notes and updates the pipeline context.
+// Sounding notes include those held by the sustain pedal, so pedaled harmonies are still recognized.
 // Since unidentified chords can be common during live performance, they are handled without warnings.
 func (s *ChordIdentifierStage) Process(ctx *context.PipelineContext, state *store.State) error {
-	// Get currently pressed notes from the state.
-	pressedNotes := state.GetPressedNotes()
+	// Get currently sounding notes from the state.
+	soundingNotes := state.GetSoundingNotes()
 
-	// Identify the chord based on pressed notes.
-	chordName, inversionName, _, chordFound := midi.GetChordName(pressedNotes)
-	if chordFound {
+	// Identify the chord based on sounding notes, keeping every ranked interpretation.
+	matches := midi.IdentifyChord(soundingNotes)
+	if len(matches) > 0 {
+		best := matches[0]
+		chordName, inversionName, chordSymbol := best.Quality, best.InversionName, best.Name
 		ctx.Chord = &chordName
 		ctx.Inversion = &inversionName
-		s.logger.Info(constants.MsgChordAndInversionDetected, zap.String("chord", chordName), zap.String("inversion", inversionName))
+		ctx.ChordSymbol = &chordSymbol
+		ctx.ChordMatch = &best
+		ctx.ChordAlternatives = matches[1:]
+		s.logger.Info(constants.MsgChordAndInversionDetected,
+			zap.String("chord", chordName),
+			zap.String("inversion", inversionName),
+			zap.String("symbol", chordSymbol),
+			zap.String("root", midi.PitchClassName(best.Root)),
+			zap.String("bass", midi.PitchClassName(best.Bass)),
+			zap.Strings("alternatives", chordNames(ctx.ChordAlternatives)))
 
 		// Check if the chord is a triad.
 		if midi.IsTriad(chordName) {
@@ -46,6 +58,9 @@ func (s *ChordIdentifierStage) Process(ctx *context.PipelineContext, state *stor
 		unknownChord := constants.UnknownChord
 		ctx.Chord = &unknownChord
 		ctx.Inversion = nil
+		ctx.ChordSymbol = nil
+		ctx.ChordMatch = nil
+		ctx.ChordAlternatives = nil
 		s.logger.Debug(constants.MsgUnknownChord)
 
 		unknownTriad := constants.UnknownTriad
@@ -55,3 +70,12 @@ func (s *ChordIdentifierStage) Process(ctx *context.PipelineContext, state *stor
 
 	return nil
 }
+
+// chordNames returns the chord symbols of the given interpretations.
+func chordNames(matches []midi.ChordMatch) []string {
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = match.Name
+	}
+	return names
+}
@@ -1,25 +1,30 @@
 package stages
 
 import (
+	stdcontext "context"
+	"errors"
+
 	"github.com/leandrodaf/pianalyze/internal/constants"
 	"go.uber.org/zap"
 
 	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
 	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/sink"
 )
 
-// FinalStage sends processed data to the server and logs the current state.
+// FinalStage logs the current state and publishes a snapshot of the processed event to the configured sinks.
 type FinalStage struct {
 	logger *zap.Logger
+	sinks  sink.Multi
 }
 
-// NewFinalStage creates a new instance of FinalStage with zap logger.
-func NewFinalStage(logger *zap.Logger) *FinalStage {
-	return &FinalStage{logger: logger}
+// NewFinalStage creates a new instance of FinalStage with zap logger and the sinks receiving each snapshot.
+func NewFinalStage(logger *zap.Logger, sinks ...sink.Sink) *FinalStage {
+	return &FinalStage{logger: logger, sinks: sinks}
 }
 
-// Process sends processed data to the server and logs the pipeline context and shared state.
-// In a real implementation, this function would contain server communication logic.
+// Process logs the pipeline context and shared state and writes a snapshot to every sink.
+// Sink failures are logged rather than returned, so one broken output does not interrupt the pipeline.
 func (s *FinalStage) Process(ctx *context.PipelineContext, state *store.State) error {
 	// Helper function to safely dereference string pointers.
 	getString := func(s *string) string {
@@ -42,18 +47,28 @@ func (s *FinalStage) Process(ctx *context.PipelineContext, state *store.State) e
 		zap.String("currentKey", getString(ctx.CurrentKey)),
 		zap.String("triad", getString(ctx.Triad)),
 		zap.String("chord", getString(ctx.Chord)),
+		zap.String("chordSymbol", getString(ctx.ChordSymbol)),
 		zap.String("inversion", getString(ctx.Inversion)))
 
 	// Logs the current state with pressed notes and last note time.
-	s.logger.Info(constants.MsgStatePressedNotes, zap.Any("pressedNotes", state.GetPressedNotes()))
+	s.logger.Info(constants.MsgStatePressedNotes,
+		zap.Any("pressedNotes", state.GetPressedNotes()),
+		zap.Any("soundingNotes", state.GetSoundingNotes()),
+		zap.Bool("sustain", state.IsSustainActive()))
 	s.logger.Debug(constants.MsgStateLastNoteTime, zap.Uint64("lastNoteTime", state.GetLastNoteTime()))
 
-	// Placeholder for server communication logic:
-	// err := sendToServer(ctx, state)
-	// if err != nil {
-	//     s.logger.Error("Failed to send data to server", zap.Error(err))
-	//     return err
-	// }
+	// Publishes the processed event to the configured sinks.
+	if len(s.sinks) > 0 {
+		if err := s.sinks.Write(sink.NewSnapshot(ctx, state)); err != nil {
+			s.logger.Error(constants.MsgSinkWriteError, zap.Error(err))
+		}
+	}
 
 	return nil
 }
+
+// Stop flushes and closes the sinks once every event is processed. Sinks posting over the network give up
+// flushing once ctx is done, so closing does not wait on them past the deadline.
+func (s *FinalStage) Stop(ctx stdcontext.Context) error {
+	return errors.Join(s.sinks.FlushContext(ctx), s.sinks.Close())
+}
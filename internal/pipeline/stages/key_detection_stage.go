@@ -0,0 +1,98 @@
+package stages
+
+import (
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"go.uber.org/zap"
+
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+)
+
+// Defaults for key detection.
+const (
+	DefaultKeyHalfLife      = 20 * time.Second // How quickly older notes stop influencing the key.
+	DefaultKeyMinNotes      = 8                // Notes required before a key is reported.
+	DefaultKeyMinConfidence = 0.5              // Minimum correlation for a key to be reported.
+	DefaultKeySwitchMargin  = 0.05             // Correlation advantage a new key needs to replace the current one.
+)
+
+// KeyDetectionStage estimates the tonal center of the performance with a rolling, time-decayed
+// pitch-class profile (Krumhansl-Schmuckler), and flags modulations.
+type KeyDetectionStage struct {
+	logger        *zap.Logger
+	detector      *midi.KeyDetector
+	minNotes      int
+	minConfidence float64
+	switchMargin  float64
+	current       *midi.Key
+}
+
+// NewKeyDetectionStage creates a new instance of KeyDetectionStage with zap logger and default settings.
+func NewKeyDetectionStage(logger *zap.Logger) *KeyDetectionStage {
+	return &KeyDetectionStage{
+		logger:        logger,
+		detector:      midi.NewKeyDetector(DefaultKeyHalfLife),
+		minNotes:      DefaultKeyMinNotes,
+		minConfidence: DefaultKeyMinConfidence,
+		switchMargin:  DefaultKeySwitchMargin,
+	}
+}
+
+// Process feeds note onsets to the detector and writes the detected key into the context.
+// A key change is only reported when the new key beats the current one by the switch margin,
+// which keeps passing chromatic notes from flipping the key back and forth.
+func (s *KeyDetectionStage) Process(ctx *context.PipelineContext, state *store.State) error {
+	event := ctx.MIDIEvent
+	ctx.KeyChanged = false
+
+	if event.Command == byte(contracts.NoteOn) && event.Velocity > 0 {
+		s.detector.AddNote(int(event.Note), event.Velocity, event.Timestamp)
+		s.update(ctx)
+	}
+
+	if s.current != nil {
+		key := *s.current
+		key.Confidence = s.detector.Correlation(key)
+		ctx.Key = &key
+	}
+	return nil
+}
+
+// update re-estimates the key after a new onset.
+func (s *KeyDetectionStage) update(ctx *context.PipelineContext) {
+	if s.detector.Notes() < s.minNotes {
+		return
+	}
+	estimate, ok := s.detector.Estimate()
+	if !ok || estimate.Confidence < s.minConfidence {
+		return
+	}
+
+	if s.current == nil {
+		s.current = &estimate
+		ctx.KeyChanged = true
+		s.logger.Info(constants.MsgKeyDetected,
+			zap.String("key", estimate.String()),
+			zap.Float64("confidence", estimate.Confidence))
+		return
+	}
+
+	if estimate.SameKey(*s.current) {
+		return
+	}
+	if estimate.Confidence < s.detector.Correlation(*s.current)+s.switchMargin {
+		return
+	}
+
+	previous := *s.current
+	s.current = &estimate
+	ctx.KeyChanged = true
+	s.logger.Info(constants.MsgKeyChanged,
+		zap.String("from", previous.String()),
+		zap.String("to", estimate.String()),
+		zap.Float64("confidence", estimate.Confidence))
+}
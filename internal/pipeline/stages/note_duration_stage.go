@@ -0,0 +1,89 @@
+package stages
+
+import (
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"go.uber.org/zap"
+
+	"github.com/leandrodaf/pianalyze/internal/analysis"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+)
+
+// maxRecentOnsets bounds the onset history used to find the note following a released one.
+const maxRecentOnsets = 64
+
+// NoteDurationStage emits a completed-note record, with duration and articulation, for every released note.
+// It must run after NoteStateUpdaterStage, which records the release in the context.
+type NoteDurationStage struct {
+	logger       *zap.Logger
+	thresholds   analysis.ArticulationThresholds
+	chordWindow  time.Duration
+	recentOnsets []uint64
+}
+
+// NewNoteDurationStage creates a new instance of NoteDurationStage with zap logger and default thresholds.
+func NewNoteDurationStage(logger *zap.Logger) *NoteDurationStage {
+	return &NoteDurationStage{
+		logger:      logger,
+		thresholds:  analysis.DefaultArticulationThresholds(),
+		chordWindow: analysis.DefaultChordWindow,
+	}
+}
+
+// Process remembers note onsets and, on release, writes the completed note into the context.
+// The articulation compares the release with the first note that started after this one, ignoring
+// notes struck together with it as part of a chord.
+func (s *NoteDurationStage) Process(ctx *context.PipelineContext, state *store.State) error {
+	event := ctx.MIDIEvent
+	ctx.CompletedNote = nil
+
+	if event.Command == byte(contracts.NoteOn) && event.Velocity > 0 {
+		s.recentOnsets = append(s.recentOnsets, event.Timestamp)
+		if len(s.recentOnsets) > maxRecentOnsets {
+			s.recentOnsets = s.recentOnsets[len(s.recentOnsets)-maxRecentOnsets:]
+		}
+		return nil
+	}
+
+	release := ctx.Release
+	if release == nil {
+		return nil
+	}
+
+	var duration time.Duration
+	if release.ReleasedAt > release.PressedAt {
+		duration = time.Duration(release.ReleasedAt - release.PressedAt)
+	}
+	nextOnset := s.nextOnset(release.PressedAt)
+	completed := analysis.CompletedNote{
+		Note:         release.Note,
+		NoteOn:       release.PressedAt,
+		NoteOff:      release.ReleasedAt,
+		Duration:     duration,
+		Velocity:     release.Velocity,
+		Articulation: analysis.ClassifyArticulation(release.PressedAt, release.ReleasedAt, nextOnset, s.thresholds),
+	}
+	ctx.CompletedNote = &completed
+
+	s.logger.Info(constants.MsgNoteCompleted,
+		zap.String("note", midi.GetNoteName(completed.Note)),
+		zap.Duration("duration", completed.Duration),
+		zap.Int("velocity", int(completed.Velocity)),
+		zap.String("articulation", string(completed.Articulation)))
+	return nil
+}
+
+// nextOnset returns the first onset after the chord window following noteOn, or 0 if there is none.
+func (s *NoteDurationStage) nextOnset(noteOn uint64) uint64 {
+	threshold := noteOn + uint64(s.chordWindow)
+	for _, onset := range s.recentOnsets {
+		if onset > threshold {
+			return onset
+		}
+	}
+	return 0
+}
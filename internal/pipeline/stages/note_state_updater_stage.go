@@ -21,7 +21,7 @@ func NewNoteStateUpdaterStage(logger *zap.Logger) *NoteStateUpdaterStage {
 }
 
 // Process updates the pressed notes state based on the current MIDI event.
-// Handles Note On and Note Off events, adjusting the state and logging key actions.
+// Handles Note On and Note Off events and the sustain pedal (CC64), adjusting the state and logging key actions.
 func (s *NoteStateUpdaterStage) Process(ctx *context.PipelineContext, state *store.State) error {
 	event := ctx.MIDIEvent
 
@@ -29,24 +29,41 @@ func (s *NoteStateUpdaterStage) Process(ctx *context.PipelineContext, state *sto
 	case byte(contracts.NoteOn):
 		if event.Velocity > 0 {
 			// Adds the note to the set of pressed notes.
-			state.AddNote(int(event.Note))
+			state.AddNote(int(event.Note), event.Velocity, event.Timestamp)
 			s.logger.Info(constants.MsgNoteOnDetected,
 				zap.String("note", midi.GetNoteName(int(event.Note))),
 				zap.Int("velocity", int(event.Velocity)),
 				zap.Int("command", int(event.Command)))
 		} else {
 			// Treats NoteOn with Velocity 0 as Note Off.
-			state.RemoveNote(int(event.Note))
+			s.release(ctx, state)
 			s.logger.Debug(constants.MsgNoteOffViaVelocity0,
 				zap.String("note", midi.GetNoteName(int(event.Note))),
 				zap.Int("command", int(event.Command)))
 		}
 	case byte(contracts.NoteOff):
 		// Removes the note from the set of pressed notes.
-		state.RemoveNote(int(event.Note))
+		s.release(ctx, state)
 		s.logger.Info(constants.MsgNoteOffDetected,
 			zap.String("note", midi.GetNoteName(int(event.Note))),
 			zap.Int("command", int(event.Command)))
+	case byte(constants.ControlChangeCommand):
+		// Tracks the damper pedal; other controllers are ignored.
+		if event.Note != constants.SustainPedalController {
+			break
+		}
+		active := event.Velocity >= constants.SustainPedalThreshold
+		if active == state.IsSustainActive() {
+			break
+		}
+		state.SetSustain(active)
+		if active {
+			s.logger.Info(constants.MsgSustainPedalPressed, zap.Int("value", int(event.Velocity)))
+		} else {
+			s.logger.Info(constants.MsgSustainPedalReleased,
+				zap.Int("value", int(event.Velocity)),
+				zap.Any("soundingNotes", state.GetSoundingNotes()))
+		}
 	default:
 		// Logs unsupported MIDI commands for traffic analysis.
 		s.logger.Debug(constants.MsgPipelineContextMIDI,
@@ -57,3 +74,11 @@ func (s *NoteStateUpdaterStage) Process(ctx *context.PipelineContext, state *sto
 
 	return nil
 }
+
+// release removes the note from the state and records when it was pressed in the context.
+func (s *NoteStateUpdaterStage) release(ctx *context.PipelineContext, state *store.State) {
+	release, ok := state.RemoveNote(int(ctx.MIDIEvent.Note), ctx.MIDIEvent.Timestamp)
+	if ok {
+		ctx.Release = &release
+	}
+}
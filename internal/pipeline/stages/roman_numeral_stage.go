@@ -0,0 +1,52 @@
+package stages
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/leandrodaf/pianalyze/internal/analysis"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+)
+
+// RomanNumeralStage analyzes the function of the identified chord as a Roman numeral, relative to a
+// fixed key or, when none is given, to the key detected by KeyDetectionStage.
+type RomanNumeralStage struct {
+	logger *zap.Logger
+	key    *midi.Key
+}
+
+// NewRomanNumeralStage creates a new instance of RomanNumeralStage with zap logger.
+// A nil key analyzes chords in the detected key.
+func NewRomanNumeralStage(logger *zap.Logger, key *midi.Key) *RomanNumeralStage {
+	return &RomanNumeralStage{logger: logger, key: key}
+}
+
+// Process writes the Roman numeral of the current chord into the context. Nothing is written until
+// both a chord and a key are known.
+func (s *RomanNumeralStage) Process(ctx *context.PipelineContext, state *store.State) error {
+	ctx.RomanNumeral = nil
+
+	key := s.key
+	if key == nil {
+		key = ctx.Key
+	}
+	if ctx.ChordMatch == nil || key == nil {
+		return nil
+	}
+
+	numeral, ok := analysis.RomanNumeral(*ctx.ChordMatch, *key)
+	if !ok {
+		return nil
+	}
+	ctx.RomanNumeral = &numeral
+
+	s.logger.Debug(constants.MsgRomanNumeral,
+		zap.String("chord", ctx.ChordMatch.Name),
+		zap.String("key", key.String()),
+		zap.String("numeral", numeral.Symbol),
+		zap.Bool("secondary", numeral.Secondary),
+		zap.Bool("borrowed", numeral.Borrowed))
+	return nil
+}
@@ -0,0 +1,83 @@
+package stages
+
+import (
+	stdcontext "context"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"go.uber.org/zap"
+
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+)
+
+// ScoreFollowerStage aligns the performance to a reference score in real time, reporting the position
+// in the score and wrong, missed and extra notes.
+type ScoreFollowerStage struct {
+	logger   *zap.Logger
+	score    *follower.Score
+	follower *follower.Follower
+	finished bool
+}
+
+// NewScoreFollowerStage creates a new instance of ScoreFollowerStage with zap logger, following score.
+func NewScoreFollowerStage(logger *zap.Logger, score *follower.Score) *ScoreFollowerStage {
+	logger.Info(constants.MsgScoreLoaded,
+		zap.String("title", score.Title),
+		zap.Int("events", len(score.Events)),
+		zap.Int("notes", score.NoteCount()))
+	return &ScoreFollowerStage{
+		logger:   logger,
+		score:    score,
+		follower: follower.New(score),
+	}
+}
+
+// Process aligns every Note On to the score and writes the alignment report into the context.
+func (s *ScoreFollowerStage) Process(ctx *context.PipelineContext, state *store.State) error {
+	event := ctx.MIDIEvent
+	ctx.ScoreReport = nil
+	if event.Command != byte(contracts.NoteOn) || event.Velocity == 0 {
+		return nil
+	}
+
+	report := s.follower.Play(int(event.Note))
+	ctx.ScoreReport = &report
+
+	fields := []zap.Field{
+		zap.String("note", midi.GetNoteName(report.Note)),
+		zap.String("result", string(report.Result)),
+		zap.Int("position", report.Position),
+		zap.Float64("progress", report.Progress),
+	}
+	if len(report.Missed) > 0 {
+		fields = append(fields, zap.Any("missed", report.Missed))
+	}
+	if report.Result == follower.Correct && len(report.Missed) == 0 {
+		s.logger.Debug(constants.MsgScoreNote, fields...)
+	} else {
+		s.logger.Info(constants.MsgScoreNote, fields...)
+	}
+
+	if report.Finished && !s.finished {
+		s.finished = true
+		s.logger.Info(constants.MsgScoreFinished, zap.String("title", s.score.Title))
+	}
+	return nil
+}
+
+// Stop logs the results of the performance. Notes never reached count as missed.
+func (s *ScoreFollowerStage) Stop(_ stdcontext.Context) error {
+	stats := s.follower.Stats()
+	stats.Missed += s.follower.Remaining()
+	s.logger.Info(constants.MsgScoreSummary,
+		zap.String("title", s.score.Title),
+		zap.Int("correct", stats.Correct),
+		zap.Int("wrong", stats.Wrong),
+		zap.Int("missed", stats.Missed),
+		zap.Int("extra", stats.Extra),
+		zap.Float64("accuracy", stats.Accuracy()))
+	return nil
+}
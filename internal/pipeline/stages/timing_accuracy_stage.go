@@ -0,0 +1,62 @@
+package stages
+
+import (
+	stdcontext "context"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"go.uber.org/zap"
+
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+)
+
+// TimingAccuracyStage scores every onset against a metronome grid, so students can see whether they
+// are rushing or dragging. The grid starts at the first onset of the session.
+type TimingAccuracyStage struct {
+	logger *zap.Logger
+	scorer *rhythm.Scorer
+}
+
+// NewTimingAccuracyStage creates a new instance of TimingAccuracyStage with zap logger, scoring onsets
+// against a grid of subdivision points per beat at bpm. Errors up to tolerance count as on time.
+func NewTimingAccuracyStage(logger *zap.Logger, bpm float64, subdivision int, tolerance time.Duration) *TimingAccuracyStage {
+	return &TimingAccuracyStage{
+		logger: logger,
+		scorer: rhythm.NewScorer(rhythm.Grid{BPM: bpm, Subdivision: subdivision}, tolerance),
+	}
+}
+
+// Process quantizes Note On events to the grid and writes the timing error into the context.
+func (s *TimingAccuracyStage) Process(ctx *context.PipelineContext, state *store.State) error {
+	event := ctx.MIDIEvent
+	ctx.Timing = nil
+	if event.Command != byte(contracts.NoteOn) || event.Velocity == 0 {
+		return nil
+	}
+
+	timing := s.scorer.Score(event.Timestamp)
+	ctx.Timing = &timing
+
+	s.logger.Debug(constants.MsgTimingScored,
+		zap.Int64("gridIndex", timing.GridIndex),
+		zap.Float64("errorMs", timing.ErrorMs),
+		zap.String("verdict", timing.Verdict))
+	return nil
+}
+
+// Stop logs the timing accuracy of the session.
+func (s *TimingAccuracyStage) Stop(_ stdcontext.Context) error {
+	stats := s.scorer.Stats()
+	if stats.Onsets == 0 {
+		return nil
+	}
+	s.logger.Info(constants.MsgTimingSummary,
+		zap.Int("onsets", stats.Onsets),
+		zap.Float64("meanErrorMs", stats.MeanMs),
+		zap.Float64("stdDevMs", stats.StdDevMs),
+		zap.Float64("withinTolerancePercent", stats.WithinPercent))
+	return nil
+}
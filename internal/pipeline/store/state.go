@@ -4,44 +4,124 @@ import (
 	"sync"
 )
 
+// NotePress registra quando e com que intensidade uma nota foi pressionada.
+type NotePress struct {
+	Timestamp uint64 // Momento em que a nota foi pressionada (Unix nanossegundos)
+	Velocity  byte   // Velocidade do Note On
+}
+
+// NoteRelease descreve uma nota que acabou de ser solta.
+type NoteRelease struct {
+	Note       int
+	Velocity   byte
+	PressedAt  uint64 // Momento do Note On (Unix nanossegundos)
+	ReleasedAt uint64 // Momento do Note Off (Unix nanossegundos)
+}
+
 // State mantém o estado compartilhado do pipeline, como notas pressionadas.
 type State struct {
-	mu           sync.RWMutex
-	PressedNotes []int // Slice para manter a ordem das notas pressionadas
-	LastNoteTime uint64
+	mu            sync.RWMutex
+	PressedNotes  []int             // Slice para manter a ordem das notas pressionadas
+	SoundingNotes []int             // Notas que ainda soam: pressionadas ou sustentadas pelo pedal
+	Presses       map[int]NotePress // Momento e velocidade de cada nota pressionada
+	SustainActive bool              // Indica se o pedal de sustentação (CC64) está pressionado
+	LastNoteTime  uint64
+	LastOnset     uint64 // Momento do último Note On
 }
 
 // NewPipelineState inicializa o estado do pipeline.
 func NewPipelineState() *State {
 	return &State{
-		PressedNotes: []int{},
+		PressedNotes:  []int{},
+		SoundingNotes: []int{},
+		Presses:       make(map[int]NotePress),
 	}
 }
 
-// AddNote adiciona uma nota pressionada.
-func (ps *State) AddNote(note int) {
+// AddNote adiciona uma nota pressionada, que também passa a soar, registrando o momento e a velocidade.
+// Uma nota repetida sem Note Off intermediário mantém o registro original.
+func (ps *State) AddNote(note int, velocity byte, timestamp uint64) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
-	// Verifica se a nota já está pressionada para evitar duplicações
-	for _, n := range ps.PressedNotes {
-		if n == note {
-			return
-		}
+	ps.PressedNotes = appendUnique(ps.PressedNotes, note)
+	ps.SoundingNotes = appendUnique(ps.SoundingNotes, note)
+	if _, exists := ps.Presses[note]; !exists {
+		ps.Presses[note] = NotePress{Timestamp: timestamp, Velocity: velocity}
 	}
-	ps.PressedNotes = append(ps.PressedNotes, note)
+	ps.LastOnset = timestamp
 }
 
-// RemoveNote remove uma nota que foi solta.
-func (ps *State) RemoveNote(note int) {
+// RemoveNote remove uma nota que foi solta e retorna quando ela foi pressionada.
+// Retorna false se a nota não estava pressionada.
+// Com o pedal de sustentação pressionado, a nota continua soando até o pedal ser solto.
+func (ps *State) RemoveNote(note int, timestamp uint64) (NoteRelease, bool) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
-	for i, n := range ps.PressedNotes {
-		if n == note {
-			// Remove a nota mantendo a ordem
-			ps.PressedNotes = append(ps.PressedNotes[:i], ps.PressedNotes[i+1:]...)
-			break
+	ps.PressedNotes = removeNote(ps.PressedNotes, note)
+	if !ps.SustainActive {
+		ps.SoundingNotes = removeNote(ps.SoundingNotes, note)
+	}
+
+	press, exists := ps.Presses[note]
+	if !exists {
+		return NoteRelease{}, false
+	}
+	delete(ps.Presses, note)
+	return NoteRelease{
+		Note:       note,
+		Velocity:   press.Velocity,
+		PressedAt:  press.Timestamp,
+		ReleasedAt: timestamp,
+	}, true
+}
+
+// GetNotePress retorna o momento e a velocidade de uma nota pressionada.
+func (ps *State) GetNotePress(note int) (NotePress, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	press, exists := ps.Presses[note]
+	return press, exists
+}
+
+// GetLastOnset retorna o momento do último Note On.
+func (ps *State) GetLastOnset() uint64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.LastOnset
+}
+
+// SetSustain atualiza o estado do pedal de sustentação.
+// Ao soltar o pedal, apenas as notas ainda pressionadas continuam soando.
+func (ps *State) SetSustain(active bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.SustainActive = active
+	if active {
+		return
+	}
+	sounding := ps.SoundingNotes[:0]
+	for _, n := range ps.SoundingNotes {
+		if containsNote(ps.PressedNotes, n) {
+			sounding = append(sounding, n)
 		}
 	}
+	ps.SoundingNotes = sounding
+}
+
+// IsSustainActive indica se o pedal de sustentação está pressionado.
+func (ps *State) IsSustainActive() bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.SustainActive
+}
+
+// GetSoundingNotes retorna uma cópia das notas que estão soando, incluindo as sustentadas pelo pedal.
+func (ps *State) GetSoundingNotes() []int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	notesCopy := make([]int, len(ps.SoundingNotes))
+	copy(notesCopy, ps.SoundingNotes)
+	return notesCopy
 }
 
 // GetPressedNotes retorna uma cópia das notas atualmente pressionadas.
@@ -68,3 +148,31 @@ func (ps *State) GetLastNoteTime() uint64 {
 	defer ps.mu.RUnlock()
 	return ps.LastNoteTime
 }
+
+// appendUnique adiciona a nota ao slice caso ainda não esteja presente, evitando duplicações.
+func appendUnique(notes []int, note int) []int {
+	if containsNote(notes, note) {
+		return notes
+	}
+	return append(notes, note)
+}
+
+// removeNote remove a nota do slice mantendo a ordem.
+func removeNote(notes []int, note int) []int {
+	for i, n := range notes {
+		if n == note {
+			return append(notes[:i], notes[i+1:]...)
+		}
+	}
+	return notes
+}
+
+// containsNote verifica se a nota está presente no slice.
+func containsNote(notes []int, note int) bool {
+	for _, n := range notes {
+		if n == note {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package progress
+
+import (
+	"sync"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+// Session is the summary of a practice session as stored in a profile.
+type Session struct {
+	Start     time.Time        `json:"start"`
+	End       time.Time        `json:"end"`
+	Practiced time.Duration    `json:"practiced"` // Time from the first to the last note played.
+	Notes     int              `json:"notes"`     // Notes played.
+	Chords    int              `json:"chords"`    // Chords recognized, counting each change of chord once.
+	Exercise  *ExerciseSummary `json:"exercise,omitempty"`
+}
+
+// ExerciseSummary is the outcome of the lesson or drill run in a session.
+type ExerciseSummary struct {
+	Name     string `json:"name"`
+	Prompts  int    `json:"prompts"`  // Steps or prompts reached.
+	Passed   int    `json:"passed"`   // Steps or prompts passed.
+	FirstTry int    `json:"firstTry"` // Steps or prompts passed on the first attempt.
+}
+
+// Tracker is a sink that counts what is played during a session.
+type Tracker struct {
+	mu        sync.Mutex
+	start     time.Time
+	first     uint64
+	last      uint64
+	notes     int
+	chords    int
+	lastChord string
+}
+
+// NewTracker creates a tracker for a session starting now.
+func NewTracker() *Tracker {
+	return &Tracker{start: time.Now()}
+}
+
+// Write counts the notes played and the chords recognized.
+func (t *Tracker) Write(snapshot *sink.Snapshot) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if snapshot.Command != byte(contracts.NoteOn) || snapshot.Velocity == 0 {
+		if len(snapshot.PressedNotes) == 0 {
+			t.lastChord = ""
+		}
+		return nil
+	}
+
+	if t.first == 0 {
+		t.first = snapshot.Timestamp
+	}
+	t.last = snapshot.Timestamp
+	t.notes++
+	if snapshot.ChordSymbol != "" && snapshot.ChordSymbol != t.lastChord {
+		t.chords++
+	}
+	t.lastChord = snapshot.ChordSymbol
+	return nil
+}
+
+// Flush does nothing; the session is read with Session.
+func (t *Tracker) Flush() error {
+	return nil
+}
+
+// Close does nothing; the session is read with Session.
+func (t *Tracker) Close() error {
+	return nil
+}
+
+// Session returns the session played so far, ending now.
+func (t *Tracker) Session() Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Session{
+		Start:     t.start,
+		End:       time.Now(),
+		Practiced: time.Duration(t.last - t.first),
+		Notes:     t.notes,
+		Chords:    t.chords,
+	}
+}
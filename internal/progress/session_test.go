@@ -0,0 +1,38 @@
+package progress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+)
+
+func TestTracker(t *testing.T) {
+	on := func(at time.Duration, symbol string, pressed ...int) *sink.Snapshot {
+		return &sink.Snapshot{Command: byte(contracts.NoteOn), Velocity: 80, Timestamp: uint64(time.Second + at), ChordSymbol: symbol, PressedNotes: pressed}
+	}
+	off := func(pressed ...int) *sink.Snapshot {
+		return &sink.Snapshot{Command: byte(contracts.NoteOff), PressedNotes: pressed}
+	}
+
+	tracker := NewTracker()
+	for _, snapshot := range []*sink.Snapshot{
+		on(0, "", 60),
+		on(time.Second, "C", 60, 64, 67),
+		on(2*time.Second, "C", 60, 64, 67, 72), // Same chord held: counted once.
+		off(60, 64, 67),
+		off(),
+		on(3*time.Second, "C", 60, 64, 67), // Played again after a release.
+		{Command: byte(contracts.NoteOn), Velocity: 0, Timestamp: uint64(time.Hour)},
+	} {
+		if err := tracker.Write(snapshot); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	session := tracker.Session()
+	if session.Notes != 4 || session.Chords != 2 || session.Practiced != 3*time.Second {
+		t.Errorf("session = %d notes, %d chords, %v practiced, want 4, 2 and 3s", session.Notes, session.Chords, session.Practiced)
+	}
+}
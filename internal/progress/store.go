@@ -0,0 +1,147 @@
+package progress
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidProfile is returned for profile names that cannot be used as a directory name.
+var ErrInvalidProfile = errors.New("invalid profile name")
+
+// sessionsFile is the append-only log of sessions in a profile directory, one JSON object per line.
+const sessionsFile = "sessions.jsonl"
+
+// Store keeps the practice sessions of every profile under a directory, one subdirectory per profile.
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the default store directory, pianalyze/profiles under the user configuration directory.
+func DefaultDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "pianalyze", "profiles"), nil
+}
+
+// Open opens the store at dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Append records a session in profile.
+func (s *Store) Append(profile string, session Session) error {
+	dir, err := s.ProfileDir(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, sessionsFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Sessions returns the sessions of profile that started in [from, to), oldest first. A zero from or to
+// leaves that end of the range open. A profile without sessions has none.
+func (s *Store) Sessions(profile string, from, to time.Time) ([]Session, error) {
+	dir, err := s.ProfileDir(profile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(dir, sessionsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sessions []Session
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal(scanner.Bytes(), &session); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", f.Name(), line, err)
+		}
+		if (!from.IsZero() && session.Start.Before(from)) || (!to.IsZero() && !session.Start.Before(to)) {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+	return sessions, nil
+}
+
+// Profiles returns the names of the profiles in the store, in alphabetical order.
+func (s *Store) Profiles() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	return profiles, nil
+}
+
+// Progress summarizes the sessions of profile per period, for every period from the one containing from to
+// the one containing to, including periods without practice.
+func (s *Store) Progress(profile string, period Period, from, to time.Time) ([]Summary, error) {
+	first := period.Start(from)
+	sessions, err := s.Sessions(profile, first, period.Next(period.Start(to)))
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(sessions, period, from, to), nil
+}
+
+// ProfileDir returns the directory of profile, rejecting names that are not a single path element.
+// Other per-profile data is kept next to the sessions.
+func (s *Store) ProfileDir(profile string) (string, error) {
+	if profile == "" || profile == "." || profile == ".." || strings.ContainsAny(profile, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidProfile, profile)
+	}
+	return filepath.Join(s.dir, profile), nil
+}
@@ -0,0 +1,101 @@
+package progress
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// session returns an hour-long session starting at start with notes played.
+func session(start time.Time, notes int) Session {
+	return Session{Start: start, End: start.Add(time.Hour), Practiced: 45 * time.Minute, Notes: notes, Chords: notes / 10}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "profiles"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	monday := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	tuesday, wednesday := monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 2)
+	exercise := session(tuesday, 120)
+	exercise.Exercise = &ExerciseSummary{Name: "Triads", Prompts: 8, Passed: 7, FirstTry: 5}
+	// Sessions are returned oldest first, whatever the order they were appended in.
+	for _, s := range []Session{session(wednesday, 300), session(monday, 100), exercise} {
+		if err := store.Append("ana", s); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if err := store.Append("bob", session(monday, 10)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []Session
+	}{
+		{name: "every session", want: []Session{session(monday, 100), exercise, session(wednesday, 300)}},
+		{name: "from", from: tuesday, want: []Session{exercise, session(wednesday, 300)}},
+		{name: "to is excluded", to: wednesday, want: []Session{session(monday, 100), exercise}},
+		{name: "range", from: tuesday, to: wednesday, want: []Session{exercise}},
+		{name: "empty range", from: wednesday.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Sessions("ana", tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Sessions: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sessions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if profiles, err := store.Profiles(); err != nil || !reflect.DeepEqual(profiles, []string{"ana", "bob"}) {
+		t.Errorf("Profiles() = %v, %v, want ana and bob", profiles, err)
+	}
+	if sessions, err := store.Sessions("carol", time.Time{}, time.Time{}); err != nil || sessions != nil {
+		t.Errorf("Sessions of a new profile = %v, %v, want none", sessions, err)
+	}
+}
+
+func TestStoreInvalidProfile(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, profile := range []string{"", ".", "..", "../ana", `a\b`} {
+		if err := store.Append(profile, Session{}); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("Append(%q) = %v, want ErrInvalidProfile", profile, err)
+		}
+		if _, err := store.Sessions(profile, time.Time{}, time.Time{}); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("Sessions(%q) = %v, want ErrInvalidProfile", profile, err)
+		}
+	}
+}
+
+func TestStoreCorruptSessions(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := store.Append("ana", session(time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC), 1)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	dir, _ := store.ProfileDir("ana")
+	f, err := os.OpenFile(filepath.Join(dir, sessionsFile), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("\n{\"start\": \n")
+	_ = f.Close()
+
+	if _, err := store.Sessions("ana", time.Time{}, time.Time{}); err == nil {
+		t.Error("Sessions() succeeded on a corrupt line")
+	}
+}
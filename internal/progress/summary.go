@@ -0,0 +1,76 @@
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Period is the span sessions are grouped by in a progress report.
+type Period string
+
+// Report periods.
+const (
+	Day  Period = "day"
+	Week Period = "week" // Weeks start on Monday.
+)
+
+// ParsePeriod parses a period name ("day" or "week").
+func ParsePeriod(name string) (Period, error) {
+	switch period := Period(strings.ToLower(strings.TrimSpace(name))); period {
+	case Day, Week:
+		return period, nil
+	default:
+		return "", fmt.Errorf("invalid period %q (want day or week)", name)
+	}
+}
+
+// Start returns the start of the period containing t, in the location of t.
+func (p Period) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if p == Week {
+		// Go weeks start on Sunday; move back to Monday.
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+// Next returns the start of the period following the one starting at start.
+func (p Period) Next(start time.Time) time.Time {
+	if p == Week {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Summary totals the sessions of a period.
+type Summary struct {
+	Start     time.Time     `json:"start"` // Start of the period.
+	Sessions  int           `json:"sessions"`
+	Practiced time.Duration `json:"practiced"`
+	Notes     int           `json:"notes"`
+	Chords    int           `json:"chords"`
+}
+
+// Summarize totals sessions per period for every period from the one containing from to the one containing to.
+func Summarize(sessions []Session, period Period, from, to time.Time) []Summary {
+	var summaries []Summary
+	index := make(map[time.Time]int)
+	for start := period.Start(from); !start.After(to); start = period.Next(start) {
+		index[start] = len(summaries)
+		summaries = append(summaries, Summary{Start: start})
+	}
+
+	for _, session := range sessions {
+		i, ok := index[period.Start(session.Start.In(from.Location()))]
+		if !ok {
+			continue
+		}
+		summary := &summaries[i]
+		summary.Sessions++
+		summary.Practiced += session.Practiced
+		summary.Notes += session.Notes
+		summary.Chords += session.Chords
+	}
+	return summaries
+}
@@ -0,0 +1,67 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	sunday := time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		period Period
+		t      time.Time
+		want   time.Time
+	}{
+		{Day, sunday, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{Week, sunday, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{Week, time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{Week, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tt.period.Start(tt.t); !got.Equal(tt.want) {
+			t.Errorf("%s.Start(%v) = %v, want %v", tt.period, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	for name, want := range map[string]Period{"day": Day, " Week ": Week} {
+		if got, err := ParsePeriod(name); err != nil || got != want {
+			t.Errorf("ParsePeriod(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParsePeriod("month"); err == nil {
+		t.Error("ParsePeriod(month) succeeded")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		session(monday, 100),
+		session(monday.Add(2*time.Hour), 50),
+		session(monday.AddDate(0, 0, 2), 300),
+		session(monday.AddDate(0, 0, 7), 10),
+		session(monday.AddDate(0, 0, -1), 999), // Before the report.
+	}
+
+	days := Summarize(sessions, Day, monday, monday.AddDate(0, 0, 2))
+	if len(days) != 3 {
+		t.Fatalf("%d days, want 3", len(days))
+	}
+	for i, want := range []Summary{
+		{Sessions: 2, Practiced: 90 * time.Minute, Notes: 150, Chords: 15},
+		{},
+		{Sessions: 1, Practiced: 45 * time.Minute, Notes: 300, Chords: 30},
+	} {
+		want.Start = Day.Start(monday).AddDate(0, 0, i)
+		if days[i] != want {
+			t.Errorf("day %d = %+v, want %+v", i, days[i], want)
+		}
+	}
+
+	weeks := Summarize(sessions, Week, monday.AddDate(0, 0, 3), monday.AddDate(0, 0, 7))
+	if len(weeks) != 2 || weeks[0].Sessions != 3 || weeks[0].Notes != 450 || weeks[1].Sessions != 1 {
+		t.Errorf("weeks = %+v, want 3 sessions then 1", weeks)
+	}
+}
@@ -0,0 +1,115 @@
+package rhythm
+
+import (
+	"math"
+	"time"
+)
+
+// Defaults for rhythmic accuracy scoring.
+const (
+	DefaultSubdivision     = 1                     // Grid points per beat (1 = quarter notes in 4/4).
+	DefaultTimingTolerance = 30 * time.Millisecond // Largest error still counted as on time.
+)
+
+// Timing verdicts for a scored onset.
+const (
+	VerdictOnTime = "on time"
+	VerdictEarly  = "early" // Rushing: the onset came before the grid point.
+	VerdictLate   = "late"  // Dragging: the onset came after the grid point.
+)
+
+// Grid is a metronome grid at a fixed tempo, divided into equal subdivisions of the beat.
+type Grid struct {
+	BPM         float64
+	Subdivision int
+	Origin      uint64 // Time of grid point 0, in Unix nanoseconds.
+}
+
+// Step returns the time between two grid points.
+func (g Grid) Step() time.Duration {
+	subdivision := g.Subdivision
+	if subdivision < 1 {
+		subdivision = 1
+	}
+	return time.Duration(float64(time.Minute) / g.BPM / float64(subdivision))
+}
+
+// Quantize returns the index of the grid point nearest to timestamp (Unix nanoseconds) and the signed
+// error of the timestamp relative to it; negative errors are early, positive errors are late.
+func (g Grid) Quantize(timestamp uint64) (int64, time.Duration) {
+	step := float64(g.Step())
+	elapsed := float64(int64(timestamp - g.Origin))
+	index := math.Round(elapsed / step)
+	return int64(index), time.Duration(elapsed - index*step)
+}
+
+// Timing is the timing error of an onset against the grid.
+type Timing struct {
+	GridIndex int64   `json:"gridIndex"` // Nearest grid point, counted from the first onset.
+	ErrorMs   float64 `json:"errorMs"`   // Signed error in milliseconds; negative is early, positive is late.
+	Verdict   string  `json:"verdict"`   // "on time", "early" or "late".
+}
+
+// AccuracyStats summarizes timing errors over a session.
+type AccuracyStats struct {
+	Onsets        int
+	MeanMs        float64 // Mean signed error; negative means rushing overall, positive dragging.
+	StdDevMs      float64 // Spread of the errors around the mean.
+	WithinPercent float64 // Percentage of onsets within the tolerance.
+}
+
+// Scorer quantizes onsets to a metronome grid and accumulates accuracy statistics.
+// When the grid has no origin, the first onset scored becomes grid point 0.
+type Scorer struct {
+	grid      Grid
+	tolerance time.Duration
+	onsets    int
+	within    int
+	sum       float64
+	sumSq     float64
+}
+
+// NewScorer creates a scorer for the grid, counting errors up to tolerance as on time.
+func NewScorer(grid Grid, tolerance time.Duration) *Scorer {
+	return &Scorer{grid: grid, tolerance: tolerance}
+}
+
+// Score quantizes an onset at timestamp (Unix nanoseconds) and records its error.
+func (s *Scorer) Score(timestamp uint64) Timing {
+	if s.grid.Origin == 0 {
+		s.grid.Origin = timestamp
+	}
+	index, offset := s.grid.Quantize(timestamp)
+	errorMs := float64(offset) / float64(time.Millisecond)
+
+	s.onsets++
+	s.sum += errorMs
+	s.sumSq += errorMs * errorMs
+
+	verdict := VerdictOnTime
+	switch {
+	case offset < -s.tolerance:
+		verdict = VerdictEarly
+	case offset > s.tolerance:
+		verdict = VerdictLate
+	default:
+		s.within++
+	}
+	return Timing{GridIndex: index, ErrorMs: errorMs, Verdict: verdict}
+}
+
+// Stats returns the accuracy statistics of the onsets scored so far.
+func (s *Scorer) Stats() AccuracyStats {
+	if s.onsets == 0 {
+		return AccuracyStats{}
+	}
+	n := float64(s.onsets)
+	mean := s.sum / n
+	variance := math.Max(s.sumSq/n-mean*mean, 0)
+	return AccuracyStats{
+		Onsets:        s.onsets,
+		MeanMs:        mean,
+		StdDevMs:      math.Sqrt(variance),
+		WithinPercent: float64(s.within) / n * 100,
+	}
+}
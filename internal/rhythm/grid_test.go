@@ -0,0 +1,60 @@
+package rhythm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGridQuantize(t *testing.T) {
+	grid := Grid{BPM: 120, Subdivision: 2, Origin: 1_000_000_000}
+	if step := grid.Step(); step != 250*time.Millisecond {
+		t.Fatalf("Step() = %v, want 250ms", step)
+	}
+	tests := []struct {
+		offset    time.Duration
+		wantIndex int64
+		wantError time.Duration
+	}{
+		{0, 0, 0},
+		{250 * time.Millisecond, 1, 0},
+		{260 * time.Millisecond, 1, 10 * time.Millisecond},
+		{740 * time.Millisecond, 3, -10 * time.Millisecond},
+		{-20 * time.Millisecond, 0, -20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		index, err := grid.Quantize(uint64(int64(grid.Origin) + int64(tt.offset)))
+		if index != tt.wantIndex || err != tt.wantError {
+			t.Errorf("Quantize(origin%+v) = %d, %v; want %d, %v", tt.offset, index, err, tt.wantIndex, tt.wantError)
+		}
+	}
+}
+
+func TestScorer(t *testing.T) {
+	scorer := NewScorer(Grid{BPM: 60, Subdivision: 1}, DefaultTimingTolerance)
+	start := uint64(5 * time.Second)
+	tests := []struct {
+		offset      time.Duration
+		wantIndex   int64
+		wantVerdict string
+	}{
+		{0, 0, VerdictOnTime}, // The first onset becomes the origin of the grid.
+		{time.Second + 20*time.Millisecond, 1, VerdictOnTime},
+		{2*time.Second - 50*time.Millisecond, 2, VerdictEarly},
+		{3*time.Second + 80*time.Millisecond, 3, VerdictLate},
+	}
+	for _, tt := range tests {
+		timing := scorer.Score(start + uint64(tt.offset))
+		if timing.GridIndex != tt.wantIndex || timing.Verdict != tt.wantVerdict {
+			t.Errorf("Score(+%v) = %+v, want grid point %d %s", tt.offset, timing, tt.wantIndex, tt.wantVerdict)
+		}
+	}
+
+	stats := scorer.Stats()
+	if stats.Onsets != 4 || stats.WithinPercent != 50 {
+		t.Errorf("Stats() = %+v, want 4 onsets, 50%% within", stats)
+	}
+	if want := (0 + 20 - 50 + 80) / 4.0; math.Abs(stats.MeanMs-want) > 1e-6 {
+		t.Errorf("MeanMs = %v, want %v", stats.MeanMs, want)
+	}
+}
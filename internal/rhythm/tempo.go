@@ -0,0 +1,107 @@
+package rhythm
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Tempo limits and tolerances used when estimating the beat period from note onsets.
+const (
+	DefaultMinBPM      = 50.0                    // Slowest tempo reported; slower estimates are doubled.
+	DefaultMaxBPM      = 200.0                   // Fastest tempo reported; faster estimates are halved.
+	clusterWidth       = 25 * time.Millisecond   // Inter-onset intervals closer than this share a cluster.
+	minInterOnset      = 70 * time.Millisecond   // Shorter intervals are ornaments, not rhythm.
+	maxInterOnset      = 2500 * time.Millisecond // Longer intervals are pauses, not rhythm.
+	maxOnsetDistance   = 4                       // Intervals are measured up to this many onsets apart.
+	relationTolerance  = 0.08                    // Relative tolerance for integer ratios between clusters.
+	maxRelatedMultiple = 4                       // Largest integer ratio between related clusters.
+)
+
+// interOnsetCluster groups similar inter-onset intervals.
+type interOnsetCluster struct {
+	sum   float64 // Sum of the intervals, in nanoseconds.
+	count int
+}
+
+// mean returns the average interval of the cluster, in nanoseconds.
+func (c interOnsetCluster) mean() float64 {
+	return c.sum / float64(c.count)
+}
+
+// EstimatePeriod estimates the beat period from onset times (Unix nanoseconds, ascending) by clustering
+// the intervals between nearby onsets. Each cluster is scored by its size plus the sizes of clusters at
+// integer multiples of its interval, so the period that best explains the whole rhythm wins over a single
+// frequent subdivision. The result is folded into the [minBPM, maxBPM] range.
+// Returns false when there are not enough onsets to find a regular interval.
+func EstimatePeriod(onsets []uint64, minBPM, maxBPM float64) (time.Duration, bool) {
+	var intervals []float64
+	for i := range onsets {
+		for j := i + 1; j < len(onsets) && j <= i+maxOnsetDistance; j++ {
+			d := time.Duration(onsets[j] - onsets[i])
+			if d >= minInterOnset && d <= maxInterOnset {
+				intervals = append(intervals, float64(d))
+			}
+		}
+	}
+	if len(intervals) < 2 {
+		return 0, false
+	}
+	sort.Float64s(intervals)
+
+	var clusters []interOnsetCluster
+	for _, interval := range intervals {
+		if n := len(clusters); n > 0 && interval-clusters[n-1].mean() < float64(clusterWidth) {
+			clusters[n-1].sum += interval
+			clusters[n-1].count++
+			continue
+		}
+		clusters = append(clusters, interOnsetCluster{sum: interval, count: 1})
+	}
+
+	best, bestScore := -1, 0.0
+	for i, cluster := range clusters {
+		score := float64(cluster.count)
+		for j, other := range clusters {
+			if i == j {
+				continue
+			}
+			ratio := other.mean() / cluster.mean()
+			multiple := math.Round(ratio)
+			if multiple < 2 || multiple > maxRelatedMultiple || math.Abs(ratio-multiple) > relationTolerance*multiple {
+				continue
+			}
+			score += float64(other.count) * (maxRelatedMultiple + 1 - multiple) / maxRelatedMultiple
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	// A single interval seen once is not yet a rhythm.
+	if best < 0 || bestScore < 2 {
+		return 0, false
+	}
+
+	return foldPeriod(clusters[best].mean(), minBPM, maxBPM), true
+}
+
+// foldPeriod doubles or halves a period (in nanoseconds) until its tempo lies in [minBPM, maxBPM].
+func foldPeriod(period, minBPM, maxBPM float64) time.Duration {
+	minPeriod := float64(time.Minute) / maxBPM
+	maxPeriod := float64(time.Minute) / minBPM
+	for period < minPeriod {
+		period *= 2
+	}
+	for period > maxPeriod {
+		period /= 2
+	}
+	return time.Duration(period)
+}
+
+// BPM converts a beat period to beats per minute.
+func BPM(period time.Duration) float64 {
+	if period <= 0 {
+		return 0
+	}
+	return float64(time.Minute) / float64(period)
+}
@@ -0,0 +1,79 @@
+package rhythm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// onsetsAt returns onsets starting at 1s, separated by the given intervals.
+func onsetsAt(intervals ...time.Duration) []uint64 {
+	onsets := []uint64{uint64(time.Second)}
+	for _, interval := range intervals {
+		onsets = append(onsets, onsets[len(onsets)-1]+uint64(interval))
+	}
+	return onsets
+}
+
+// repeat returns interval n times.
+func repeat(interval time.Duration, n int) []time.Duration {
+	intervals := make([]time.Duration, n)
+	for i := range intervals {
+		intervals[i] = interval
+	}
+	return intervals
+}
+
+func TestEstimatePeriod(t *testing.T) {
+	q := 500 * time.Millisecond // A quarter note at 120 BPM.
+	tests := []struct {
+		name    string
+		onsets  []uint64
+		wantBPM float64
+		wantOK  bool
+	}{
+		{"steady quarters", onsetsAt(repeat(q, 8)...), 120, true},
+		{"quarters and eighths", onsetsAt(q, q/2, q/2, q, q, q/2, q/2, q), 120, true},
+		{"slow half notes fold into range", onsetsAt(repeat(2*time.Second, 6)...), 60, true},
+		{"fast notes fold into range", onsetsAt(repeat(100*time.Millisecond, 16)...), 150, true},
+		{"too few onsets", onsetsAt(q), 0, false},
+		{"only pauses", onsetsAt(repeat(5*time.Second, 4)...), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, ok := EstimatePeriod(tt.onsets, DefaultMinBPM, DefaultMaxBPM)
+			if ok != tt.wantOK {
+				t.Fatalf("EstimatePeriod() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if bpm := BPM(period); ok && math.Abs(bpm-tt.wantBPM) > 1 {
+				t.Errorf("EstimatePeriod() = %.1f BPM, want %.0f", bpm, tt.wantBPM)
+			}
+		})
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	start := uint64(time.Second)
+	var last Beat
+	for i := 0; i < 12; i++ {
+		onset := start + uint64(i)*uint64(500*time.Millisecond)
+		beat, ok := tracker.AddOnset(onset)
+		if i < DefaultMinOnsets-1 && ok {
+			t.Fatalf("onset %d: beat reported before the tempo is known", i)
+		}
+		// A chord note right after the onset is not counted again.
+		if _, ok := tracker.AddOnset(onset + uint64(10*time.Millisecond)); ok {
+			t.Fatalf("onset %d: chord note counted as an onset", i)
+		}
+		if ok {
+			last = beat
+		}
+	}
+	if math.Abs(last.BPM-120) > 1 || last.Bar != 3 || last.Beat != 4 || math.Abs(last.Phase) > 0.05 {
+		t.Errorf("last beat = %+v, want bar 3 beat 4 at 120 BPM", last)
+	}
+	if stats := tracker.Stats(); math.Abs(stats.Drift()) > 1 {
+		t.Errorf("drift = %.1f BPM over a steady tempo", stats.Drift())
+	}
+}
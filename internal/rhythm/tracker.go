@@ -0,0 +1,187 @@
+package rhythm
+
+import (
+	"math"
+	"time"
+)
+
+// Defaults for beat tracking.
+const (
+	DefaultBeatsPerBar  = 4
+	DefaultChordWindow  = 30 * time.Millisecond // Onsets closer than this to the previous one belong to the same chord.
+	DefaultOnsetHistory = 8 * time.Second       // Onsets older than this no longer influence the tempo.
+	DefaultMinOnsets    = 4                     // Onsets required before a tempo is reported.
+	tempoSmoothing      = 0.2                   // Weight of a new tempo estimate against the current one.
+	phaseCorrection     = 0.3                   // Fraction of the timing error used to realign the beat grid.
+	periodCorrection    = 0.15                  // Fraction of the timing error, per beat, used to adjust the period.
+	onBeatTolerance     = 0.25                  // Largest timing error, in beats, of an onset played on the beat.
+	maxTempoChange      = 0.3                   // Relative change above which an estimate is treated as a new tempo.
+)
+
+// Beat is the metrical position of an onset.
+type Beat struct {
+	BPM   float64 `json:"bpm"`   // Current tempo estimate.
+	Bar   int     `json:"bar"`   // Bar number, from 1.
+	Beat  int     `json:"beat"`  // Beat within the bar, from 1.
+	Phase float64 `json:"phase"` // Offset of the onset from the nearest beat, in beats (-0.5 to 0.5).
+	Drift float64 `json:"drift"` // Tempo change since the first estimate of the session, in BPM.
+}
+
+// DriftStats summarizes how the tempo changed over a session.
+type DriftStats struct {
+	InitialBPM float64
+	CurrentBPM float64
+	MinBPM     float64
+	MaxBPM     float64
+	MeanBPM    float64
+	Estimates  int
+}
+
+// Drift returns the tempo change since the first estimate, in BPM.
+func (s DriftStats) Drift() float64 {
+	return s.CurrentBPM - s.InitialBPM
+}
+
+// Tracker estimates tempo and beat positions from a stream of note onsets.
+// The tempo comes from EstimatePeriod over a sliding window of onsets; the beat grid is anchored at the
+// first onset, advanced by the current period and nudged towards each onset that lands near a beat.
+type Tracker struct {
+	BeatsPerBar int
+	ChordWindow time.Duration
+	History     time.Duration
+	MinOnsets   int
+	MinBPM      float64
+	MaxBPM      float64
+
+	onsets    []uint64
+	lastOnset uint64
+	period    float64 // Current beat period, in nanoseconds; 0 until estimated.
+	beatTime  float64 // Time of the current beat, in Unix nanoseconds.
+	beatIndex int     // Number of beats since the first onset.
+	stats     DriftStats
+	bpmSum    float64
+}
+
+// NewTracker creates a tracker with default settings.
+func NewTracker() *Tracker {
+	return &Tracker{
+		BeatsPerBar: DefaultBeatsPerBar,
+		ChordWindow: DefaultChordWindow,
+		History:     DefaultOnsetHistory,
+		MinOnsets:   DefaultMinOnsets,
+		MinBPM:      DefaultMinBPM,
+		MaxBPM:      DefaultMaxBPM,
+	}
+}
+
+// AddOnset adds a note onset at timestamp (Unix nanoseconds) and returns its metrical position.
+// Returns false for onsets that are part of a chord already counted, and while the tempo is unknown.
+func (t *Tracker) AddOnset(timestamp uint64) (Beat, bool) {
+	if t.lastOnset != 0 && timestamp < t.lastOnset+uint64(t.ChordWindow) {
+		return Beat{}, false
+	}
+	t.lastOnset = timestamp
+	t.onsets = append(t.onsets, timestamp)
+	if t.beatTime == 0 {
+		t.beatTime = float64(timestamp)
+	}
+	t.trim(timestamp)
+
+	t.updateTempo()
+	if t.period == 0 {
+		return Beat{}, false
+	}
+
+	// Advance the grid to the beat nearest to the onset.
+	now := float64(timestamp)
+	elapsed := 0
+	for now >= t.beatTime+t.period/2 {
+		t.beatTime += t.period
+		t.beatIndex++
+		elapsed++
+	}
+	offset := now - t.beatTime
+
+	// Onsets played on the beat pull the grid towards them; a timing error that persists means the
+	// period itself is off, as in a gradual accelerando or ritardando. Off-beat onsets leave the grid alone.
+	if math.Abs(offset) <= t.period*onBeatTolerance {
+		t.beatTime += offset * phaseCorrection
+		if elapsed > 0 {
+			t.period += offset * periodCorrection / float64(elapsed)
+		}
+	}
+
+	bpm := BPM(time.Duration(t.period))
+	t.record(bpm)
+	return Beat{
+		BPM:   bpm,
+		Bar:   t.beatIndex/t.BeatsPerBar + 1,
+		Beat:  t.beatIndex%t.BeatsPerBar + 1,
+		Phase: offset / t.period,
+		Drift: bpm - t.stats.InitialBPM,
+	}, true
+}
+
+// Period returns the current beat period, or 0 while the tempo is unknown.
+func (t *Tracker) Period() time.Duration {
+	return time.Duration(t.period)
+}
+
+// Stats returns the tempo drift statistics of the session.
+func (t *Tracker) Stats() DriftStats {
+	return t.stats
+}
+
+// updateTempo re-estimates the period from the onset window. Estimates close to the current tempo are
+// smoothed in; an estimate far from it replaces the tempo only when it is not a multiple of the current
+// period, so a passage of eighth notes does not double the tempo.
+func (t *Tracker) updateTempo() {
+	if len(t.onsets) < t.MinOnsets {
+		return
+	}
+	estimate, ok := EstimatePeriod(t.onsets, t.MinBPM, t.MaxBPM)
+	if !ok {
+		return
+	}
+	period := float64(estimate)
+
+	switch {
+	case t.period == 0:
+		t.period = period
+	case math.Abs(period-t.period)/t.period <= maxTempoChange:
+		t.period += (period - t.period) * tempoSmoothing
+	default:
+		ratio := period / t.period
+		if ratio < 1 {
+			ratio = 1 / ratio
+		}
+		if math.Abs(ratio-math.Round(ratio)) <= relationTolerance*math.Round(ratio) {
+			return
+		}
+		t.period = period
+	}
+}
+
+// record adds a tempo estimate to the drift statistics.
+func (t *Tracker) record(bpm float64) {
+	if t.stats.Estimates == 0 {
+		t.stats.InitialBPM = bpm
+		t.stats.MinBPM = bpm
+		t.stats.MaxBPM = bpm
+	}
+	t.stats.Estimates++
+	t.stats.CurrentBPM = bpm
+	t.stats.MinBPM = math.Min(t.stats.MinBPM, bpm)
+	t.stats.MaxBPM = math.Max(t.stats.MaxBPM, bpm)
+	t.bpmSum += bpm
+	t.stats.MeanBPM = t.bpmSum / float64(t.stats.Estimates)
+}
+
+// trim drops onsets that fell out of the history window.
+func (t *Tracker) trim(now uint64) {
+	cutoff := 0
+	for cutoff < len(t.onsets) && now-t.onsets[cutoff] > uint64(t.History) {
+		cutoff++
+	}
+	t.onsets = t.onsets[cutoff:]
+}
@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/leandrodaf/pianalyze/internal/analysis"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"go.uber.org/zap"
+)
+
+// Message types sent to WebSocket clients.
+const (
+	MessageTypeState = "state" // Sent once on connect with the latest analysis, so reconnecting clients can resync.
+	MessageTypeEvent = "event" // Sent for every processed event.
+)
+
+// Connection tuning for WebSocket clients.
+const (
+	clientSendBuffer = 64
+	writeTimeout     = 5 * time.Second
+	pongTimeout      = 60 * time.Second
+	pingInterval     = pongTimeout * 9 / 10
+	shutdownTimeout  = 5 * time.Second
+)
+
+// Message is the JSON document broadcast to WebSocket clients.
+type Message struct {
+	Type             string   `json:"type"`
+	Timestamp        uint64   `json:"timestamp"`
+	Command          byte     `json:"command"`
+	Note             string   `json:"note"`
+	Velocity         byte     `json:"velocity"`
+	Chord            string   `json:"chord"`
+	ChordSymbol      string   `json:"chordSymbol"`
+	Triad            string   `json:"triad"`
+	Inversion        string   `json:"inversion"`
+	Interval         uint64   `json:"interval"`
+	PressedNotes     []int    `json:"pressedNotes"`
+	PressedNoteNames []string `json:"pressedNoteNames"`
+	Sustain          bool     `json:"sustain"`
+	Key              string   `json:"key"`
+	KeyConfidence    float64  `json:"keyConfidence"`
+	KeyChanged       bool     `json:"keyChanged"`
+	RomanNumeral     string   `json:"romanNumeral"`
+
+	CompletedNote *analysis.CompletedNote `json:"completedNote,omitempty"`
+	Beat          *rhythm.Beat            `json:"beat,omitempty"`
+	Timing        *rhythm.Timing          `json:"timing,omitempty"`
+	ScoreReport   *follower.Report        `json:"score,omitempty"`
+}
+
+// newMessage converts a pipeline snapshot into a Message of the given type.
+func newMessage(messageType string, snapshot *sink.Snapshot) Message {
+	names := make([]string, len(snapshot.PressedNotes))
+	for i, note := range snapshot.PressedNotes {
+		names[i] = midi.GetNoteName(note)
+	}
+	return Message{
+		Type:             messageType,
+		Timestamp:        snapshot.Timestamp,
+		Command:          snapshot.Command,
+		Note:             snapshot.NoteName,
+		Velocity:         snapshot.Velocity,
+		Chord:            snapshot.Chord,
+		ChordSymbol:      snapshot.ChordSymbol,
+		Triad:            snapshot.Triad,
+		Inversion:        snapshot.Inversion,
+		Interval:         snapshot.Interval,
+		PressedNotes:     snapshot.PressedNotes,
+		PressedNoteNames: names,
+		Sustain:          snapshot.Sustain,
+		Key:              snapshot.Key,
+		KeyConfidence:    snapshot.KeyConfidence,
+		KeyChanged:       snapshot.KeyChanged,
+		RomanNumeral:     snapshot.RomanNumeral,
+		CompletedNote:    snapshot.CompletedNote,
+		Beat:             snapshot.Beat,
+		Timing:           snapshot.Timing,
+		ScoreReport:      snapshot.ScoreReport,
+	}
+}
+
+// Server is an embedded HTTP server that streams pipeline results to WebSocket clients.
+// It implements sink.Sink, so it can be attached to the pipeline like any other output.
+//
+// Endpoints:
+//
+//	/ws     WebSocket stream; a "state" message on connect followed by "event" messages
+//	/state  the latest "state" message as plain JSON
+type Server struct {
+	logger   *zap.Logger
+	http     *http.Server
+	upgrader websocket.Upgrader
+	origins  []string // Origins accepted besides the same origin and localhost.
+
+	mu      sync.RWMutex
+	clients map[*client]struct{}
+	current *sink.Snapshot
+	closed  bool
+}
+
+// client is a connected WebSocket peer with its own outgoing queue.
+type client struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithAllowedOrigins accepts WebSocket connections from pages served by the given origins (e.g.
+// "https://example.com"), besides those of the same origin or of localhost. "*" accepts any origin.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(s *Server) {
+		s.origins = append(s.origins, origins...)
+	}
+}
+
+// NewServer creates a server listening on addr (e.g. ":8080"). Call Start to begin serving.
+// WebSocket connections are only accepted from pages of the same origin or of localhost, and from the origins
+// allowed with WithAllowedOrigins, so other sites opened in a browser cannot read the stream.
+func NewServer(addr string, logger *zap.Logger, opts ...Option) *Server {
+	s := &Server{
+		logger:  logger,
+		clients: make(map[*client]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.upgrader = websocket.Upgrader{CheckOrigin: s.checkOrigin}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/state", s.handleState)
+	s.http = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: writeTimeout,
+	}
+	return s
+}
+
+// checkOrigin accepts requests without an Origin header, which do not come from browsers, and requests from
+// the same origin, from localhost or from an allowed origin.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host != "" {
+		if strings.EqualFold(u.Host, r.Host) || isLoopback(u.Hostname()) {
+			return true
+		}
+	}
+	for _, allowed := range s.origins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	s.logger.Warn(constants.MsgWebSocketOriginRejected, zap.String("origin", origin),
+		zap.String("remote", r.RemoteAddr))
+	return false
+}
+
+// isLoopback reports whether host names the local machine.
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Start binds the listening address and serves requests in the background.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	s.logger.Info(constants.MsgWebSocketServerStarted, zap.String("addr", listener.Addr().String()))
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error(constants.MsgWebSocketServerError, zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// Write stores the snapshot as the current state and broadcasts it to every connected client.
+// Clients that cannot keep up are disconnected instead of blocking the pipeline.
+func (s *Server) Write(snapshot *sink.Snapshot) error {
+	payload, err := json.Marshal(newMessage(MessageTypeEvent, snapshot))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = snapshot
+	for c := range s.clients {
+		select {
+		case c.send <- payload:
+		default:
+			s.logger.Warn(constants.MsgWebSocketClientSlow, zap.String("remote", c.conn.RemoteAddr().String()))
+			s.removeLocked(c)
+		}
+	}
+	return nil
+}
+
+// Flush does nothing; messages are sent as they are written.
+func (s *Server) Flush() error {
+	return nil
+}
+
+// Close disconnects every client and shuts the HTTP server down.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.clients {
+		s.removeLocked(c)
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.http.Shutdown(ctx)
+}
+
+// handleWebSocket upgrades the connection, sends the current state and registers the client.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Debug(constants.MsgWebSocketUpgradeError, zap.Error(err))
+		return
+	}
+
+	c := &client{conn: conn, send: make(chan []byte, clientSendBuffer)}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	// Queue the state message before registering, so it always precedes broadcast events.
+	if payload, err := json.Marshal(s.stateMessageLocked()); err == nil {
+		c.send <- payload
+	}
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+
+	s.logger.Info(constants.MsgWebSocketClientConnected, zap.String("remote", conn.RemoteAddr().String()))
+
+	go s.writePump(c)
+	s.readPump(c)
+}
+
+// handleState serves the current state message as JSON.
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	message := s.stateMessageLocked()
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(message)
+}
+
+// stateMessageLocked builds the "state" message from the latest snapshot. The caller must hold s.mu.
+func (s *Server) stateMessageLocked() Message {
+	if s.current == nil {
+		return Message{
+			Type:             MessageTypeState,
+			Chord:            constants.DefaultChord,
+			Triad:            constants.DefaultTriad,
+			Inversion:        constants.DefaultInversion,
+			PressedNotes:     []int{},
+			PressedNoteNames: []string{},
+		}
+	}
+	return newMessage(MessageTypeState, s.current)
+}
+
+// readPump discards incoming messages and unregisters the client once the connection closes.
+func (s *Server) readPump(c *client) {
+	defer func() {
+		s.mu.Lock()
+		s.removeLocked(c)
+		s.mu.Unlock()
+	}()
+
+	c.conn.SetReadLimit(512)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump sends queued messages and periodic pings until the client's queue is closed.
+func (s *Server) writePump(c *client) {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// removeLocked unregisters a client and closes its queue. The caller must hold s.mu.
+func (s *Server) removeLocked(c *client) {
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+	delete(s.clients, c)
+	close(c.send)
+	s.logger.Info(constants.MsgWebSocketClientDisconnected, zap.String("remote", c.conn.RemoteAddr().String()))
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{name: "no origin", origin: "", want: true},
+		{name: "same origin", origin: "http://piano.local:8080", want: true},
+		{name: "localhost", origin: "http://localhost:3000", want: true},
+		{name: "loopback address", origin: "http://127.0.0.1:5173", want: true},
+		{name: "IPv6 loopback address", origin: "http://[::1]:5173", want: true},
+		{name: "foreign origin", origin: "https://evil.example", want: false},
+		{name: "same host on another port", origin: "http://piano.local:9000", want: false},
+		{name: "localhost lookalike", origin: "http://localhost.evil.example", want: false},
+		{name: "invalid origin", origin: "null", want: false},
+		{name: "allowed origin", origin: "https://app.example", allowed: []string{"https://app.example/"}, want: true},
+		{name: "other than the allowed origin", origin: "https://evil.example", allowed: []string{"https://app.example"}, want: false},
+		{name: "any origin allowed", origin: "https://evil.example", allowed: []string{"*"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(":0", zap.NewNop(), WithAllowedOrigins(tt.allowed...))
+			r := httptest.NewRequest(http.MethodGet, "http://piano.local:8080/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := s.checkOrigin(r); got != tt.want {
+				t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketRejectsForeignOrigin(t *testing.T) {
+	s := NewServer(":0", zap.NewNop())
+	httpServer := httptest.NewServer(s.http.Handler)
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/ws"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("foreign origin: err %v, response %v, want 403", err, resp)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://localhost:3000"}})
+	if err != nil {
+		t.Fatalf("localhost origin: %v", err)
+	}
+	defer conn.Close()
+	var message Message
+	if err := conn.ReadJSON(&message); err != nil || message.Type != MessageTypeState {
+		t.Errorf("first message = %+v, %v, want the state", message, err)
+	}
+}
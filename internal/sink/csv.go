@@ -0,0 +1,136 @@
+package sink
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// csvHeader lists the columns written by CSVSink.
+var csvHeader = []string{
+	"timestamp", "command", "note", "noteName", "velocity", "interval", "currentKey",
+	"chord", "chordSymbol", "chordRoot", "chordBass", "triad", "inversion",
+	"pressedNotes", "soundingNotes", "sustain", "key", "keyConfidence", "keyChanged", "romanNumeral",
+	"duration", "articulation", "bpm", "bar", "beat", "phase",
+	"timingErrorMs", "timingVerdict", "scoreResult", "scorePosition", "scoreMissed",
+}
+
+// CSVSink writes one row per snapshot. Note lists are space-separated within their column, and the
+// duration of a released note is written in milliseconds.
+type CSVSink struct {
+	mu          sync.Mutex
+	out         io.WriteCloser
+	writer      *csv.Writer
+	writeHeader bool
+}
+
+// NewCSVSink creates a CSV sink writing to out. When writeHeader is true, the header row precedes the first record.
+func NewCSVSink(out io.WriteCloser, writeHeader bool) *CSVSink {
+	return &CSVSink{
+		out:         out,
+		writer:      csv.NewWriter(out),
+		writeHeader: writeHeader,
+	}
+}
+
+// Write appends the snapshot as a CSV record.
+func (s *CSVSink) Write(snapshot *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.writeHeader {
+		if err := s.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		s.writeHeader = false
+	}
+
+	var duration, articulation string
+	if snapshot.CompletedNote != nil {
+		duration = strconv.FormatInt(snapshot.CompletedNote.Duration.Milliseconds(), 10)
+		articulation = string(snapshot.CompletedNote.Articulation)
+	}
+	var bpm, bar, beat, phase string
+	if snapshot.Beat != nil {
+		bpm = strconv.FormatFloat(snapshot.Beat.BPM, 'f', 1, 64)
+		bar = strconv.Itoa(snapshot.Beat.Bar)
+		beat = strconv.Itoa(snapshot.Beat.Beat)
+		phase = strconv.FormatFloat(snapshot.Beat.Phase, 'f', 3, 64)
+	}
+	var timingError, timingVerdict string
+	if snapshot.Timing != nil {
+		timingError = strconv.FormatFloat(snapshot.Timing.ErrorMs, 'f', 1, 64)
+		timingVerdict = snapshot.Timing.Verdict
+	}
+	var scoreResult, scorePosition, scoreMissed string
+	if snapshot.ScoreReport != nil {
+		scoreResult = string(snapshot.ScoreReport.Result)
+		scorePosition = strconv.Itoa(snapshot.ScoreReport.Position)
+		scoreMissed = joinNotes(snapshot.ScoreReport.Missed)
+	}
+
+	return s.writer.Write([]string{
+		strconv.FormatUint(snapshot.Timestamp, 10),
+		strconv.Itoa(int(snapshot.Command)),
+		strconv.Itoa(int(snapshot.Note)),
+		snapshot.NoteName,
+		strconv.Itoa(int(snapshot.Velocity)),
+		strconv.FormatUint(snapshot.Interval, 10),
+		snapshot.CurrentKey,
+		snapshot.Chord,
+		snapshot.ChordSymbol,
+		snapshot.ChordRoot,
+		snapshot.ChordBass,
+		snapshot.Triad,
+		snapshot.Inversion,
+		joinNotes(snapshot.PressedNotes),
+		joinNotes(snapshot.SoundingNotes),
+		strconv.FormatBool(snapshot.Sustain),
+		snapshot.Key,
+		strconv.FormatFloat(snapshot.KeyConfidence, 'f', 3, 64),
+		strconv.FormatBool(snapshot.KeyChanged),
+		snapshot.RomanNumeral,
+		duration,
+		articulation,
+		bpm,
+		bar,
+		beat,
+		phase,
+		timingError,
+		timingVerdict,
+		scoreResult,
+		scorePosition,
+		scoreMissed,
+	})
+}
+
+// Flush writes buffered records to the underlying writer.
+func (s *CSVSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+// Close flushes buffered records and closes the underlying writer.
+func (s *CSVSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		s.out.Close()
+		return err
+	}
+	return s.out.Close()
+}
+
+// joinNotes formats a list of MIDI notes as space-separated numbers.
+func joinNotes(notes []int) string {
+	parts := make([]string, len(notes))
+	for i, note := range notes {
+		parts[i] = strconv.Itoa(note)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,228 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for HTTPSink batching.
+const (
+	DefaultHTTPBatchSize     = 50
+	DefaultHTTPFlushInterval = 2 * time.Second
+	DefaultHTTPTimeout       = 5 * time.Second
+	DefaultHTTPQueueSize     = 1000 // Snapshots waiting to be posted before new ones are dropped.
+)
+
+// ErrSinkClosed is returned when writing to a sink that was closed.
+var ErrSinkClosed = errors.New("sink is closed")
+
+// HTTPSink POSTs snapshots to an endpoint as JSON arrays.
+// Snapshots are queued and posted by a background goroutine once the batch is full or every flush interval, so a
+// slow or unreachable endpoint never holds up the pipeline. When the queue is full, snapshots are dropped and
+// counted; failed posts are reported by the next Write, Flush or Close.
+type HTTPSink struct {
+	url           string
+	client        *http.Client
+	batchSize     int
+	flushInterval time.Duration
+
+	queue   chan *Snapshot
+	flush   chan flushRequest // Flush requests, answered once the pending batch is posted.
+	stop    chan struct{}
+	done    chan struct{}
+	dropped atomic.Int64
+
+	mu     sync.Mutex
+	closed bool  // Set by Close; Write queues under mu so nothing is queued once the final batch is drained.
+	err    error // First post failure not yet reported.
+}
+
+// NewHTTPSink creates an HTTP sink posting to url with the default batching settings.
+func NewHTTPSink(url string) *HTTPSink {
+	return newHTTPSink(url, DefaultHTTPBatchSize, DefaultHTTPFlushInterval, DefaultHTTPQueueSize)
+}
+
+// newHTTPSink creates an HTTP sink and starts its posting goroutine.
+func newHTTPSink(url string, batchSize int, flushInterval time.Duration, queueSize int) *HTTPSink {
+	s := &HTTPSink{
+		url:           url,
+		client:        &http.Client{Timeout: DefaultHTTPTimeout},
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		queue:         make(chan *Snapshot, queueSize),
+		flush:         make(chan flushRequest),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write queues the snapshot without waiting for it to be posted. It returns the failure of an earlier post,
+// if any, so it gets reported.
+func (s *HTTPSink) Write(snapshot *Snapshot) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrSinkClosed
+	}
+	select {
+	case s.queue <- snapshot:
+	default:
+		s.dropped.Add(1)
+	}
+	s.mu.Unlock()
+	return s.takeErr()
+}
+
+// flushRequest asks the posting goroutine to post the queued snapshots within ctx.
+type flushRequest struct {
+	ctx   context.Context
+	reply chan error
+}
+
+// Flush posts the queued snapshots and waits for the post to complete.
+func (s *HTTPSink) Flush() error {
+	return s.FlushContext(context.Background())
+}
+
+// FlushContext posts the queued snapshots and waits for the post to complete, giving up once ctx is done. The
+// snapshots not posted by then are dropped.
+func (s *HTTPSink) FlushContext(ctx context.Context) error {
+	reply := make(chan error, 1)
+	select {
+	case s.flush <- flushRequest{ctx: ctx, reply: reply}:
+		return errors.Join(<-reply, s.takeErr())
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close posts the queued snapshots and stops the posting goroutine. It reports failed posts and the number
+// of snapshots dropped during the session.
+func (s *HTTPSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	<-s.done
+
+	err := s.takeErr()
+	if dropped := s.dropped.Load(); dropped > 0 {
+		err = errors.Join(err, fmt.Errorf("%d snapshots dropped: sink endpoint %s did not keep up", dropped, s.url))
+	}
+	return err
+}
+
+// run posts the queued snapshots in batches until the sink is closed.
+func (s *HTTPSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Snapshot
+	for {
+		select {
+		case snapshot := <-s.queue:
+			batch = append(batch, snapshot)
+			if len(batch) >= s.batchSize {
+				s.setErr(s.post(context.Background(), batch))
+				batch = nil
+			}
+		case <-ticker.C:
+			s.setErr(s.post(context.Background(), batch))
+			batch = nil
+		case request := <-s.flush:
+			request.reply <- s.post(request.ctx, s.drain(batch))
+			batch = nil
+		case <-s.stop:
+			s.setErr(s.post(context.Background(), s.drain(batch)))
+			return
+		}
+	}
+}
+
+// drain appends the snapshots waiting in the queue to batch.
+func (s *HTTPSink) drain(batch []*Snapshot) []*Snapshot {
+	for {
+		select {
+		case snapshot := <-s.queue:
+			batch = append(batch, snapshot)
+		default:
+			return batch
+		}
+	}
+}
+
+// post sends the snapshots in batches of at most batchSize, until ctx is done. Batches are dropped on failure so
+// an unreachable endpoint cannot grow memory without bound.
+func (s *HTTPSink) post(ctx context.Context, snapshots []*Snapshot) error {
+	var errs []error
+	for len(snapshots) > 0 {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("%d snapshots not posted: %w", len(snapshots), err))
+			break
+		}
+		n := min(len(snapshots), s.batchSize)
+		errs = append(errs, s.postBatch(ctx, snapshots[:n]))
+		snapshots = snapshots[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// postBatch sends a batch as a JSON array.
+func (s *HTTPSink) postBatch(ctx context.Context, batch []*Snapshot) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sink endpoint %s responded with %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+// setErr keeps the first post failure until it is reported.
+func (s *HTTPSink) setErr(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// takeErr returns the post failure not yet reported, if any.
+func (s *HTTPSink) takeErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
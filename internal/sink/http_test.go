@@ -0,0 +1,183 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recorder is an endpoint counting the snapshots posted to it.
+type recorder struct {
+	mu        sync.Mutex
+	batches   []int
+	snapshots int
+	block     chan struct{} // When not nil, requests wait until it is closed.
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.block != nil {
+		<-r.block
+	}
+	var batch []*Snapshot
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, len(batch))
+	r.snapshots += len(batch)
+}
+
+func (r *recorder) counts() (batches, snapshots int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.batches), r.snapshots
+}
+
+func TestHTTPSinkBatches(t *testing.T) {
+	endpoint := &recorder{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	s := newHTTPSink(server.URL, 10, time.Hour, 100)
+	for i := 0; i < 25; i++ {
+		if err := s.Write(&Snapshot{Note: 60}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	batches, snapshots := endpoint.counts()
+	if snapshots != 25 || batches != 3 {
+		t.Errorf("posted %d snapshots in %d batches, want 25 in 3", snapshots, batches)
+	}
+	if err := s.Write(&Snapshot{}); err != ErrSinkClosed {
+		t.Errorf("Write after Close = %v, want ErrSinkClosed", err)
+	}
+}
+
+func TestHTTPSinkFlushInterval(t *testing.T) {
+	endpoint := &recorder{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	s := newHTTPSink(server.URL, 100, 20*time.Millisecond, 100)
+	defer s.Close()
+	if err := s.Write(&Snapshot{}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// No other snapshot arrives: the ticker alone must post the batch.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, snapshots := endpoint.counts(); snapshots == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("snapshot not posted after the flush interval")
+}
+
+func TestHTTPSinkSlowEndpointDoesNotBlockWrite(t *testing.T) {
+	endpoint := &recorder{block: make(chan struct{})}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	s := newHTTPSink(server.URL, 1, time.Hour, 5)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		_ = s.Write(&Snapshot{})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("writing took %v while the endpoint was stalled", elapsed)
+	}
+	close(endpoint.block)
+
+	err := s.Close()
+	if err == nil || !strings.Contains(err.Error(), "dropped") {
+		t.Fatalf("Close = %v, want dropped snapshots reported", err)
+	}
+	_, snapshots := endpoint.counts()
+	if snapshots+int(s.dropped.Load()) != 100 {
+		t.Errorf("%d posted + %d dropped, want 100", snapshots, s.dropped.Load())
+	}
+}
+
+func TestHTTPSinkReportsFailedPosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	s := newHTTPSink(server.URL, 100, time.Hour, 100)
+	_ = s.Write(&Snapshot{})
+	if err := s.Flush(); err == nil {
+		t.Error("Flush succeeded against a failing endpoint")
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close = %v, want the failure reported only once", err)
+	}
+}
+
+func TestHTTPSinkFlushContextGivesUpAtDeadline(t *testing.T) {
+	endpoint := &recorder{block: make(chan struct{})}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	defer close(endpoint.block)
+
+	s := newHTTPSink(server.URL, 100, time.Hour, 100)
+	_ = s.Write(&Snapshot{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.FlushContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FlushContext = %v, want the deadline exceeded", err)
+	}
+	// The post was given up, so closing does not wait for the stalled endpoint either.
+	_ = s.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("flushing and closing took %v past a 50ms deadline", elapsed)
+	}
+}
+
+func TestHTTPSinkWriteRacingClose(t *testing.T) {
+	endpoint := &recorder{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	// Every snapshot written is posted, dropped or rejected as written after Close: none is lost.
+	const writers, writes = 8, 200
+	for round := 0; round < 20; round++ {
+		_, before := endpoint.counts()
+		s := newHTTPSink(server.URL, 50, time.Hour, 64)
+		var rejected atomic.Int64
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < writes; i++ {
+					if errors.Is(s.Write(&Snapshot{Note: 60}), ErrSinkClosed) {
+						rejected.Add(1)
+					}
+				}
+			}()
+		}
+		_ = s.Close()
+		wg.Wait()
+
+		_, after := endpoint.counts()
+		if got := int64(after-before) + s.dropped.Load() + rejected.Load(); got != writers*writes {
+			t.Fatalf("round %d: %d snapshots posted, dropped or rejected, want %d", round, got, writers*writes)
+		}
+	}
+}
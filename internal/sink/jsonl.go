@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// JSONLinesSink writes one JSON object per snapshot, separated by newlines.
+type JSONLinesSink struct {
+	mu      sync.Mutex
+	out     io.WriteCloser
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewJSONLinesSink creates a JSON Lines sink writing to out. Output is buffered until Flush or Close.
+func NewJSONLinesSink(out io.WriteCloser) *JSONLinesSink {
+	writer := bufio.NewWriter(out)
+	return &JSONLinesSink{
+		out:     out,
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+// Write encodes the snapshot as a single line.
+func (s *JSONLinesSink) Write(snapshot *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(snapshot)
+}
+
+// Flush writes buffered lines to the underlying writer.
+func (s *JSONLinesSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Flush()
+}
+
+// Close flushes buffered lines and closes the underlying writer.
+func (s *JSONLinesSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writer.Flush(); err != nil {
+		s.out.Close()
+		return err
+	}
+	return s.out.Close()
+}
@@ -0,0 +1,240 @@
+package sink
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leandrodaf/pianalyze/internal/analysis"
+	"github.com/leandrodaf/pianalyze/internal/follower"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/context"
+	"github.com/leandrodaf/pianalyze/internal/pipeline/store"
+	"github.com/leandrodaf/pianalyze/internal/rhythm"
+)
+
+// ErrInvalidSpec is returned when a sink specification cannot be parsed.
+var ErrInvalidSpec = errors.New("invalid sink specification")
+
+// Sink receives a snapshot of every event processed by the pipeline.
+// Implementations may buffer writes; Flush forces buffered snapshots out and Close releases resources
+// after a final flush.
+type Sink interface {
+	Write(snapshot *Snapshot) error
+	Flush() error
+	Close() error
+}
+
+// ContextFlusher is implemented by sinks whose flush waits on the network, such as HTTPSink, so that it can be
+// given up once ctx is done.
+type ContextFlusher interface {
+	FlushContext(ctx stdcontext.Context) error
+}
+
+// Snapshot is a structured, serializable view of a processed PipelineContext and the shared State.
+type Snapshot struct {
+	Timestamp     uint64   `json:"timestamp"`
+	Command       byte     `json:"command"`
+	Note          byte     `json:"note"`
+	NoteName      string   `json:"noteName"`
+	Velocity      byte     `json:"velocity"`
+	Interval      uint64   `json:"interval"`
+	CurrentKey    string   `json:"currentKey,omitempty"`
+	Chord         string   `json:"chord,omitempty"`
+	ChordSymbol   string   `json:"chordSymbol,omitempty"`
+	ChordRoot     string   `json:"chordRoot,omitempty"`
+	ChordBass     string   `json:"chordBass,omitempty"`
+	Triad         string   `json:"triad,omitempty"`
+	Inversion     string   `json:"inversion,omitempty"`
+	PressedNotes  []int    `json:"pressedNotes"`
+	SoundingNotes []int    `json:"soundingNotes"`
+	Sustain       bool     `json:"sustain"`
+	Alternatives  []string `json:"alternatives,omitempty"`
+	Key           string   `json:"key,omitempty"`
+	KeyConfidence float64  `json:"keyConfidence,omitempty"`
+	KeyChanged    bool     `json:"keyChanged,omitempty"`
+	RomanNumeral  string   `json:"romanNumeral,omitempty"`
+
+	CompletedNote *analysis.CompletedNote `json:"completedNote,omitempty"`
+	Beat          *rhythm.Beat            `json:"beat,omitempty"`
+	Timing        *rhythm.Timing          `json:"timing,omitempty"`
+	ScoreReport   *follower.Report        `json:"score,omitempty"`
+}
+
+// NewSnapshot builds a Snapshot from the pipeline context and state.
+func NewSnapshot(ctx *context.PipelineContext, state *store.State) *Snapshot {
+	snapshot := &Snapshot{
+		Timestamp:     ctx.MIDIEvent.Timestamp,
+		Command:       ctx.MIDIEvent.Command,
+		Note:          ctx.MIDIEvent.Note,
+		NoteName:      midi.GetNoteName(int(ctx.MIDIEvent.Note)),
+		Velocity:      ctx.MIDIEvent.Velocity,
+		Interval:      ctx.Interval,
+		CurrentKey:    deref(ctx.CurrentKey),
+		Chord:         deref(ctx.Chord),
+		ChordSymbol:   deref(ctx.ChordSymbol),
+		Triad:         deref(ctx.Triad),
+		Inversion:     deref(ctx.Inversion),
+		PressedNotes:  state.GetPressedNotes(),
+		SoundingNotes: state.GetSoundingNotes(),
+		Sustain:       state.IsSustainActive(),
+		CompletedNote: ctx.CompletedNote,
+		Beat:          ctx.Beat,
+		Timing:        ctx.Timing,
+		ScoreReport:   ctx.ScoreReport,
+	}
+	if ctx.ChordMatch != nil {
+		snapshot.ChordRoot = midi.PitchClassName(ctx.ChordMatch.Root)
+		snapshot.ChordBass = midi.PitchClassName(ctx.ChordMatch.Bass)
+	}
+	if ctx.Key != nil {
+		snapshot.Key = ctx.Key.String()
+		snapshot.KeyConfidence = ctx.Key.Confidence
+		snapshot.KeyChanged = ctx.KeyChanged
+	}
+	if ctx.RomanNumeral != nil {
+		snapshot.RomanNumeral = ctx.RomanNumeral.Symbol
+	}
+	for _, alternative := range ctx.ChordAlternatives {
+		snapshot.Alternatives = append(snapshot.Alternatives, alternative.Name)
+	}
+	return snapshot
+}
+
+// Parse creates a sink from a specification string:
+//
+//	jsonl:stdout          JSON Lines written to standard output
+//	jsonl:<path>          JSON Lines appended to a file
+//	csv:<path>            CSV appended to a file (a header is written to new files)
+//	http://... https://...  snapshots POSTed in JSON batches
+func Parse(spec string) (Sink, error) {
+	if err := Validate(spec); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return NewHTTPSink(spec), nil
+	}
+
+	kind, target, _ := strings.Cut(spec, ":")
+
+	switch kind {
+	case "jsonl", "json":
+		if target == "stdout" || target == "-" {
+			return NewJSONLinesSink(nopCloser{os.Stdout}), nil
+		}
+		f, err := openAppend(target)
+		if err != nil {
+			return nil, err
+		}
+		return NewJSONLinesSink(f), nil
+	case "csv":
+		if target == "stdout" || target == "-" {
+			return NewCSVSink(nopCloser{os.Stdout}, true), nil
+		}
+		f, err := openAppend(target)
+		if err != nil {
+			return nil, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return NewCSVSink(f, info.Size() == 0), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown sink type %q", ErrInvalidSpec, kind)
+	}
+}
+
+// Validate checks a specification string accepted by Parse, without creating the sink.
+func Validate(spec string) error {
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return nil
+	}
+	kind, target, found := strings.Cut(spec, ":")
+	if !found || target == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidSpec, spec)
+	}
+	switch kind {
+	case "jsonl", "json", "csv":
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown sink type %q", ErrInvalidSpec, kind)
+	}
+}
+
+// Multi fans snapshots out to several sinks, collecting every error.
+type Multi []Sink
+
+// Write sends the snapshot to every sink.
+func (m Multi) Write(snapshot *Snapshot) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Write(snapshot); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes every sink.
+func (m Multi) Flush() error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FlushContext flushes every sink, giving up on those implementing ContextFlusher once ctx is done.
+func (m Multi) FlushContext(ctx stdcontext.Context) error {
+	var errs []error
+	for _, s := range m {
+		var err error
+		if flusher, ok := s.(ContextFlusher); ok {
+			err = flusher.FlushContext(ctx)
+		} else {
+			err = s.Flush()
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink.
+func (m Multi) Close() error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// openAppend opens path for appending, creating it if needed.
+func openAppend(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// nopCloser wraps standard streams so closing a sink does not close them.
+type nopCloser struct {
+	*os.File
+}
+
+// Close does nothing.
+func (nopCloser) Close() error { return nil }
+
+// deref safely dereferences an optional string.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,105 @@
+package smf
+
+import (
+	"context"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+)
+
+// PlayerOptions defines the configuration options for a Player.
+type PlayerOptions struct {
+	RealTime        bool                       // Waits between events to reproduce the original timing.
+	MIDIEventFilter *contracts.MIDIEventFilter // Optional filter for the commands forwarded to the channel.
+}
+
+// PlayerOption is a function that modifies PlayerOptions.
+type PlayerOption func(*PlayerOptions)
+
+// WithRealTime enables or disables real-time playback.
+// When disabled, events are delivered as fast as the receiver consumes them.
+func WithRealTime(realTime bool) PlayerOption {
+	return func(opts *PlayerOptions) {
+		opts.RealTime = realTime
+	}
+}
+
+// WithMIDIEventFilter restricts playback to the given commands, matching the live client filter.
+func WithMIDIEventFilter(filter contracts.MIDIEventFilter) PlayerOption {
+	return func(opts *PlayerOptions) {
+		opts.MIDIEventFilter = &filter
+	}
+}
+
+// Player replays the events of a File into a contracts.MIDI channel, as a live client would.
+type Player struct {
+	events  []Event
+	options PlayerOptions
+}
+
+// NewPlayer creates a Player for the merged event stream of file.
+func NewPlayer(file *File, opts ...PlayerOption) *Player {
+	options := PlayerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Player{
+		events:  file.Events(),
+		options: options,
+	}
+}
+
+// Play sends every event to eventChannel and returns once the file is exhausted or ctx is canceled.
+// Timestamps are expressed in Unix nanoseconds relative to the moment playback starts, so replayed
+// events are indistinguishable from captured ones. Since contracts.MIDI carries no channel, the channel
+// nibble is dropped and all channels are merged into a single stream, like a single keyboard.
+// The channel is not closed; the caller owns it.
+func (p *Player) Play(ctx context.Context, eventChannel chan contracts.MIDI) error {
+	start := time.Now()
+	base := uint64(start.UTC().UnixNano())
+
+	for _, event := range p.events {
+		if !p.allowed(event.Command()) {
+			continue
+		}
+
+		if p.options.RealTime {
+			if wait := time.Until(start.Add(event.Time)); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		midiEvent := contracts.MIDI{
+			Timestamp: base + uint64(event.Time),
+			Command:   event.Command(),
+			Note:      event.Data1,
+			Velocity:  event.Data2,
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case eventChannel <- midiEvent:
+		}
+	}
+	return nil
+}
+
+// allowed checks the command against the configured filter, if any.
+func (p *Player) allowed(command byte) bool {
+	if p.options.MIDIEventFilter == nil {
+		return true
+	}
+	for _, allowed := range p.options.MIDIEventFilter.Commands {
+		if command == byte(allowed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,375 @@
+package smf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"time"
+)
+
+// Error definitions for malformed or unsupported Standard MIDI Files.
+var (
+	ErrInvalidHeader     = errors.New("invalid SMF header")
+	ErrUnsupportedFormat = errors.New("unsupported SMF format")
+	ErrInvalidTrack      = errors.New("invalid SMF track")
+	ErrRunningStatus     = errors.New("data byte without running status")
+)
+
+// DefaultTempo is the tempo assumed until the first Set Tempo meta event, in microseconds per quarter note (120 BPM).
+const DefaultTempo = 500000
+
+// Meta and system status bytes handled by the reader.
+const (
+	statusSysEx       = 0xF0
+	statusSysExEscape = 0xF7
+	statusMeta        = 0xFF
+	metaEndOfTrack    = 0x2F
+	metaSetTempo      = 0x51
+	metaTrackName     = 0x03
+)
+
+// Event is a channel voice message decoded from a track, positioned both in ticks and in absolute time.
+type Event struct {
+	Tick   uint64        // Absolute position in ticks from the start of the file.
+	Time   time.Duration // Absolute position in time, resolved through the tempo map.
+	Track  int           // Index of the track the event was read from.
+	Status byte          // Status byte, including the MIDI channel in its low nibble.
+	Data1  byte          // First data byte (e.g. note number or controller).
+	Data2  byte          // Second data byte (e.g. velocity or controller value), zero for one-byte messages.
+}
+
+// Command returns the message type of the event with the channel stripped (e.g. 0x90 for any Note On).
+func (e Event) Command() byte {
+	return e.Status & 0xF0
+}
+
+// Channel returns the zero-based MIDI channel of the event.
+func (e Event) Channel() byte {
+	return e.Status & 0x0F
+}
+
+// TempoChange records a Set Tempo meta event.
+type TempoChange struct {
+	Tick             uint64 // Absolute position in ticks.
+	MicrosPerQuarter uint32 // New tempo in microseconds per quarter note.
+}
+
+// File is a decoded Standard MIDI File.
+type File struct {
+	Format   uint16        // SMF format (0 or 1).
+	Division uint16        // Raw division word from the header.
+	Tracks   [][]Event     // Channel events per track, in file order.
+	Names    []string      // Track names from Sequence/Track Name meta events, if any.
+	Tempos   []TempoChange // Tempo map collected from all tracks, sorted by tick.
+}
+
+// ReadFile opens and decodes the Standard MIDI File at path.
+func ReadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read decodes a Standard MIDI File of format 0 or 1 from r.
+// Channel messages are kept with their running status resolved; system exclusive and meta events
+// other than Set Tempo and Track Name are skipped.
+func Read(r io.Reader) (*File, error) {
+	br := bufio.NewReader(r)
+
+	id, data, err := readChunk(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if id != "MThd" || len(data) < 6 {
+		return nil, ErrInvalidHeader
+	}
+
+	file := &File{
+		Format:   binary.BigEndian.Uint16(data[0:2]),
+		Division: binary.BigEndian.Uint16(data[4:6]),
+	}
+	numTracks := int(binary.BigEndian.Uint16(data[2:4]))
+
+	if file.Format > 1 {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedFormat, file.Format)
+	}
+	if err := checkDivision(file.Division); err != nil {
+		return nil, err
+	}
+
+	for len(file.Tracks) < numTracks {
+		id, data, err := readChunk(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %v", ErrInvalidTrack, len(file.Tracks), err)
+		}
+		// Unknown chunk types must be ignored according to the specification.
+		if id != "MTrk" {
+			continue
+		}
+		trackIndex := len(file.Tracks)
+		events, name, tempos, err := parseTrack(data, trackIndex)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %v", ErrInvalidTrack, trackIndex, err)
+		}
+		file.Tracks = append(file.Tracks, events)
+		file.Names = append(file.Names, name)
+		file.Tempos = append(file.Tempos, tempos...)
+	}
+
+	sort.SliceStable(file.Tempos, func(i, j int) bool { return file.Tempos[i].Tick < file.Tempos[j].Tick })
+	file.resolveTimes()
+
+	return file, nil
+}
+
+// Events returns the events of every track merged into a single stream ordered by time.
+// Events sharing the same tick keep their track order, so a type 1 file replays like a type 0 one.
+func (f *File) Events() []Event {
+	var merged []Event
+	for _, track := range f.Tracks {
+		merged = append(merged, track...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Tick < merged[j].Tick })
+	return merged
+}
+
+// Duration returns the time of the last event in the file.
+func (f *File) Duration() time.Duration {
+	var last time.Duration
+	for _, track := range f.Tracks {
+		if len(track) > 0 && track[len(track)-1].Time > last {
+			last = track[len(track)-1].Time
+		}
+	}
+	return last
+}
+
+// TickDuration converts an absolute tick position to time using the file's division and tempo map. Positions
+// beyond the longest time.Duration are clamped to it.
+func (f *File) TickDuration(tick uint64) time.Duration {
+	// SMPTE division: the high byte holds the negative frame rate and the low byte the ticks per frame.
+	if f.Division&0x8000 != 0 {
+		fps := uint64(-int8(f.Division >> 8))
+		ticksPerFrame := uint64(f.Division & 0xFF)
+		if fps == 29 {
+			// 29 stands for 29.97 drop-frame.
+			return mulDiv(tick, uint64(time.Second)*100, 2997*ticksPerFrame)
+		}
+		return mulDiv(tick, uint64(time.Second), fps*ticksPerFrame)
+	}
+
+	ppq := uint64(f.Division)
+	var (
+		elapsed  time.Duration
+		lastTick uint64
+		tempo    uint64 = DefaultTempo
+	)
+	for _, change := range f.Tempos {
+		if change.Tick >= tick {
+			break
+		}
+		elapsed = addDuration(elapsed, mulDiv(change.Tick-lastTick, tempo*uint64(time.Microsecond), ppq))
+		lastTick = change.Tick
+		tempo = uint64(change.MicrosPerQuarter)
+	}
+	return addDuration(elapsed, mulDiv(tick-lastTick, tempo*uint64(time.Microsecond), ppq))
+}
+
+// mulDiv returns value*mul/div nanoseconds, computing the product on 128 bits so it cannot overflow, clamped to
+// the longest time.Duration.
+func mulDiv(value, mul, div uint64) time.Duration {
+	hi, lo := bits.Mul64(value, mul)
+	if hi >= div {
+		return math.MaxInt64
+	}
+	quotient, _ := bits.Div64(hi, lo, div)
+	if quotient > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(quotient)
+}
+
+// addDuration returns a+b for non-negative durations, clamped to the longest time.Duration.
+func addDuration(a, b time.Duration) time.Duration {
+	if b > math.MaxInt64-a {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// resolveTimes fills the Time field of every event from its tick position.
+func (f *File) resolveTimes() {
+	for _, track := range f.Tracks {
+		for i := range track {
+			track[i].Time = f.TickDuration(track[i].Tick)
+		}
+	}
+}
+
+// checkDivision rejects a division that leaves ticks without a duration: zero ticks per quarter note, or an
+// SMPTE division with a frame rate other than 24, 25, 29 (29.97 drop-frame) or 30, or zero ticks per frame.
+func checkDivision(division uint16) error {
+	if division == 0 {
+		return fmt.Errorf("%w: zero division", ErrInvalidHeader)
+	}
+	if division&0x8000 == 0 {
+		return nil
+	}
+	switch fps := -int(int8(division >> 8)); fps {
+	case 24, 25, 29, 30:
+	default:
+		return fmt.Errorf("%w: SMPTE division with %d frames per second", ErrInvalidHeader, fps)
+	}
+	if division&0xFF == 0 {
+		return fmt.Errorf("%w: SMPTE division with zero ticks per frame", ErrInvalidHeader)
+	}
+	return nil
+}
+
+// readChunk reads a chunk type and its payload.
+func readChunk(r io.Reader) (string, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return "", nil, err
+	}
+	length := binary.BigEndian.Uint32(header[4:8])
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", nil, err
+	}
+	return string(header[0:4]), data, nil
+}
+
+// parseTrack decodes the events of a single MTrk chunk.
+func parseTrack(data []byte, trackIndex int) ([]Event, string, []TempoChange, error) {
+	var (
+		events        []Event
+		tempos        []TempoChange
+		name          string
+		tick          uint64
+		runningStatus byte
+		pos           int
+	)
+
+	for pos < len(data) {
+		delta, n, err := readVarLen(data[pos:])
+		if err != nil {
+			return nil, "", nil, err
+		}
+		pos += n
+		tick += uint64(delta)
+
+		if pos >= len(data) {
+			return nil, "", nil, io.ErrUnexpectedEOF
+		}
+		status := data[pos]
+
+		switch {
+		case status == statusMeta:
+			if pos+2 > len(data) {
+				return nil, "", nil, io.ErrUnexpectedEOF
+			}
+			metaType := data[pos+1]
+			length, n, err := readVarLen(data[pos+2:])
+			if err != nil {
+				return nil, "", nil, err
+			}
+			start := pos + 2 + n
+			end := start + int(length)
+			if end > len(data) {
+				return nil, "", nil, io.ErrUnexpectedEOF
+			}
+			payload := data[start:end]
+			pos = end
+
+			switch metaType {
+			case metaEndOfTrack:
+				return events, name, tempos, nil
+			case metaSetTempo:
+				if len(payload) == 3 {
+					tempos = append(tempos, TempoChange{
+						Tick:             tick,
+						MicrosPerQuarter: uint32(payload[0])<<16 | uint32(payload[1])<<8 | uint32(payload[2]),
+					})
+				}
+			case metaTrackName:
+				if name == "" {
+					name = string(payload)
+				}
+			}
+			// Meta events cancel running status.
+			runningStatus = 0
+
+		case status == statusSysEx || status == statusSysExEscape:
+			length, n, err := readVarLen(data[pos+1:])
+			if err != nil {
+				return nil, "", nil, err
+			}
+			pos += 1 + n + int(length)
+			if pos > len(data) {
+				return nil, "", nil, io.ErrUnexpectedEOF
+			}
+			runningStatus = 0
+
+		case status >= statusSysEx:
+			// System common and real-time messages do not occur in files and carry no running status.
+			return nil, "", nil, fmt.Errorf("unexpected system status byte %#02x", status)
+
+		default:
+			if status&0x80 != 0 {
+				runningStatus = status
+				pos++
+			} else if runningStatus == 0 {
+				return nil, "", nil, ErrRunningStatus
+			}
+
+			size := DataLength(runningStatus)
+			if pos+size > len(data) {
+				return nil, "", nil, io.ErrUnexpectedEOF
+			}
+			event := Event{Tick: tick, Track: trackIndex, Status: runningStatus, Data1: data[pos]}
+			if size == 2 {
+				event.Data2 = data[pos+1]
+			}
+			pos += size
+			events = append(events, event)
+		}
+	}
+
+	// Tolerate tracks missing the End of Track meta event.
+	return events, name, tempos, nil
+}
+
+// DataLength returns the number of data bytes that follow a channel voice status byte.
+func DataLength(status byte) int {
+	switch status & 0xF0 {
+	case 0xC0, 0xD0:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// readVarLen decodes a variable-length quantity, returning its value and the number of bytes consumed.
+func readVarLen(data []byte) (uint32, int, error) {
+	var value uint32
+	for i := 0; i < 4; i++ {
+		if i >= len(data) {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		value = value<<7 | uint32(data[i]&0x7F)
+		if data[i]&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("variable-length quantity exceeds 4 bytes")
+}
@@ -0,0 +1,211 @@
+package smf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// rawFile builds a Standard MIDI File from a header and the payloads of its tracks.
+func rawFile(format, division uint16, tracks ...[]byte) []byte {
+	var buf bytes.Buffer
+	header := make([]byte, 6)
+	binary.BigEndian.PutUint16(header[0:2], format)
+	binary.BigEndian.PutUint16(header[2:4], uint16(len(tracks)))
+	binary.BigEndian.PutUint16(header[4:6], division)
+	_ = writeChunk(&buf, "MThd", header)
+	for _, track := range tracks {
+		_ = writeChunk(&buf, "MTrk", track)
+	}
+	return buf.Bytes()
+}
+
+// smpte returns an SMPTE division word for fps frames per second and ticks per frame.
+func smpte(fps int8, ticks byte) uint16 {
+	return uint16(byte(-fps))<<8 | uint16(ticks)
+}
+
+var endOfTrack = []byte{0x00, 0xFF, 0x2F, 0x00}
+
+func TestReadTrack(t *testing.T) {
+	track := []byte{
+		0x00, 0xFF, 0x03, 0x04, 'L', 'e', 'a', 'd', // Track name.
+		0x00, 0x90, 60, 100, // Note On.
+		0x60, 64, 90, // Note On, running status, 96 ticks later.
+		0x00, 0xFF, 0x51, 0x03, 0x0F, 0x42, 0x40, // Tempo change to 1,000,000 µs per quarter.
+		0x60, 0x80, 60, 0, // Note Off.
+		0x00, 0xC0, 5, // Program change, one data byte.
+	}
+	data := rawFile(0, 96, append(track, endOfTrack...))
+
+	file, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(file.Names) != 1 || file.Names[0] != "Lead" {
+		t.Errorf("names = %q, want [Lead]", file.Names)
+	}
+	want := []Event{
+		{Tick: 0, Time: 0, Status: 0x90, Data1: 60, Data2: 100},
+		{Tick: 96, Time: 500 * time.Millisecond, Status: 0x90, Data1: 64, Data2: 90},
+		{Tick: 192, Time: 1500 * time.Millisecond, Status: 0x80, Data1: 60},
+		{Tick: 192, Time: 1500 * time.Millisecond, Status: 0xC0, Data1: 5},
+	}
+	events := file.Events()
+	if len(events) != len(want) {
+		t.Fatalf("read %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+	if got := file.Duration(); got != 1500*time.Millisecond {
+		t.Errorf("Duration() = %v, want 1.5s", got)
+	}
+}
+
+func TestTickDurationSMPTE(t *testing.T) {
+	tests := []struct {
+		division uint16
+		tick     uint64
+		want     time.Duration
+	}{
+		{smpte(25, 40), 1000, time.Second},
+		{smpte(24, 10), 240, time.Second},
+		{smpte(30, 80), 1200, 500 * time.Millisecond},
+		{smpte(29, 100), 2997, time.Second},
+	}
+	for _, tt := range tests {
+		file := &File{Division: tt.division}
+		if got := file.TickDuration(tt.tick); got != tt.want {
+			t.Errorf("TickDuration(%d) with division %#04x = %v, want %v", tt.tick, tt.division, got, tt.want)
+		}
+	}
+}
+
+func TestTickDurationLongPositions(t *testing.T) {
+	tests := []struct {
+		name string
+		file *File
+		tick uint64
+		want time.Duration
+	}{
+		// 2^30 quarter notes at 120 BPM: the product of ticks and tempo in nanoseconds exceeds 64 bits.
+		{"ticks per quarter", &File{Division: 480}, 480 << 30, (1 << 30) * 500 * time.Millisecond},
+		{
+			name: "after a tempo change",
+			file: &File{Division: 480, Tempos: []TempoChange{{Tick: 480, MicrosPerQuarter: 1000000}}},
+			tick: 480 + 480<<30,
+			want: 500*time.Millisecond + (1<<30)*time.Second,
+		},
+		{"SMPTE", &File{Division: smpte(25, 40)}, 1000 << 32, (1 << 32) * time.Second},
+		{"clamped", &File{Division: 1}, math.MaxUint64, math.MaxInt64},
+		{"clamped after a tempo change", &File{Division: 1, Tempos: []TempoChange{{Tick: 1 << 40, MicrosPerQuarter: 1}}}, 1 << 41, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.TickDuration(tt.tick); got != tt.want {
+				t.Errorf("TickDuration(%d) = %v, want %v", tt.tick, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidHeader},
+		{"not a MIDI file", []byte("RIFF\x00\x00\x00\x06abcdef"), ErrInvalidHeader},
+		{"format 2", rawFile(2, 96), ErrUnsupportedFormat},
+		{"zero division", rawFile(0, 0), ErrInvalidHeader},
+		{"SMPTE without frames per second", rawFile(0, 0x8028), ErrInvalidHeader},
+		{"SMPTE without ticks per frame", rawFile(0, smpte(25, 0)), ErrInvalidHeader},
+		{"SMPTE with a nonstandard frame rate", rawFile(0, smpte(12, 40)), ErrInvalidHeader},
+		{"missing track", rawFile(0, 96, endOfTrack)[:14], ErrInvalidTrack}, // The header announces a track.
+		{"truncated event", rawFile(0, 96, []byte{0x00, 0x90, 60}), ErrInvalidTrack},
+		{"data without running status", rawFile(0, 96, []byte{0x00, 60, 100}), ErrInvalidTrack},
+		{"data after a meta event", rawFile(0, 96, []byte{0x00, 0x90, 60, 100, 0x00, 0xFF, 0x01, 0x00, 0x00, 62, 100}), ErrInvalidTrack},
+		{"data after a sysex event", rawFile(0, 96, []byte{0x00, 0x90, 60, 100, 0x00, 0xF0, 0x01, 0xF7, 0x00, 62, 100}), ErrInvalidTrack},
+		{"system common message", rawFile(0, 96, []byte{0x00, 0xF2, 0x00, 0x00}), ErrInvalidTrack},
+		{"real-time message", rawFile(0, 96, []byte{0x00, 0x90, 60, 100, 0x00, 0xF8, 0x00, 62, 100}), ErrInvalidTrack},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(tt.data)); !errors.Is(err, tt.want) {
+				t.Errorf("Read() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	original := &File{
+		Format:   1,
+		Division: 480,
+		Tracks: [][]Event{
+			{{Tick: 0, Status: 0x90, Data1: 60, Data2: 100}, {Tick: 480, Status: 0x80, Data1: 60}},
+			{{Tick: 240, Track: 1, Status: 0xB0, Data1: 64, Data2: 127}, {Tick: 960, Track: 1, Status: 0xB0, Data1: 64}},
+		},
+		Names:  []string{"Right hand", "Pedal"},
+		Tempos: []TempoChange{{Tick: 0, MicrosPerQuarter: 600000}},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, original); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	file, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if file.Format != original.Format || file.Division != original.Division {
+		t.Errorf("header = format %d division %d, want %d %d", file.Format, file.Division, original.Format, original.Division)
+	}
+	if len(file.Tempos) != 1 || file.Tempos[0] != original.Tempos[0] {
+		t.Errorf("tempos = %+v, want %+v", file.Tempos, original.Tempos)
+	}
+	for i, track := range original.Tracks {
+		if file.Names[i] != original.Names[i] {
+			t.Errorf("track %d name = %q, want %q", i, file.Names[i], original.Names[i])
+		}
+		if len(file.Tracks[i]) != len(track) {
+			t.Fatalf("track %d has %d events, want %d", i, len(file.Tracks[i]), len(track))
+		}
+		for j, event := range track {
+			got := file.Tracks[i][j]
+			got.Time = 0
+			if got != event {
+				t.Errorf("track %d event %d = %+v, want %+v", i, j, got, event)
+			}
+		}
+	}
+	// At 600,000 µs per quarter, 960 ticks of 480 per quarter last 1.2s.
+	if got := file.Duration(); got != 1200*time.Millisecond {
+		t.Errorf("Duration() = %v, want 1.2s", got)
+	}
+}
+
+func TestWriteInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file *File
+		want error
+	}{
+		{"format 2", &File{Format: 2, Division: 96}, ErrUnsupportedFormat},
+		{"format 0 with two tracks", &File{Division: 96, Tracks: [][]Event{nil, nil}}, ErrUnsupportedFormat},
+		{"SMPTE without ticks per frame", &File{Division: smpte(25, 0)}, ErrInvalidHeader},
+	}
+	for _, tt := range tests {
+		if err := Write(io.Discard, tt.file); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Write() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
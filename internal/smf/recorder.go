@@ -0,0 +1,94 @@
+package smf
+
+import (
+	"sync"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+)
+
+// RecorderDivision is the resolution of recorded files, in ticks per quarter note.
+const RecorderDivision = 480
+
+// Recorder collects live contracts.MIDI events and writes them as a format 0 Standard MIDI File.
+// Tick positions are derived from the event Timestamp (Unix nanoseconds) at a fixed tempo of DefaultTempo,
+// so the recording keeps the exact timing of the performance.
+type Recorder struct {
+	mu         sync.Mutex
+	path       string
+	firstStamp uint64
+	events     []Event
+	closed     bool
+}
+
+// NewRecorder creates a Recorder that writes to path when Close is called.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Record appends a captured event to the recording. Events that are not channel voice messages are ignored.
+func (r *Recorder) Record(event contracts.MIDI) {
+	if event.Command < 0x80 || event.Command >= 0xF0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	if len(r.events) == 0 {
+		r.firstStamp = event.Timestamp
+	}
+	var elapsed uint64
+	if event.Timestamp > r.firstStamp {
+		elapsed = event.Timestamp - r.firstStamp
+	}
+
+	r.events = append(r.events, Event{
+		Tick:   nanosToTicks(elapsed),
+		Status: event.Command,
+		Data1:  event.Note,
+		Data2:  event.Velocity,
+	})
+}
+
+// Len returns the number of events recorded so far.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+// Path returns the destination of the recording.
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Close writes the recording to disk. Further events are ignored and subsequent calls are no-ops.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	file := &File{
+		Format:   0,
+		Division: RecorderDivision,
+		Tracks:   [][]Event{r.events},
+		Names:    []string{"Pianalyze session"},
+		Tempos:   []TempoChange{{Tick: 0, MicrosPerQuarter: DefaultTempo}},
+	}
+	return WriteFile(r.path, file)
+}
+
+// nanosToTicks converts elapsed nanoseconds to ticks at DefaultTempo and RecorderDivision.
+func nanosToTicks(nanos uint64) uint64 {
+	const nanosPerQuarter = DefaultTempo * 1000
+	// Split the computation to avoid overflowing on long sessions.
+	quarters := nanos / nanosPerQuarter
+	remainder := nanos % nanosPerQuarter
+	return quarters*RecorderDivision + remainder*RecorderDivision/nanosPerQuarter
+}
@@ -0,0 +1,153 @@
+package smf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Write encodes file as a Standard MIDI File of format 0 or 1.
+// The tempo map is written as meta events at the start of the first track, and every track is
+// terminated with an End of Track meta event. Running status is used for consecutive channel messages.
+func Write(w io.Writer, file *File) error {
+	if file.Format > 1 {
+		return fmt.Errorf("%w: %d", ErrUnsupportedFormat, file.Format)
+	}
+	if file.Format == 0 && len(file.Tracks) > 1 {
+		return fmt.Errorf("%w: format 0 requires a single track", ErrUnsupportedFormat)
+	}
+	if err := checkDivision(file.Division); err != nil {
+		return err
+	}
+
+	tracks := file.Tracks
+	if len(tracks) == 0 {
+		tracks = [][]Event{nil}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 6)
+	binary.BigEndian.PutUint16(header[0:2], file.Format)
+	binary.BigEndian.PutUint16(header[2:4], uint16(len(tracks)))
+	binary.BigEndian.PutUint16(header[4:6], file.Division)
+	if err := writeChunk(bw, "MThd", header); err != nil {
+		return err
+	}
+
+	for i, track := range tracks {
+		var tempos []TempoChange
+		if i == 0 {
+			tempos = file.Tempos
+		}
+		name := ""
+		if i < len(file.Names) {
+			name = file.Names[i]
+		}
+		if err := writeChunk(bw, "MTrk", encodeTrack(track, tempos, name)); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile encodes file to path. The data is written to a temporary file first and renamed into place,
+// so an interrupted write never leaves a truncated file behind.
+func WriteFile(path string, file *File) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, file); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeChunk writes a chunk type followed by its length and payload.
+func writeChunk(w io.Writer, id string, data []byte) error {
+	var header [8]byte
+	copy(header[0:4], id)
+	binary.BigEndian.PutUint32(header[4:8], uint32(len(data)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// trackItem is either a meta event or a channel event waiting to be encoded in tick order.
+type trackItem struct {
+	tick  uint64
+	meta  []byte // Complete meta event (0xFF, type, length, payload), or nil for channel events.
+	event Event
+}
+
+// encodeTrack serializes the events of a track, interleaving the tempo map and the optional track name.
+func encodeTrack(events []Event, tempos []TempoChange, name string) []byte {
+	var items []trackItem
+	if name != "" {
+		meta := append([]byte{statusMeta, metaTrackName}, encodeVarLen(uint32(len(name)))...)
+		items = append(items, trackItem{meta: append(meta, name...)})
+	}
+	for _, tempo := range tempos {
+		t := tempo.MicrosPerQuarter
+		items = append(items, trackItem{
+			tick: tempo.Tick,
+			meta: []byte{statusMeta, metaSetTempo, 3, byte(t >> 16), byte(t >> 8), byte(t)},
+		})
+	}
+	for _, event := range events {
+		items = append(items, trackItem{tick: event.Tick, event: event})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].tick < items[j].tick })
+
+	var (
+		buf           bytes.Buffer
+		lastTick      uint64
+		runningStatus byte
+	)
+	for _, item := range items {
+		buf.Write(encodeVarLen(uint32(item.tick - lastTick)))
+		lastTick = item.tick
+
+		if item.meta != nil {
+			buf.Write(item.meta)
+			runningStatus = 0
+			continue
+		}
+
+		if item.event.Status != runningStatus {
+			buf.WriteByte(item.event.Status)
+			runningStatus = item.event.Status
+		}
+		buf.WriteByte(item.event.Data1 & 0x7F)
+		if DataLength(item.event.Status) == 2 {
+			buf.WriteByte(item.event.Data2 & 0x7F)
+		}
+	}
+
+	buf.Write([]byte{0x00, statusMeta, metaEndOfTrack, 0x00})
+	return buf.Bytes()
+}
+
+// encodeVarLen encodes a value as a variable-length quantity.
+func encodeVarLen(value uint32) []byte {
+	out := []byte{byte(value & 0x7F)}
+	for value >>= 7; value > 0; value >>= 7 {
+		out = append([]byte{byte(value&0x7F) | 0x80}, out...)
+	}
+	return out
+}
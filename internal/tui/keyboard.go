@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/leandrodaf/pianalyze/internal/midi"
+)
+
+// Range of an 88-key piano.
+const (
+	LowestKey  = 21  // A0
+	HighestKey = 108 // C8
+	whiteKeys  = 52
+)
+
+// ANSI styles of the keyboard cells.
+const (
+	styleReset     = "\x1b[0m"
+	styleWhiteKey  = "\x1b[30;47m"
+	styleBlackKey  = "\x1b[37;40m"
+	stylePressed   = "\x1b[30;42m" // Key held down.
+	styleSounding  = "\x1b[30;46m" // Key released but still sounding through the sustain pedal.
+	styleRollNote  = "\x1b[32m"
+	styleHighlight = "\x1b[1m"
+	styleDim       = "\x1b[2m"
+)
+
+// isBlack reports whether a MIDI note is a black key.
+func isBlack(note int) bool {
+	switch note % 12 {
+	case 1, 3, 6, 8, 10:
+		return true
+	default:
+		return false
+	}
+}
+
+// layout maps the keys of the keyboard to screen columns. The wide layout draws white keys two columns wide
+// with black keys between them, like a piano; the narrow layout gives every key a single column.
+type layout struct {
+	wide bool
+}
+
+// newLayout picks the wide layout when the terminal is wide enough for it.
+func newLayout(width int) layout {
+	return layout{wide: width >= 2*whiteKeys+2}
+}
+
+// width returns the number of columns of the keyboard.
+func (l layout) width() int {
+	if l.wide {
+		return 2 * whiteKeys
+	}
+	return HighestKey - LowestKey + 1
+}
+
+// column returns the screen column of a key, or false for notes outside the range of the keyboard.
+func (l layout) column(note int) (int, bool) {
+	if note < LowestKey || note > HighestKey {
+		return 0, false
+	}
+	if !l.wide {
+		return note - LowestKey, true
+	}
+	white := 0
+	for n := LowestKey; n < note; n++ {
+		if !isBlack(n) {
+			white++
+		}
+	}
+	if isBlack(note) {
+		// Between the white key below and the one above.
+		return 2*white - 1, true
+	}
+	return 2 * white, true
+}
+
+// keyState is how a key is drawn.
+type keyState byte
+
+const (
+	keyUp keyState = iota
+	keyPressed
+	keySounding
+)
+
+// keyStyle returns the style of a key in a given state.
+func keyStyle(note int, state keyState) string {
+	switch state {
+	case keyPressed:
+		return stylePressed
+	case keySounding:
+		return styleSounding
+	}
+	if isBlack(note) {
+		return styleBlackKey
+	}
+	return styleWhiteKey
+}
+
+// renderKeyboard draws the keyboard: two rows where black keys show, one row of white keys only, and a row
+// of octave labels under every C.
+func renderKeyboard(l layout, states map[int]keyState) []string {
+	cells := func(upper bool) string {
+		var b strings.Builder
+		for note := LowestKey; note <= HighestKey; note++ {
+			black := isBlack(note)
+			switch {
+			case l.wide && black:
+				// Drawn with the white key below it.
+				continue
+			case l.wide:
+				b.WriteString(keyStyle(note, states[note]))
+				b.WriteByte(' ')
+				next := note + 1
+				switch {
+				case upper && next <= HighestKey && isBlack(next):
+					b.WriteString(keyStyle(next, states[next]))
+					b.WriteByte(' ')
+				case note%12 == 4 || note%12 == 11 || note == HighestKey || !upper:
+					// Gap between two white keys.
+					b.WriteString("│")
+				default:
+					b.WriteByte(' ')
+				}
+			case black && !upper:
+				b.WriteString(styleWhiteKey + "│")
+			default:
+				b.WriteString(keyStyle(note, states[note]))
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(styleReset)
+		return b.String()
+	}
+
+	labels := []byte(strings.Repeat(" ", l.width()))
+	for note := LowestKey; note <= HighestKey; note++ {
+		if note%12 == 0 {
+			name := midi.GetNoteName(note)
+			column, _ := l.column(note)
+			copy(labels[column:min(column+len(name), len(labels))], name)
+		}
+	}
+	return []string{cells(true), cells(true), cells(false), styleDim + string(labels) + styleReset}
+}
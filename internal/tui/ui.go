@@ -0,0 +1,336 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/sink"
+	"golang.org/x/term"
+)
+
+// Display settings.
+const (
+	DefaultRollRows  = 12                     // Rows of the piano roll.
+	DefaultRollStep  = 100 * time.Millisecond // Time covered by a row of the piano roll.
+	DefaultFrameRate = 20                     // Frames drawn per second.
+	messageLines     = 6                      // Lines of exercise output kept on screen.
+	fallbackWidth    = 100                    // Terminal size assumed when it cannot be read.
+	fallbackHeight   = 40
+)
+
+// ANSI sequences controlling the terminal.
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	leaveAltScreen = "\x1b[?25h\x1b[?1049l"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+	clearBelow     = "\x1b[J"
+)
+
+// span is a note of the piano roll, from press to release.
+type span struct {
+	note    int
+	on, off uint64 // Unix nanoseconds; off is 0 while the key is held.
+}
+
+// UI is a full-screen terminal view of the session: an 88-key keyboard highlighting the keys held and
+// sounding, the current chord, inversion, triad and key, a scrolling piano roll of the recent notes and the
+// session status. It implements sink.Sink and redraws at a fixed frame rate on the alternate screen.
+type UI struct {
+	Device    string
+	RollRows  int
+	RollStep  time.Duration
+	FrameRate int
+
+	out   *os.File
+	now   func() time.Time
+	start time.Time
+
+	mu       sync.Mutex
+	snapshot *sink.Snapshot
+	notes    int
+	spans    []span
+	active   map[int]int // Index in spans of the held notes.
+	messages []string
+	partial  []byte // Exercise output not yet terminated by a newline.
+	frame    string
+
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// New creates a UI drawing to out for the named device.
+func New(out *os.File, device string) *UI {
+	return &UI{
+		Device:    device,
+		RollRows:  DefaultRollRows,
+		RollStep:  DefaultRollStep,
+		FrameRate: DefaultFrameRate,
+		out:       out,
+		now:       time.Now,
+		active:    make(map[int]int),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Start switches to the alternate screen and starts drawing.
+func (u *UI) Start() {
+	u.start = u.now()
+	_, _ = io.WriteString(u.out, enterAltScreen)
+	go u.loop()
+}
+
+// Messages returns a writer whose lines are shown under the keyboard, for lesson and drill feedback.
+func (u *UI) Messages() io.Writer {
+	return messageWriter{u}
+}
+
+// Write records a processed event for the next frame.
+func (u *UI) Write(snapshot *sink.Snapshot) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.snapshot = snapshot
+	note := int(snapshot.Note)
+	switch {
+	case snapshot.Command == byte(contracts.NoteOn) && snapshot.Velocity > 0:
+		u.notes++
+		u.release(note, snapshot.Timestamp)
+		u.active[note] = len(u.spans)
+		u.spans = append(u.spans, span{note: note, on: snapshot.Timestamp})
+	case snapshot.Command == byte(contracts.NoteOn) || snapshot.Command == byte(contracts.NoteOff):
+		u.release(note, snapshot.Timestamp)
+	}
+	u.prune(snapshot.Timestamp)
+	return nil
+}
+
+// Flush does nothing; frames are drawn at the frame rate.
+func (u *UI) Flush() error {
+	return nil
+}
+
+// Close draws a last frame and restores the terminal.
+func (u *UI) Close() error {
+	u.once.Do(func() {
+		close(u.stop)
+		if !u.start.IsZero() {
+			<-u.stopped
+			_, _ = io.WriteString(u.out, leaveAltScreen)
+		}
+	})
+	return nil
+}
+
+// loop draws a frame whenever the view changes, until Close.
+func (u *UI) loop() {
+	defer close(u.stopped)
+	ticker := time.NewTicker(time.Second / time.Duration(max(u.FrameRate, 1)))
+	defer ticker.Stop()
+	for {
+		u.draw()
+		select {
+		case <-ticker.C:
+		case <-u.stop:
+			return
+		}
+	}
+}
+
+// draw renders the screen and writes it if it changed since the last frame.
+func (u *UI) draw() {
+	width, height, err := term.GetSize(int(u.out.Fd()))
+	if err != nil {
+		width, height = fallbackWidth, fallbackHeight
+	}
+
+	u.mu.Lock()
+	lines := u.render(width, u.now())
+	u.mu.Unlock()
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	frame := cursorHome + strings.Join(lines, clearLine+"\r\n") + clearLine + clearBelow
+	if frame == u.frame {
+		return
+	}
+	u.frame = frame
+	_, _ = io.WriteString(u.out, frame)
+}
+
+// render builds the lines of the screen at time now.
+func (u *UI) render(width int, now time.Time) []string {
+	l := newLayout(width)
+	s := u.snapshot
+	if s == nil {
+		s = &sink.Snapshot{}
+	}
+
+	elapsed := now.Sub(u.start).Truncate(time.Second)
+	status := fmt.Sprintf(styleHighlight+" pianalyze"+styleReset+"  device: %s  elapsed: %s  notes: %d",
+		u.Device, formatElapsed(elapsed), u.notes)
+	if s.Beat != nil {
+		status += fmt.Sprintf("  tempo: %.0f BPM (bar %d, beat %d)", s.Beat.BPM, s.Beat.Bar, s.Beat.Beat)
+	}
+	if s.Sustain {
+		status += "  sustain"
+	}
+
+	chord := "-"
+	if s.ChordSymbol != "" {
+		chord = fmt.Sprintf("%s (%s)", s.ChordSymbol, s.Chord)
+	}
+	key := orDash(s.Key)
+	if s.Key != "" {
+		key = fmt.Sprintf("%s (%.0f%%)", s.Key, s.KeyConfidence*100)
+	}
+
+	lines := []string{
+		status,
+		"",
+		fmt.Sprintf(" Chord: "+styleHighlight+"%s"+styleReset+"  Inversion: %s  Triad: %s",
+			chord, orDash(s.Inversion), orDash(s.Triad)),
+		fmt.Sprintf(" Key: %s  Roman numeral: %s  Note key: %s",
+			key, orDash(s.RomanNumeral), orDash(s.CurrentKey)),
+		feedbackLine(s),
+		"",
+	}
+	lines = append(lines, u.renderRoll(l, uint64(now.UnixNano()))...)
+	lines = append(lines, renderKeyboard(l, keyStates(s))...)
+	lines = append(lines, "")
+	lines = append(lines, u.messages...)
+	return append(lines, "", styleDim+" Press Ctrl+C to stop."+styleReset)
+}
+
+// renderRoll draws the recent notes, oldest at the top and the present just above the keyboard.
+func (u *UI) renderRoll(l layout, now uint64) []string {
+	step := uint64(u.RollStep)
+	lines := make([]string, u.RollRows)
+	for row := range lines {
+		// Row covers [from, to); the last row ends now.
+		to := now - uint64(u.RollRows-1-row)*step
+		from := to - step
+		cells := []byte(strings.Repeat(" ", l.width()))
+		for _, sp := range u.spans {
+			column, ok := l.column(sp.note)
+			if ok && sp.on < to && (sp.off == 0 || sp.off > from) {
+				cells[column] = '#'
+			}
+		}
+		lines[row] = styleRollNote + strings.ReplaceAll(string(cells), "#", "█") + styleReset
+	}
+	return lines
+}
+
+// release ends the roll span of a held note.
+func (u *UI) release(note int, timestamp uint64) {
+	if i, ok := u.active[note]; ok {
+		u.spans[i].off = max(timestamp, u.spans[i].on+1)
+		delete(u.active, note)
+	}
+}
+
+// prune drops the spans that scrolled off the piano roll.
+func (u *UI) prune(now uint64) {
+	window := uint64(u.RollRows) * uint64(u.RollStep)
+	kept := u.spans[:0]
+	for _, sp := range u.spans {
+		if sp.off == 0 || sp.off+window > now {
+			kept = append(kept, sp)
+		}
+	}
+	u.spans = kept
+	for i, sp := range u.spans {
+		if sp.off == 0 {
+			u.active[sp.note] = i
+		}
+	}
+}
+
+// addMessage appends a line of exercise output, keeping the last messageLines.
+func (u *UI) addMessage(line string) {
+	u.messages = append(u.messages, " "+line)
+	if len(u.messages) > messageLines {
+		u.messages = u.messages[len(u.messages)-messageLines:]
+	}
+}
+
+// messageWriter splits exercise output into the message lines of the UI.
+type messageWriter struct {
+	u *UI
+}
+
+// Write adds every complete line of p to the messages.
+func (w messageWriter) Write(p []byte) (int, error) {
+	w.u.mu.Lock()
+	defer w.u.mu.Unlock()
+	w.u.partial = append(w.u.partial, p...)
+	for {
+		i := bytes.IndexByte(w.u.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimRight(string(w.u.partial[:i]), " "); line != "" {
+			w.u.addMessage(line)
+		}
+		w.u.partial = w.u.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// keyStates returns how every key of the snapshot is drawn.
+func keyStates(s *sink.Snapshot) map[int]keyState {
+	states := make(map[int]keyState, len(s.SoundingNotes))
+	for _, note := range s.SoundingNotes {
+		states[note] = keySounding
+	}
+	for _, note := range s.PressedNotes {
+		states[note] = keyPressed
+	}
+	return states
+}
+
+// feedbackLine describes the timing and score results of the last event, when available.
+func feedbackLine(s *sink.Snapshot) string {
+	var parts []string
+	if s.Timing != nil {
+		parts = append(parts, fmt.Sprintf("Timing: %s (%+.0f ms)", s.Timing.Verdict, s.Timing.ErrorMs))
+	}
+	if s.ScoreReport != nil {
+		parts = append(parts, fmt.Sprintf("Score: %s, %.0f%% done", s.ScoreReport.Result, s.ScoreReport.Progress*100))
+	}
+	if s.CompletedNote != nil {
+		parts = append(parts, fmt.Sprintf("Last note: %s, %s",
+			s.CompletedNote.Duration.Round(time.Millisecond), s.CompletedNote.Articulation))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, "  ")
+}
+
+// formatElapsed formats a duration as mm:ss, or h:mm:ss past an hour.
+func formatElapsed(d time.Duration) string {
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// orDash returns value, or "-" when it is empty.
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRenderRoll(t *testing.T) {
+	now := uint64(time.Hour)
+	for _, tc := range []struct {
+		name      string
+		width     int
+		notes     []int
+		wantNotes []int // Notes drawn on the keyboard.
+	}{
+		{name: "narrow", width: 80, notes: []int{0, 12, 21, 60, 108, 120, 127}, wantNotes: []int{21, 60, 108}},
+		{name: "wide", width: 200, notes: []int{0, 12, 21, 61, 108, 120, 127}, wantNotes: []int{21, 61, 108}},
+		{name: "out of range only", width: 80, notes: []int{0, 127}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u := New(nil, "test")
+			for _, note := range tc.notes {
+				u.spans = append(u.spans, span{note: note, on: now - uint64(time.Second)})
+			}
+			l := newLayout(tc.width)
+
+			lines := u.renderRoll(l, now)
+			if len(lines) != u.RollRows {
+				t.Fatalf("got %d rows, want %d", len(lines), u.RollRows)
+			}
+			row := strings.TrimSuffix(strings.TrimPrefix(lines[len(lines)-1], styleRollNote), styleReset)
+			if got := utf8.RuneCountInString(row); got != l.width() {
+				t.Fatalf("row is %d columns wide, want %d", got, l.width())
+			}
+			cells := []rune(row)
+			drawn := 0
+			for _, cell := range cells {
+				if cell == '█' {
+					drawn++
+				}
+			}
+			if drawn != len(tc.wantNotes) {
+				t.Errorf("drew %d notes, want %d", drawn, len(tc.wantNotes))
+			}
+			for _, note := range tc.wantNotes {
+				column, _ := l.column(note)
+				if cells[column] != '█' {
+					t.Errorf("note %d not drawn in column %d", note, column)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutColumn(t *testing.T) {
+	for _, tc := range []struct {
+		wide   bool
+		note   int
+		want   int
+		wantOK bool
+	}{
+		{wide: false, note: LowestKey, want: 0, wantOK: true},
+		{wide: false, note: HighestKey, want: HighestKey - LowestKey, wantOK: true},
+		{wide: true, note: LowestKey, want: 0, wantOK: true},
+		{wide: true, note: LowestKey + 1, want: 1, wantOK: true}, // A#0, between A0 and B0.
+		{wide: true, note: HighestKey, want: 2 * (whiteKeys - 1), wantOK: true},
+		{wide: false, note: 0},
+		{wide: false, note: LowestKey - 1},
+		{wide: true, note: HighestKey + 1},
+		{wide: true, note: 127},
+	} {
+		got, ok := layout{wide: tc.wide}.column(tc.note)
+		if ok != tc.wantOK || got != tc.want {
+			t.Errorf("column(%d) wide=%v = %d, %v; want %d, %v", tc.note, tc.wide, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package virtual
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+)
+
+// Error definitions for virtual client operations.
+var (
+	ErrNoMIDIDevices     = errors.New("no MIDI devices found")
+	ErrInvalidMIDIDevice = errors.New("invalid MIDI device")
+	ErrNotCapturing      = errors.New("virtual client is not capturing")
+	ErrInvalidScript     = errors.New("invalid virtual client script")
+)
+
+// Defaults applied to virtual devices and scripted notes.
+const (
+	DefaultDeviceName   = "Pianalyze Virtual Keyboard"
+	DefaultManufacturer = "Pianalyze"
+	DefaultVelocity     = 100
+)
+
+// Client is an in-memory implementation of contracts.ClientMIDI.
+// Events can be pushed from Go code with Send or played from a Script once capture starts,
+// which allows device selection and the pipeline to be exercised without hardware.
+type Client struct {
+	mu              sync.Mutex
+	devices         []contracts.DeviceInfo
+	defaultDevices  bool // True while devices only holds the default device.
+	selected        int
+	selectedName    string                 // Name of the selected device.
+	disconnected    bool                   // True once the selected device is unplugged, until a device is selected again.
+	unplugged       []contracts.DeviceInfo // Devices removed by an unplug step, restored by a plug step.
+	script          *Script
+	midiEventFilter *contracts.MIDIEventFilter
+	eventChannel    chan contracts.MIDI
+	capturing       bool
+	stop            chan struct{} // Closed by Stop to interrupt playback and pending sends.
+	done            chan struct{} // Closed when the script finishes playing or capture stops.
+	wg              sync.WaitGroup
+}
+
+// NewMIDIClient creates a virtual client. The MIDI event filter from opts is honored like on hardware clients;
+// other options are accepted for signature compatibility with midi.NewMIDIClient.
+func NewMIDIClient(opts ...contracts.Option) *Client {
+	options := contracts.ClientOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Client{
+		devices: []contracts.DeviceInfo{{
+			Name:         DefaultDeviceName,
+			Manufacturer: DefaultManufacturer,
+			EntityName:   DefaultDeviceName,
+		}},
+		defaultDevices:  true,
+		selected:        -1,
+		midiEventFilter: options.MIDIEventFilter,
+		done:            make(chan struct{}),
+	}
+}
+
+// AddDevice registers a device returned by ListDevices, replacing the default device on first use.
+func (c *Client) AddDevice(device contracts.DeviceInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addDevicesLocked(device)
+}
+
+// SetDevices replaces the devices returned by ListDevices, simulating devices being plugged or unplugged.
+func (c *Client) SetDevices(devices []contracts.DeviceInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.devices = append([]contracts.DeviceInfo(nil), devices...)
+	c.defaultDevices = false
+	c.updateConnectionLocked()
+}
+
+// LoadScript sets the script played when capture starts. Devices declared by the script are added to the client.
+// If neither the script nor the caller declares a device, a single default device is exposed.
+func (c *Client) LoadScript(script *Script) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.script = script
+	c.addDevicesLocked(script.Devices...)
+}
+
+// addDevicesLocked appends devices, dropping the default device first. The caller must hold c.mu.
+func (c *Client) addDevicesLocked(devices ...contracts.DeviceInfo) {
+	if len(devices) == 0 {
+		return
+	}
+	if c.defaultDevices {
+		c.devices = nil
+		c.defaultDevices = false
+	}
+	c.devices = append(c.devices, devices...)
+}
+
+// ListDevices returns the virtual devices, or ErrNoMIDIDevices if every device was removed.
+func (c *Client) ListDevices() ([]contracts.DeviceInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.devices) == 0 {
+		return nil, ErrNoMIDIDevices
+	}
+	devices := make([]contracts.DeviceInfo, len(c.devices))
+	copy(devices, c.devices)
+	return devices, nil
+}
+
+// SelectDevice selects a virtual device by its index in ListDevices.
+func (c *Client) SelectDevice(deviceID int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if deviceID < 0 || deviceID >= len(c.devices) {
+		return ErrInvalidMIDIDevice
+	}
+	c.selected = deviceID
+	c.selectedName = c.devices[deviceID].Name
+	c.disconnected = false
+	return nil
+}
+
+// SelectedDevice returns the index of the selected device, or -1 if none was selected.
+func (c *Client) SelectedDevice() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.selected
+}
+
+// StartCapture starts delivering events to eventChannel and begins playing the loaded script, if any.
+// A capture already in progress is stopped first.
+func (c *Client) StartCapture(eventChannel chan contracts.MIDI) {
+	if eventChannel == nil {
+		return
+	}
+	_ = c.Stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eventChannel = eventChannel
+	c.capturing = true
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	if c.script == nil {
+		return
+	}
+	c.wg.Add(1)
+	go c.play(c.script.Steps, eventChannel, c.stop, c.done)
+}
+
+// Stop interrupts script playback and stops delivering events. It waits for in-flight sends,
+// so the caller may close the event channel once Stop returns. Calling Stop more than once is safe.
+func (c *Client) Stop() error {
+	c.mu.Lock()
+	if !c.capturing {
+		c.mu.Unlock()
+		return nil
+	}
+	c.capturing = false
+	close(c.stop)
+	c.mu.Unlock()
+
+	c.wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eventChannel = nil
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	return nil
+}
+
+// Done returns a channel closed when the script has been fully played or capture stops.
+func (c *Client) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+
+// Send delivers an event to the capture channel, stamping it with the current time.
+// It blocks until the event is accepted or capture stops, and returns ErrNotCapturing if no capture is active.
+func (c *Client) Send(event contracts.MIDI) error {
+	c.mu.Lock()
+	if !c.capturing {
+		c.mu.Unlock()
+		return ErrNotCapturing
+	}
+	eventChannel, stop := c.eventChannel, c.stop
+	c.wg.Add(1)
+	c.mu.Unlock()
+	defer c.wg.Done()
+
+	return c.deliver(event, eventChannel, stop)
+}
+
+// NoteOn sends a Note On event.
+func (c *Client) NoteOn(note, velocity byte) error {
+	return c.Send(contracts.MIDI{Command: byte(contracts.NoteOn), Note: note, Velocity: velocity})
+}
+
+// NoteOff sends a Note Off event.
+func (c *Client) NoteOff(note byte) error {
+	return c.Send(contracts.MIDI{Command: byte(contracts.NoteOff), Note: note})
+}
+
+// ControlChange sends a Control Change event.
+func (c *Client) ControlChange(controller, value byte) error {
+	return c.Send(contracts.MIDI{Command: constants.ControlChangeCommand, Note: controller, Velocity: value})
+}
+
+// play runs the script steps until they are exhausted or stop is closed.
+func (c *Client) play(steps []Step, eventChannel chan contracts.MIDI, stop, done chan struct{}) {
+	defer c.wg.Done()
+
+	for _, step := range steps {
+		switch step.Kind {
+		case StepWait:
+			timer := time.NewTimer(step.Wait)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		case StepEvent:
+			if err := c.deliver(step.Event, eventChannel, stop); err != nil {
+				return
+			}
+		case StepUnplug:
+			c.unplug(step.Device)
+		case StepPlug:
+			c.plug(step.Device)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-done:
+	default:
+		close(done)
+	}
+}
+
+// unplug removes the devices named name, or the selected device when name is empty, from ListDevices.
+func (c *Client) unplug(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name == "" {
+		name = c.selectedName
+	}
+	var kept []contracts.DeviceInfo
+	for _, device := range c.devices {
+		if name == "" || device.Name == name {
+			c.unplugged = append(c.unplugged, device)
+		} else {
+			kept = append(kept, device)
+		}
+	}
+	c.devices = kept
+	c.defaultDevices = false
+	c.updateConnectionLocked()
+}
+
+// plug adds the unplugged devices named name, or every unplugged device when name is empty, back to ListDevices.
+// Like hardware, a device plugged back delivers events again only once it is selected.
+func (c *Client) plug(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var kept []contracts.DeviceInfo
+	for _, device := range c.unplugged {
+		if name == "" || device.Name == name {
+			c.devices = append(c.devices, device)
+		} else {
+			kept = append(kept, device)
+		}
+	}
+	c.unplugged = kept
+}
+
+// updateConnectionLocked disconnects the selected device once it is no longer listed. The caller must hold c.mu.
+func (c *Client) updateConnectionLocked() {
+	if c.selected < 0 {
+		return
+	}
+	for _, device := range c.devices {
+		if device.Name == c.selectedName {
+			return
+		}
+	}
+	c.disconnected = true
+}
+
+// deliver applies the event filter, stamps the event and sends it unless stop is closed first. Events of a
+// disconnected device are dropped, as they would never reach the computer.
+func (c *Client) deliver(event contracts.MIDI, eventChannel chan contracts.MIDI, stop chan struct{}) error {
+	if c.midiEventFilter != nil && !isCommandAllowed(event.Command, c.midiEventFilter.Commands) {
+		return nil
+	}
+	c.mu.Lock()
+	disconnected := c.disconnected
+	c.mu.Unlock()
+	if disconnected {
+		return nil
+	}
+	event.Timestamp = uint64(time.Now().UTC().UnixNano())
+	select {
+	case <-stop:
+		return ErrNotCapturing
+	case eventChannel <- event:
+		return nil
+	}
+}
+
+// isCommandAllowed verifies if a MIDI command is allowed based on the event filter configuration.
+func isCommandAllowed(command byte, allowedCommands []contracts.MIDICommand) bool {
+	for _, allowedCommand := range allowedCommands {
+		if command == byte(allowedCommand) {
+			return true
+		}
+	}
+	return false
+}
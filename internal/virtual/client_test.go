@@ -0,0 +1,200 @@
+package virtual
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+)
+
+// receive returns the next captured event, failing the test if none arrives in time.
+func receive(t *testing.T, events chan contracts.MIDI) contracts.MIDI {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event captured")
+		return contracts.MIDI{}
+	}
+}
+
+// waitDone fails the test unless the client reports its script played in time.
+func waitDone(t *testing.T, client *Client) {
+	t.Helper()
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed")
+	}
+}
+
+func loadScript(t *testing.T, client *Client, source string) {
+	t.Helper()
+	script, err := ParseScript(strings.NewReader(source))
+	if err != nil {
+		t.Fatalf("ParseScript: %v", err)
+	}
+	client.LoadScript(script)
+}
+
+func deviceNames(t *testing.T, client *Client) []string {
+	t.Helper()
+	devices, err := client.ListDevices()
+	if errors.Is(err, ErrNoMIDIDevices) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("ListDevices: %v", err)
+	}
+	names := make([]string, len(devices))
+	for i, device := range devices {
+		names[i] = device.Name
+	}
+	return names
+}
+
+func TestClientPlaysScript(t *testing.T) {
+	client := NewMIDIClient()
+	loadScript(t, client, "on C4\nwait 10ms\noff C4")
+	if names := deviceNames(t, client); len(names) != 1 || names[0] != DefaultDeviceName {
+		t.Fatalf("devices = %v, want the default device", names)
+	}
+
+	events := make(chan contracts.MIDI, 4)
+	client.StartCapture(events)
+	on, off := receive(t, events), receive(t, events)
+	waitDone(t, client)
+	if on.Command != byte(contracts.NoteOn) || on.Note != 60 || off.Command != byte(contracts.NoteOff) {
+		t.Errorf("played %+v, %+v, want C4 on and off", on, off)
+	}
+	if off.Timestamp-on.Timestamp < uint64(10*time.Millisecond) {
+		t.Errorf("events %v apart, want the 10ms wait", time.Duration(off.Timestamp-on.Timestamp))
+	}
+	if err := client.Stop(); err != nil {
+		t.Errorf("Stop: %v", err)
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	client := NewMIDIClient(contracts.WithMIDIEventFilter(contracts.MIDIEventFilter{
+		Commands: []contracts.MIDICommand{contracts.NoteOn},
+	}))
+	if err := client.NoteOn(60, 100); !errors.Is(err, ErrNotCapturing) {
+		t.Fatalf("NoteOn before capture = %v, want ErrNotCapturing", err)
+	}
+
+	events := make(chan contracts.MIDI, 4)
+	client.StartCapture(events)
+	// Note Off is filtered out, like on hardware clients.
+	if err := client.NoteOff(60); err != nil {
+		t.Fatalf("NoteOff: %v", err)
+	}
+	if err := client.NoteOn(62, 80); err != nil {
+		t.Fatalf("NoteOn: %v", err)
+	}
+	if event := receive(t, events); event.Note != 62 || event.Velocity != 80 {
+		t.Errorf("captured %+v, want D4 at velocity 80", event)
+	}
+
+	// Without a script, Done is closed only once capture stops.
+	select {
+	case <-client.Done():
+		t.Fatal("Done closed while capturing without a script")
+	default:
+	}
+	if err := client.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	waitDone(t, client)
+	if err := client.NoteOn(60, 100); !errors.Is(err, ErrNotCapturing) {
+		t.Errorf("NoteOn after Stop = %v, want ErrNotCapturing", err)
+	}
+	if err := client.Stop(); err != nil {
+		t.Errorf("second Stop: %v", err)
+	}
+}
+
+func TestClientStopInterruptsScript(t *testing.T) {
+	client := NewMIDIClient()
+	loadScript(t, client, "on C4\nwait 1h\noff C4")
+	events := make(chan contracts.MIDI, 4)
+	client.StartCapture(events)
+	receive(t, events)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- client.Stop() }()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop waited for the script")
+	}
+	waitDone(t, client)
+	if len(events) != 0 {
+		t.Errorf("%d events captured after Stop", len(events))
+	}
+}
+
+func TestClientUnplugAndPlug(t *testing.T) {
+	client := NewMIDIClient()
+	loadScript(t, client, `device Piano
+device Synth
+on C4
+unplug
+on D4
+plug Piano`)
+	if err := client.SelectDevice(0); err != nil {
+		t.Fatalf("SelectDevice: %v", err)
+	}
+
+	events := make(chan contracts.MIDI, 4)
+	client.StartCapture(events)
+	waitDone(t, client)
+	// D4 was played while the piano was unplugged and never reaches the computer.
+	if event := receive(t, events); event.Note != 60 || len(events) != 0 {
+		t.Fatalf("captured %+v and %d more, want only C4", event, len(events))
+	}
+	if names := deviceNames(t, client); len(names) != 2 || names[0] != "Synth" || names[1] != "Piano" {
+		t.Fatalf("devices = %v, want the piano plugged back after the synth", names)
+	}
+
+	// A device plugged back delivers events again once it is selected.
+	_ = client.Stop()
+	client.LoadScript(&Script{})
+	client.StartCapture(events)
+	if err := client.NoteOn(64, 100); err != nil {
+		t.Fatalf("NoteOn: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("captured %d events before the piano was selected again", len(events))
+	}
+	if err := client.SelectDevice(1); err != nil {
+		t.Fatalf("SelectDevice: %v", err)
+	}
+	if err := client.NoteOn(64, 100); err != nil {
+		t.Fatalf("NoteOn: %v", err)
+	}
+	if event := receive(t, events); event.Note != 64 {
+		t.Errorf("captured %+v, want E4", event)
+	}
+	_ = client.Stop()
+}
+
+func TestClientUnplugEveryDevice(t *testing.T) {
+	client := NewMIDIClient()
+	if err := client.SelectDevice(0); err != nil {
+		t.Fatalf("SelectDevice: %v", err)
+	}
+	client.SetDevices(nil)
+	if _, err := client.ListDevices(); !errors.Is(err, ErrNoMIDIDevices) {
+		t.Errorf("ListDevices = %v, want ErrNoMIDIDevices", err)
+	}
+	if err := client.SelectDevice(0); !errors.Is(err, ErrInvalidMIDIDevice) {
+		t.Errorf("SelectDevice = %v, want ErrInvalidMIDIDevice", err)
+	}
+}
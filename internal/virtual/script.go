@@ -0,0 +1,216 @@
+package virtual
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+	"github.com/leandrodaf/pianalyze/internal/midi"
+)
+
+// StepKind identifies the action performed by a script step.
+type StepKind int
+
+const (
+	// StepEvent sends a MIDI event to the capture channel.
+	StepEvent StepKind = iota
+	// StepWait pauses playback for the step duration.
+	StepWait
+	// StepUnplug removes a device from ListDevices, as if it were unplugged.
+	StepUnplug
+	// StepPlug adds an unplugged device back to ListDevices.
+	StepPlug
+)
+
+// Step is a single instruction of a virtual client script.
+type Step struct {
+	Kind  StepKind
+	Event contracts.MIDI // Event to send; Timestamp is assigned at playback time.
+	Wait  time.Duration  // Pause duration for StepWait.
+	// Device unplugged or plugged back by StepUnplug and StepPlug; empty for the selected device, or for every
+	// unplugged device when plugging.
+	Device string
+}
+
+// Script is a parsed virtual client script: the devices it declares and the steps it plays.
+type Script struct {
+	Devices []contracts.DeviceInfo
+	Steps   []Step
+}
+
+// LoadScript reads and parses the script file at path.
+func LoadScript(path string) (*Script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseScript(f)
+}
+
+// ParseScript parses a line-based script. Blank lines and lines starting with '#' are ignored.
+// Supported instructions:
+//
+//	device <name>                  declares a device returned by ListDevices
+//	on <note> [velocity]           Note On (velocity defaults to 100)
+//	off <note>                     Note Off
+//	chord <note> <note>... [vel=N] Note On for several notes at once
+//	release <note> <note>...       Note Off for several notes at once
+//	cc <controller> <value>        Control Change
+//	wait <duration>                pause, e.g. 250ms or 1s
+//	unplug [name]                  removes a device (the selected one by default) from ListDevices
+//	plug [name]                    adds an unplugged device (every one by default) back to ListDevices
+//
+// Notes are MIDI numbers or names such as C4, F#3 or Bb2.
+func ParseScript(r io.Reader) (*Script, error) {
+	script := &Script{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		args := fields[1:]
+
+		var err error
+		switch strings.ToLower(fields[0]) {
+		case "device":
+			if len(args) == 0 {
+				err = fmt.Errorf("device requires a name")
+				break
+			}
+			name := strings.Trim(strings.Join(args, " "), `"`)
+			script.Devices = append(script.Devices, contracts.DeviceInfo{
+				Name:         name,
+				Manufacturer: DefaultManufacturer,
+				EntityName:   name,
+			})
+		case "on":
+			err = script.addNotes(args, byte(contracts.NoteOn), 1, true)
+		case "off":
+			err = script.addNotes(args, byte(contracts.NoteOff), 1, false)
+		case "chord":
+			err = script.addNotes(args, byte(contracts.NoteOn), -1, true)
+		case "release":
+			err = script.addNotes(args, byte(contracts.NoteOff), -1, false)
+		case "cc":
+			if len(args) != 2 {
+				err = fmt.Errorf("cc requires a controller and a value")
+				break
+			}
+			var controller, value byte
+			if controller, err = parseDataByte(args[0]); err != nil {
+				break
+			}
+			if value, err = parseDataByte(args[1]); err != nil {
+				break
+			}
+			script.Steps = append(script.Steps, Step{
+				Kind:  StepEvent,
+				Event: contracts.MIDI{Command: constants.ControlChangeCommand, Note: controller, Velocity: value},
+			})
+		case "wait":
+			if len(args) != 1 {
+				err = fmt.Errorf("wait requires a duration")
+				break
+			}
+			var wait time.Duration
+			if wait, err = time.ParseDuration(args[0]); err != nil {
+				break
+			}
+			script.Steps = append(script.Steps, Step{Kind: StepWait, Wait: wait})
+		case "unplug", "plug":
+			kind := StepUnplug
+			if strings.ToLower(fields[0]) == "plug" {
+				kind = StepPlug
+			}
+			script.Steps = append(script.Steps, Step{Kind: kind, Device: strings.Trim(strings.Join(args, " "), `"`)})
+		default:
+			err = fmt.Errorf("unknown instruction %q", fields[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidScript, lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// addNotes appends Note On or Note Off steps. maxNotes limits the number of notes (-1 for any).
+// For Note On, the velocity is either the second argument of a single-note instruction or a "vel=N" argument.
+func (s *Script) addNotes(args []string, command byte, maxNotes int, withVelocity bool) error {
+	velocity := byte(DefaultVelocity)
+	var notes []byte
+
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "vel=") && withVelocity {
+			v, err := parseDataByte(strings.TrimPrefix(arg, "vel="))
+			if err != nil {
+				return err
+			}
+			velocity = v
+			continue
+		}
+		if maxNotes == 1 && i == 1 && withVelocity {
+			v, err := parseDataByte(arg)
+			if err != nil {
+				return err
+			}
+			velocity = v
+			continue
+		}
+		note, err := parseNote(arg)
+		if err != nil {
+			return err
+		}
+		notes = append(notes, note)
+	}
+
+	if len(notes) == 0 {
+		return fmt.Errorf("at least one note is required")
+	}
+	if maxNotes > 0 && len(notes) > maxNotes {
+		return fmt.Errorf("too many notes")
+	}
+
+	if !withVelocity {
+		velocity = 0
+	}
+	for _, note := range notes {
+		s.Steps = append(s.Steps, Step{
+			Kind:  StepEvent,
+			Event: contracts.MIDI{Command: command, Note: note, Velocity: velocity},
+		})
+	}
+	return nil
+}
+
+// parseNote accepts a MIDI note number or a note name.
+func parseNote(value string) (byte, error) {
+	if number, ok := midi.ParseNoteName(value); ok {
+		return byte(number), nil
+	}
+	return parseDataByte(value)
+}
+
+// parseDataByte parses a MIDI data byte (0-127).
+func parseDataByte(value string) (byte, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 || number > 127 {
+		return 0, fmt.Errorf("invalid data byte %q", value)
+	}
+	return byte(number), nil
+}
@@ -0,0 +1,101 @@
+package virtual
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leandrodaf/midi/sdk/contracts"
+	"github.com/leandrodaf/pianalyze/internal/constants"
+)
+
+func noteOn(note, velocity byte) Step {
+	return Step{Kind: StepEvent, Event: contracts.MIDI{Command: byte(contracts.NoteOn), Note: note, Velocity: velocity}}
+}
+
+func noteOff(note byte) Step {
+	return Step{Kind: StepEvent, Event: contracts.MIDI{Command: byte(contracts.NoteOff), Note: note}}
+}
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		devices []string
+		steps   []Step
+	}{
+		{name: "empty", script: "\n# only a comment\n"},
+		{name: "device", script: `device "Digital Piano"`, devices: []string{"Digital Piano"}},
+		{name: "note on with the default velocity", script: "on C4", steps: []Step{noteOn(60, DefaultVelocity)}},
+		{name: "note on with a velocity", script: "on 61 40", steps: []Step{noteOn(61, 40)}},
+		{name: "note off", script: "off Bb2", steps: []Step{noteOff(46)}},
+		{
+			name:   "chord and release",
+			script: "chord C4 E4 G4 vel=90\nrelease C4 E4 G4",
+			steps: []Step{
+				noteOn(60, 90), noteOn(64, 90), noteOn(67, 90),
+				noteOff(60), noteOff(64), noteOff(67),
+			},
+		},
+		{
+			name:   "control change",
+			script: "cc 64 127",
+			steps: []Step{{Kind: StepEvent, Event: contracts.MIDI{
+				Command: constants.ControlChangeCommand, Note: constants.SustainPedalController, Velocity: 127,
+			}}},
+		},
+		{name: "wait", script: "wait 250ms", steps: []Step{{Kind: StepWait, Wait: 250 * time.Millisecond}}},
+		{
+			name:   "unplug and plug",
+			script: "unplug\nplug \"Digital Piano\"",
+			steps:  []Step{{Kind: StepUnplug}, {Kind: StepPlug, Device: "Digital Piano"}},
+		},
+		{name: "case-insensitive instructions", script: "  ON C4  ", steps: []Step{noteOn(60, DefaultVelocity)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := ParseScript(strings.NewReader(tt.script))
+			if err != nil {
+				t.Fatalf("ParseScript: %v", err)
+			}
+			var devices []string
+			for _, device := range script.Devices {
+				devices = append(devices, device.Name)
+			}
+			if !reflect.DeepEqual(devices, tt.devices) {
+				t.Errorf("devices = %v, want %v", devices, tt.devices)
+			}
+			if !reflect.DeepEqual(script.Steps, tt.steps) {
+				t.Errorf("steps = %+v, want %+v", script.Steps, tt.steps)
+			}
+		})
+	}
+}
+
+func TestParseScriptInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		line   string
+	}{
+		{name: "unknown instruction", script: "strum C4", line: "line 1"},
+		{name: "device without a name", script: "on C4\ndevice", line: "line 2"},
+		{name: "note without a note", script: "on", line: "line 1"},
+		{name: "note out of range", script: "on 128", line: "line 1"},
+		{name: "unknown note name", script: "off H4", line: "line 1"},
+		{name: "velocity out of range", script: "on C4 200", line: "line 1"},
+		{name: "several notes on", script: "on C4 E4 G4", line: "line 1"},
+		{name: "cc without a value", script: "cc 64", line: "line 1"},
+		{name: "invalid duration", script: "# comment\n\nwait soon", line: "line 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScript(strings.NewReader(tt.script))
+			if !errors.Is(err, ErrInvalidScript) || !strings.Contains(err.Error(), tt.line) {
+				t.Errorf("ParseScript() error = %v, want ErrInvalidScript at %s", err, tt.line)
+			}
+		})
+	}
+}
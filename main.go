@@ -1,8 +1,163 @@
 package main
 
-import "github.com/leandrodaf/pianalyze/cmd"
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leandrodaf/pianalyze/cmd"
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	name    string
+	args    string // Positional arguments, shown in the usage.
+	summary string
+	run     func(fs *flag.FlagSet, args []string)
+}
+
+// commands lists the subcommands in the order shown by the usage.
+var commands = []command{
+	{"devices", "", "List the available MIDI devices", runDevices},
+	{"listen", "", "Capture from a MIDI device and analyze what is played", runListen},
+	{"record", "<file>", "Capture like listen and record the session to a Standard MIDI File", runRecord},
+	{"replay", "<file>", "Play a Standard MIDI File through the pipeline in real time", runReplay},
+	{"analyze", "<file>", "Analyze a Standard MIDI File as fast as possible (jsonl:stdout unless outputs are configured)", runAnalyze},
+	{"drill", "chords|scales", "Practice random chords or scales", runDrill},
+	{"lesson", "<file>", "Work through a lesson file", runLesson},
+	{"progress", "", "Show the practice progress, points and achievements of a profile", runProgress},
+}
 
 // The main entry point for the MIDI client application.
 func main() {
-	cmd.Start() // Starts the MIDI client process.
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage()
+		return
+	}
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n\n%s.\n\nFlags:\n", os.Args[0], c.name, c.args, c.summary)
+			fs.PrintDefaults()
+		}
+		c.run(fs, os.Args[2:])
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the commands of the CLI.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s <command> [flags] [arguments]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-24s %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Fprintf(out, "\nRun \"%s <command> -h\" for the flags of a command.\n", os.Args[0])
+}
+
+// parse parses the flags of a command, which may come before or after its positional arguments, and checks
+// it got exactly want positional arguments, exiting with the command usage otherwise.
+func parse(fs *flag.FlagSet, args []string, want int) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != want {
+		fs.Usage()
+		os.Exit(2)
+	}
+	return positional
+}
+
+// runDevices lists the MIDI devices.
+func runDevices(fs *flag.FlagSet, args []string) {
+	virtualScript := fs.String("virtual", "", "List the device of a virtual MIDI client playing the given script instead of hardware")
+	logLevel := fs.String("log-level", "", "Minimum level logged: debug, info, warn or error")
+	configuration := configFlags(fs)
+	parse(fs, args, 0)
+	cmd.ListDevices(configuration(), cmd.WithVirtualScript(*virtualScript), cmd.WithLogLevel(*logLevel))
+}
+
+// runListen captures and analyzes a session.
+func runListen(fs *flag.FlagSet, args []string) {
+	capture := captureFlags(fs)
+	parse(fs, args, 0)
+	cmd.Start(capture()...)
+}
+
+// runRecord captures a session and records it to the file given as argument.
+func runRecord(fs *flag.FlagSet, args []string) {
+	capture := captureFlags(fs)
+	path := parse(fs, args, 1)[0]
+	cmd.Start(append(capture(), cmd.WithRecordPath(path))...)
+}
+
+// runReplay plays the file given as argument through the pipeline in real time.
+func runReplay(fs *flag.FlagSet, args []string) {
+	output := outputFlags(fs)
+	analysis := analysisFlags(fs)
+	configuration := configFlags(fs)
+	duration := fs.Duration("duration", 0, "Stop the replay after this long (0 plays the whole file)")
+	path := parse(fs, args, 1)[0]
+	opts := append(append(output(), analysis()...), configuration())
+	if isSet(fs, "duration") {
+		opts = append(opts, cmd.WithDuration(*duration))
+	}
+	cmd.Replay(path, true, opts...)
+}
+
+// runAnalyze processes the file given as argument as fast as possible.
+func runAnalyze(fs *flag.FlagSet, args []string) {
+	output := outputFlags(fs)
+	analysis := analysisFlags(fs)
+	configuration := configFlags(fs)
+	path := parse(fs, args, 1)[0]
+	cmd.Analyze(path, append(append(output(), analysis()...), configuration())...)
+}
+
+// runDrill captures a session running the chord or scale drill given as argument.
+func runDrill(fs *flag.FlagSet, args []string) {
+	capture := captureFlags(fs)
+	exercise := drillFlags(fs)
+	kind := parse(fs, args, 1)[0]
+	drill, err := exercise(kind)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		os.Exit(2)
+	}
+	cmd.Start(append(capture(), drill)...)
+}
+
+// runLesson captures a session running the lesson file given as argument.
+func runLesson(fs *flag.FlagSet, args []string) {
+	capture := captureFlags(fs)
+	path := parse(fs, args, 1)[0]
+	cmd.Start(append(capture(), cmd.WithLesson(path))...)
+}
+
+// runProgress prints the practice progress of a profile.
+func runProgress(fs *flag.FlagSet, args []string) {
+	profile := fs.String("profile", "default", "Practice profile to show")
+	period := fs.String("by", "day", "Period of the report: day or week")
+	periods := fs.Int("periods", 7, "Periods shown in the report")
+	parse(fs, args, 0)
+	cmd.ShowProgress(*profile, *period, *periods)
 }